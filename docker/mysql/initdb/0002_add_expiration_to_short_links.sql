@@ -0,0 +1,4 @@
+ALTER TABLE short_links
+    ADD COLUMN max_visits INT UNSIGNED NULL AFTER visitors,
+    ADD COLUMN expires_at TIMESTAMP NULL AFTER max_visits,
+    ADD INDEX idx_short_links_expires_at (expires_at);
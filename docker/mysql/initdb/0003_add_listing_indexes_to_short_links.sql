@@ -0,0 +1,8 @@
+ALTER TABLE short_links
+    ADD COLUMN destination_host VARCHAR(255) NOT NULL DEFAULT '' AFTER destination,
+    ADD INDEX idx_short_links_destination_host (destination_host),
+    ADD INDEX idx_short_links_created_at_id (created_at, id),
+    ADD INDEX idx_short_links_visitors_id (visitors, id);
+
+UPDATE short_links
+SET destination_host = LOWER(SUBSTRING_INDEX(SUBSTRING_INDEX(SUBSTRING_INDEX(destination, '://', -1), '/', 1), ':', 1));
@@ -0,0 +1,10 @@
+CREATE TABLE clicks (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    short_link_id CHAR(36) NOT NULL,
+    clicked_at TIMESTAMP(3) NOT NULL,
+    referrer VARCHAR(512) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip_prefix VARCHAR(64) NOT NULL DEFAULT '',
+    accept_language VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX idx_clicks_short_link_id_clicked_at (short_link_id, clicked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+ALTER TABLE short_links
+    ADD COLUMN redirect_type SMALLINT UNSIGNED NOT NULL DEFAULT 301 AFTER destination_host;
@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE AFTER email;
+
+CREATE TABLE domain_rules (
+    id CHAR(36) PRIMARY KEY,
+    domain VARCHAR(255) NOT NULL UNIQUE,
+    type VARCHAR(8) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by CHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
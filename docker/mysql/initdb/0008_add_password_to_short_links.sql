@@ -0,0 +1,2 @@
+ALTER TABLE short_links
+    ADD COLUMN password_hash VARCHAR(60) NOT NULL DEFAULT '' AFTER redirect_type;
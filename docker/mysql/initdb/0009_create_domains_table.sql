@@ -0,0 +1,11 @@
+CREATE TABLE domains (
+    id CHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_by CHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+ALTER TABLE short_links
+    ADD COLUMN domain VARCHAR(255) NOT NULL DEFAULT '' AFTER owner_id,
+    DROP INDEX slash_code,
+    ADD UNIQUE INDEX idx_short_links_domain_slash_code (domain, slash_code);
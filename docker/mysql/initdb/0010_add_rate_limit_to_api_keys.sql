@@ -0,0 +1,2 @@
+ALTER TABLE api_keys
+    ADD COLUMN rate_limit_multiplier DECIMAL(6,2) NOT NULL DEFAULT 1.00 AFTER revoked_at;
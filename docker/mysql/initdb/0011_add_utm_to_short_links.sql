@@ -0,0 +1,7 @@
+ALTER TABLE short_links
+    ADD COLUMN utm_source VARCHAR(255) NOT NULL DEFAULT '' AFTER password_hash,
+    ADD COLUMN utm_medium VARCHAR(255) NOT NULL DEFAULT '' AFTER utm_source,
+    ADD COLUMN utm_campaign VARCHAR(255) NOT NULL DEFAULT '' AFTER utm_medium,
+    ADD COLUMN utm_term VARCHAR(255) NOT NULL DEFAULT '' AFTER utm_campaign,
+    ADD COLUMN utm_content VARCHAR(255) NOT NULL DEFAULT '' AFTER utm_term,
+    ADD COLUMN passthrough VARCHAR(8) NOT NULL DEFAULT 'off' AFTER utm_content;
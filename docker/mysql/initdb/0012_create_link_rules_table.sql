@@ -0,0 +1,14 @@
+CREATE TABLE link_rules (
+    id CHAR(36) PRIMARY KEY,
+    short_link_id CHAR(36) NOT NULL,
+    position INT NOT NULL,
+    os VARCHAR(16) NOT NULL DEFAULT '',
+    device VARCHAR(16) NOT NULL DEFAULT '',
+    countries VARCHAR(512) NULL,
+    languages VARCHAR(512) NULL,
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+    destination VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_link_rules_short_link_id_position (short_link_id, position)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+CREATE TABLE link_variants (
+    id CHAR(36) PRIMARY KEY,
+    short_link_id CHAR(36) NOT NULL,
+    position INT NOT NULL,
+    destination VARCHAR(512) NOT NULL,
+    weight INT UNSIGNED NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_link_variants_short_link_id_position (short_link_id, position)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+ALTER TABLE clicks
+    ADD COLUMN variant_id CHAR(36) NULL AFTER short_link_id;
@@ -0,0 +1,39 @@
+CREATE TABLE webhooks (
+    id CHAR(36) PRIMARY KEY,
+    owner_id CHAR(36) NOT NULL,
+    url VARCHAR(512) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events VARCHAR(512) NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_webhooks_owner_id (owner_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE webhook_deliveries (
+    id CHAR(36) PRIMARY KEY,
+    webhook_id CHAR(36) NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(512) NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_webhook_deliveries_webhook_id (webhook_id),
+    INDEX idx_webhook_deliveries_next_attempt_at (next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE webhook_dead_letters (
+    id CHAR(36) PRIMARY KEY,
+    webhook_id CHAR(36) NOT NULL,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL,
+    last_error VARCHAR(512) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    failed_at TIMESTAMP NOT NULL,
+    INDEX idx_webhook_dead_letters_webhook_id_failed_at (webhook_id, failed_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
+
+CREATE TABLE webhook_event_keys (
+    `key` VARCHAR(128) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE utf8mb4_unicode_ci;
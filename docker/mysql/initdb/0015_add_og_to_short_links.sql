@@ -0,0 +1,4 @@
+ALTER TABLE short_links
+    ADD COLUMN og_title VARCHAR(255) NOT NULL DEFAULT '' AFTER passthrough,
+    ADD COLUMN og_description VARCHAR(512) NOT NULL DEFAULT '' AFTER og_title,
+    ADD COLUMN og_image VARCHAR(512) NOT NULL DEFAULT '' AFTER og_description;
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"url-shortener/database"
+	"url-shortener/domain"
+	"url-shortener/logs"
+	"url-shortener/repositories"
+	"url-shortener/usecases"
+	"url-shortener/utils/slashcode"
+	"url-shortener/utils/validation"
+
+	"github.com/google/uuid"
+)
+
+func runCommand(args []string) {
+	logs.NewLogger()
+	defer logs.Close()
+
+	initTimezone()
+
+	switch args[0] {
+	case "create-user":
+		createUser(args[1:])
+	case "import":
+		importLinks(args[1:])
+	case "export":
+		exportLinks(args[1:])
+	case "claim-links":
+		claimLinks(args[1:])
+	default:
+		log.Fatalf("unknown command: %v", args[0])
+	}
+}
+
+func createUser(args []string) {
+	flags := flag.NewFlagSet("create-user", flag.ExitOnError)
+	keyName := flags.String("key-name", "default", "name of the first api key")
+	admin := flags.Bool("admin", false, "allow the user to manage domain rules")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("usage: create-user [-key-name name] [-admin] <email>")
+	}
+
+	req := &domain.CreateUserRequest{Email: flags.Arg(0), IsAdmin: *admin}
+	if errs := validator.ValidateStruct(req); errs != nil {
+		log.Fatal(errs[0].Message)
+	}
+
+	db := database.NewConnection()
+	userUcase := usecases.NewUserUsecase(repositories.NewUserRepository(db))
+	apiKeyUcase := usecases.NewAPIKeyUsecase(repositories.NewAPIKeyRepository(db))
+
+	user, err := userUcase.CreateUser(req)
+	if err != nil {
+		log.Fatalf("can't create user: %v", err)
+	}
+
+	apiKey, err := apiKeyUcase.CreateAPIKey(user.ID, &domain.CreateAPIKeyRequest{Name: *keyName})
+	if err != nil {
+		log.Fatalf("can't create api key: %v", err)
+	}
+
+	fmt.Printf("user id: %v\napi key: %v\n", user.ID, apiKey.Key)
+}
+
+func importLinks(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	email := flags.String("email", "", "owner of the imported links")
+	format := flags.String("format", "", "csv or ndjson (default from the file extension)")
+	flags.Parse(args)
+
+	if *email == "" || flags.NArg() != 1 {
+		log.Fatal("usage: import -email <email> [-format csv|ndjson] <file>")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("can't open file: %v", err)
+	}
+	defer file.Close()
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(file.Name()), ".")
+	}
+
+	ownerID, shortLinkUcase := linkCommandSetup(*email)
+	result, err := shortLinkUcase.ImportShortLinks(ownerID, *format, file)
+	if err != nil {
+		log.Fatalf("can't import links: %v", err)
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "line %v: %v\n", e.Line, e.Error)
+	}
+	fmt.Printf("total: %v\ncreated: %v\nfailed: %v\n", result.Total, result.Created, result.Failed)
+}
+
+func exportLinks(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	email := flags.String("email", "", "owner of the exported links")
+	format := flags.String("format", "csv", "csv or ndjson")
+	output := flags.String("o", "", "output file (default stdout)")
+	flags.Parse(args)
+
+	if *email == "" {
+		log.Fatal("usage: export -email <email> [-format csv|ndjson] [-o file]")
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("can't create file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	buf := bufio.NewWriter(w)
+	ownerID, shortLinkUcase := linkCommandSetup(*email)
+	if err := shortLinkUcase.ExportShortLinks(ownerID, *format, buf); err != nil {
+		log.Fatalf("can't export links: %v", err)
+	}
+	if err := buf.Flush(); err != nil {
+		log.Fatalf("can't export links: %v", err)
+	}
+}
+
+// claimLinks gives the links created before links had owners to a user, so
+// they can be managed through the API again.
+func claimLinks(args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: claim-links <email>")
+	}
+
+	ownerID, shortLinkUcase := linkCommandSetup(args[0])
+	claimed, err := shortLinkUcase.ClaimUnownedShortLinks(ownerID)
+	if err != nil {
+		log.Fatalf("can't claim links: %v", err)
+	}
+	fmt.Printf("claimed: %v\n", claimed)
+}
+
+func linkCommandSetup(email string) (uuid.UUID, domain.ShortLinkUsecase) {
+	db := database.NewConnection()
+	rdb := database.NewRedis()
+	userUcase := usecases.NewUserUsecase(repositories.NewUserRepository(db))
+
+	user, err := userUcase.FindByEmail(email)
+	if err != nil {
+		log.Fatalf("can't find user %v: %v", email, err)
+	}
+
+	generator, slashLength := slashcode.NewFromEnv(rdb)
+	policyUcase := usecases.NewDestinationPolicyFromEnv(repositories.NewDomainRuleRepository(db))
+	shortLinkUcase := usecases.NewShortLinkUsecase(usecases.ShortLinkUsecaseOptions{
+		ShortLinkRepo: repositories.NewShortLinkRepository(db, rdb, 0, 0),
+		ClickRepo:     repositories.NewClickRepository(db),
+		Policy:        policyUcase,
+		Domains:       usecases.NewDomainUsecase(repositories.NewDomainRepository(db)),
+		Webhooks:      usecases.NewWebhookUsecaseFromEnv(repositories.NewWebhookRepository(db), policyUcase),
+		Generator:     generator,
+		SlashLength:   slashLength,
+	})
+	return user.ID, shortLinkUcase
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 	"url-shortener/database"
 	"url-shortener/handlers"
 	"url-shortener/helpers"
 	"url-shortener/logs"
+	"url-shortener/middleware"
 	"url-shortener/routes"
 
 	"github.com/bytedance/sonic"
@@ -20,8 +25,9 @@ import (
 )
 
 var (
-	app     *fiber.App
-	factory *handlers.Factory
+	app        *fiber.App
+	metricsApp *fiber.App
+	factory    *handlers.Factory
 )
 
 func bootstrap() {
@@ -42,10 +48,13 @@ func initRoutes() {
 	app.Use(logger.New())
 	app.Use(recover.New())
 	app.Use(helmet.New())
+	app.Use(middleware.Metrics())
 
 	api := app.Group("/api")
 	routes.NewWebRoutes(app, factory)
 	routes.NewAPIRoutes(api, factory)
+
+	routes.NewMetricsRoutes(metricsApp)
 }
 
 func initTimezone() {
@@ -57,6 +66,16 @@ func initTimezone() {
 	time.Local = loc
 }
 
+// shutdownDelay is how long readiness fails before the server stops, giving
+// the orchestrator time to notice.
+func shutdownDelay() time.Duration {
+	delay, err := time.ParseDuration(helpers.Getenv("SHUTDOWN_DELAY", "5s"))
+	if err != nil || delay < 0 {
+		return 5 * time.Second
+	}
+	return delay
+}
+
 func errorHandler(c *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 	message := "Internal Server Error"
@@ -72,17 +91,56 @@ func errorHandler(c *fiber.Ctx, err error) error {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
 	app = fiber.New(fiber.Config{
 		JSONEncoder:  sonic.Marshal,
 		JSONDecoder:  sonic.Unmarshal,
 		ErrorHandler: errorHandler,
 	})
+	metricsApp = fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		ErrorHandler:          errorHandler,
+	})
 
 	bootstrap()
 
-	port := helpers.Getenv("APP_PORT", "5000")
-	err := app.Listen(":" + port)
-	if err != nil {
-		log.Fatalf("failed to listen on port %v: %v", port, err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go factory.RunJobs(ctx)
+
+	go func() {
+		port := helpers.Getenv("APP_PORT", "5000")
+		err := app.Listen(":" + port)
+		if err != nil {
+			log.Fatalf("failed to listen on port %v: %v", port, err)
+		}
+	}()
+
+	go func() {
+		port := helpers.Getenv("METRICS_PORT", "9090")
+		err := metricsApp.Listen(":" + port)
+		if err != nil {
+			log.Fatalf("failed to listen on port %v: %v", port, err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	factory.Shutdown()
+	time.Sleep(shutdownDelay())
+
+	factory.Drain()
+	if err := app.ShutdownWithTimeout(30 * time.Second); err != nil {
+		log.Printf("failed to shutdown: %v", err)
+	}
+	// Requests still in flight during shutdown may have queued more visits.
+	factory.Drain()
+	if err := metricsApp.Shutdown(); err != nil {
+		log.Printf("failed to shutdown metrics: %v", err)
 	}
 }
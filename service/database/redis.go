@@ -2,12 +2,20 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"time"
 	"url-shortener/helpers"
+	"url-shortener/logs"
+	"url-shortener/metrics"
+	"url-shortener/utils/breaker"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// NewRedis doesn't fail when Redis is down, so the service can start
+// without it. Commands fail fast while the breaker is open.
 func NewRedis() *redis.Client {
 	addr := helpers.Getenv("REDIS_HOST", "127.0.0.1") + ":" + helpers.Getenv("REDIS_PORT", "6379")
 	client := redis.NewClient(&redis.Options{
@@ -15,11 +23,75 @@ func NewRedis() *redis.Client {
 		Password: helpers.Getenv("REDIS_PASSWORD", ""),
 		DB:       0,
 	})
+	client.AddHook(newBreakerHook())
 
 	err := client.Ping(context.Background()).Err()
 	if err != nil {
-		panic(fmt.Sprintf("can't connect to redis: %v", err))
+		logs.Error(fmt.Sprintf("can't connect to redis, starting without it: %v", err))
 	}
 
 	return client
 }
+
+type breakerHook struct {
+	breaker *breaker.Breaker
+}
+
+func newBreakerHook() *breakerHook {
+	threshold, err := strconv.Atoi(helpers.Getenv("REDIS_BREAKER_THRESHOLD", "5"))
+	if err != nil || threshold <= 0 {
+		threshold = 5
+	}
+	cooldown, err := time.ParseDuration(helpers.Getenv("REDIS_BREAKER_COOLDOWN", "10s"))
+	if err != nil || cooldown <= 0 {
+		cooldown = 10 * time.Second
+	}
+
+	return &breakerHook{breaker.New(threshold, cooldown, func(from breaker.State, to breaker.State) {
+		logs.Info(fmt.Sprintf("redis circuit breaker %v -> %v", from, to))
+		if to == breaker.Closed {
+			metrics.RedisBreakerOpen.Set(0)
+		} else {
+			metrics.RedisBreakerOpen.Set(1)
+		}
+	})}
+}
+
+func (h *breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		err := next(ctx, cmd)
+		h.breaker.Done(isUnavailable(err))
+		return err
+	}
+}
+
+func (h *breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		err := next(ctx, cmds)
+		h.breaker.Done(isUnavailable(err))
+		return err
+	}
+}
+
+// isUnavailable tells connection problems apart from replies such as a
+// missing key or a wrong type, which mean Redis is up.
+func isUnavailable(err error) bool {
+	var redisErr redis.Error
+	return err != nil && !errors.As(err, &redisErr)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepository interface {
+	Create(apiKey *models.APIKey) error
+	FindByID(id uuid.UUID) (*models.APIKey, error)
+	FindByHash(hash string) (*models.APIKey, error)
+	FindByUserID(userID uuid.UUID) ([]*models.APIKey, error)
+	Revoke(apiKey *models.APIKey) error
+	UpdateRateLimit(apiKey *models.APIKey) error
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type UpdateRateLimitRequest struct {
+	Multiplier float64 `json:"rate_limit_multiplier" validate:"required,gt=0,lte=1000"`
+}
+
+type CreateAPIKeyResponse struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyUsecase interface {
+	CreateAPIKey(userID uuid.UUID, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(userID uuid.UUID) ([]*models.APIKey, error)
+	RevokeAPIKey(userID uuid.UUID, id uuid.UUID) error
+	UpdateRateLimit(id uuid.UUID, req *UpdateRateLimitRequest) (*models.APIKey, error)
+	Authenticate(key string) (*models.APIKey, error)
+}
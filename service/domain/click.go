@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type ClickRepository interface {
+	CreateBatch(clicks []*models.Click) error
+	CountByInterval(shortLinkID uuid.UUID, interval string, from time.Time, to time.Time) ([]*StatsBucket, error)
+	CountByVariant(shortLinkID uuid.UUID, from time.Time, to time.Time) (map[uuid.UUID]int, error)
+}
+
+type Visit struct {
+	Referrer       string
+	UserAgent      string
+	IP             string
+	AcceptLanguage string
+	Query          string
+}
+
+type StatsRequest struct {
+	Interval string `query:"interval" validate:"omitempty,oneof=hour day month"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type StatsBucket struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+type ShortLinkStats struct {
+	SlashCode string          `json:"slash_code"`
+	Interval  string          `json:"interval"`
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Total     int             `json:"total"`
+	Buckets   []*StatsBucket  `json:"buckets"`
+	Variants  []*VariantStats `json:"variants,omitempty"`
+}
+
+type VariantStats struct {
+	ID          uuid.UUID `json:"id"`
+	Destination string    `json:"destination"`
+	Weight      int       `json:"weight"`
+	Count       int       `json:"count"`
+}
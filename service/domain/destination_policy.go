@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type DomainRuleRepository interface {
+	Create(rule *models.DomainRule) error
+	FindAll() ([]*models.DomainRule, error)
+	FindByID(id uuid.UUID) (*models.DomainRule, error)
+	FindByDomain(domain string) (*models.DomainRule, error)
+	Delete(rule *models.DomainRule) error
+}
+
+type CreateDomainRuleRequest struct {
+	Domain string `json:"domain" validate:"required,max=255"`
+	Type   string `json:"type" validate:"required,oneof=block allow"`
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+type DestinationPolicyUsecase interface {
+	CheckDestination(destination string) error
+	IsBlocked(destination string) bool
+	ListRules() ([]*models.DomainRule, error)
+	CreateRule(userID uuid.UUID, req *CreateDomainRuleRequest) (*models.DomainRule, error)
+	DeleteRule(id uuid.UUID) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type DomainRepository interface {
+	Create(domain *models.Domain) error
+	FindAll() ([]*models.Domain, error)
+	FindByID(id uuid.UUID) (*models.Domain, error)
+	FindByName(name string) (*models.Domain, error)
+	CountShortLinks(name string) (int64, error)
+	Delete(domain *models.Domain) error
+}
+
+type CreateDomainRequest struct {
+	Name string `json:"name" validate:"required,fqdn,max=255"`
+}
+
+type DomainUsecase interface {
+	ListDomains() ([]*models.Domain, error)
+	CreateDomain(userID uuid.UUID, req *CreateDomainRequest) (*models.Domain, error)
+	DeleteDomain(id uuid.UUID) error
+	IsRegistered(name string) bool
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+type HealthRepository interface {
+	PingDatabase(ctx context.Context) error
+	PingRedis(ctx context.Context) error
+}
+
+type Readiness struct {
+	Status string                       `json:"status"`
+	Checks map[string]*DependencyStatus `json:"checks,omitempty"`
+}
+
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type HealthUsecase interface {
+	Readiness() *Readiness
+	Shutdown()
+}
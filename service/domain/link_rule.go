@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type GeoLocator interface {
+	Country(ip string) string
+}
+
+type LinkRuleRequest struct {
+	OS          string     `json:"os" validate:"omitempty,oneof=ios android windows macos linux chromeos"`
+	Device      string     `json:"device" validate:"omitempty,oneof=mobile tablet desktop"`
+	Countries   []string   `json:"countries" validate:"max=50,dive,len=2,alpha"`
+	Languages   []string   `json:"languages" validate:"max=20,dive,min=2,max=35"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Destination string     `json:"destination" validate:"required,url,max=512"`
+}
+
+type ReplaceLinkRulesRequest struct {
+	Rules []*LinkRuleRequest `json:"rules" validate:"max=20,dive,required"`
+}
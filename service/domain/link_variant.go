@@ -0,0 +1,10 @@
+package domain
+
+type LinkVariantRequest struct {
+	Destination string `json:"destination" validate:"required,url,max=512"`
+	Weight      int    `json:"weight" validate:"required,min=1,max=1000"`
+}
+
+type ReplaceLinkVariantsRequest struct {
+	Variants []*LinkVariantRequest `json:"variants" validate:"max=10,dive,required"`
+}
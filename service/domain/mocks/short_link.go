@@ -9,11 +9,14 @@
 package mock_domain
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 	domain "url-shortener/domain"
 	models "url-shortener/models"
 
+	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,6 +43,64 @@ func (m *MockShortLinkRepository) EXPECT() *MockShortLinkRepositoryMockRecorder
 	return m.recorder
 }
 
+// AckPendingVisitors mocks base method.
+func (m *MockShortLinkRepository) AckPendingVisitors(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckPendingVisitors", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckPendingVisitors indicates an expected call of AckPendingVisitors.
+func (mr *MockShortLinkRepositoryMockRecorder) AckPendingVisitors(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckPendingVisitors", reflect.TypeOf((*MockShortLinkRepository)(nil).AckPendingVisitors), id)
+}
+
+// AnnounceShortLinks mocks base method.
+func (m *MockShortLinkRepository) AnnounceShortLinks(shortLinks []*models.ShortLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnnounceShortLinks", shortLinks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnnounceShortLinks indicates an expected call of AnnounceShortLinks.
+func (mr *MockShortLinkRepositoryMockRecorder) AnnounceShortLinks(shortLinks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceShortLinks", reflect.TypeOf((*MockShortLinkRepository)(nil).AnnounceShortLinks), shortLinks)
+}
+
+// ClaimUnowned mocks base method.
+func (m *MockShortLinkRepository) ClaimUnowned(ownerID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUnowned", ownerID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUnowned indicates an expected call of ClaimUnowned.
+func (mr *MockShortLinkRepositoryMockRecorder) ClaimUnowned(ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUnowned", reflect.TypeOf((*MockShortLinkRepository)(nil).ClaimUnowned), ownerID)
+}
+
+// CountFailedUnlocks mocks base method.
+func (m *MockShortLinkRepository) CountFailedUnlocks(id uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountFailedUnlocks", id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountFailedUnlocks indicates an expected call of CountFailedUnlocks.
+func (mr *MockShortLinkRepositoryMockRecorder) CountFailedUnlocks(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountFailedUnlocks", reflect.TypeOf((*MockShortLinkRepository)(nil).CountFailedUnlocks), id)
+}
+
 // Create mocks base method.
 func (m *MockShortLinkRepository) Create(shortLink *models.ShortLink) error {
 	m.ctrl.T.Helper()
@@ -54,62 +115,377 @@ func (mr *MockShortLinkRepositoryMockRecorder) Create(shortLink any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockShortLinkRepository)(nil).Create), shortLink)
 }
 
+// CreateBatch mocks base method.
+func (m *MockShortLinkRepository) CreateBatch(shortLinks []*models.ShortLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", shortLinks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockShortLinkRepositoryMockRecorder) CreateBatch(shortLinks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockShortLinkRepository)(nil).CreateBatch), shortLinks)
+}
+
+// Delete mocks base method.
+func (m *MockShortLinkRepository) Delete(shortLink *models.ShortLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", shortLink)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockShortLinkRepositoryMockRecorder) Delete(shortLink any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockShortLinkRepository)(nil).Delete), shortLink)
+}
+
+// DeleteShortLinkCache mocks base method.
+func (m *MockShortLinkRepository) DeleteShortLinkCache(host, slashCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShortLinkCache", host, slashCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShortLinkCache indicates an expected call of DeleteShortLinkCache.
+func (mr *MockShortLinkRepositoryMockRecorder) DeleteShortLinkCache(host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShortLinkCache", reflect.TypeOf((*MockShortLinkRepository)(nil).DeleteShortLinkCache), host, slashCode)
+}
+
+// FindByIDs mocks base method.
+func (m *MockShortLinkRepository) FindByIDs(ids []uuid.UUID) ([]*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDs", ids)
+	ret0, _ := ret[0].([]*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDs indicates an expected call of FindByIDs.
+func (mr *MockShortLinkRepositoryMockRecorder) FindByIDs(ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDs", reflect.TypeOf((*MockShortLinkRepository)(nil).FindByIDs), ids)
+}
+
 // FindBySlashCode mocks base method.
-func (m *MockShortLinkRepository) FindBySlashCode(slashCode string) (*models.ShortLink, error) {
+func (m *MockShortLinkRepository) FindBySlashCode(host, slashCode string) (*models.ShortLink, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindBySlashCode", slashCode)
+	ret := m.ctrl.Call(m, "FindBySlashCode", host, slashCode)
 	ret0, _ := ret[0].(*models.ShortLink)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindBySlashCode indicates an expected call of FindBySlashCode.
-func (mr *MockShortLinkRepositoryMockRecorder) FindBySlashCode(slashCode any) *gomock.Call {
+func (mr *MockShortLinkRepositoryMockRecorder) FindBySlashCode(host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlashCode", reflect.TypeOf((*MockShortLinkRepository)(nil).FindBySlashCode), host, slashCode)
+}
+
+// FindExistingSlashCodes mocks base method.
+func (m *MockShortLinkRepository) FindExistingSlashCodes(host string, slashCodes []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExistingSlashCodes", host, slashCodes)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExistingSlashCodes indicates an expected call of FindExistingSlashCodes.
+func (mr *MockShortLinkRepositoryMockRecorder) FindExistingSlashCodes(host, slashCodes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExistingSlashCodes", reflect.TypeOf((*MockShortLinkRepository)(nil).FindExistingSlashCodes), host, slashCodes)
+}
+
+// FindExpired mocks base method.
+func (m *MockShortLinkRepository) FindExpired(from, to time.Time) ([]*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExpired", from, to)
+	ret0, _ := ret[0].([]*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExpired indicates an expected call of FindExpired.
+func (mr *MockShortLinkRepositoryMockRecorder) FindExpired(from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExpired", reflect.TypeOf((*MockShortLinkRepository)(nil).FindExpired), from, to)
+}
+
+// FindPendingVisitors mocks base method.
+func (m *MockShortLinkRepository) FindPendingVisitors(id string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPendingVisitors", id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPendingVisitors indicates an expected call of FindPendingVisitors.
+func (mr *MockShortLinkRepositoryMockRecorder) FindPendingVisitors(id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlashCode", reflect.TypeOf((*MockShortLinkRepository)(nil).FindBySlashCode), slashCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPendingVisitors", reflect.TypeOf((*MockShortLinkRepository)(nil).FindPendingVisitors), id)
+}
+
+// FindPendingVisitorsByIDs mocks base method.
+func (m *MockShortLinkRepository) FindPendingVisitorsByIDs(ids []string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPendingVisitorsByIDs", ids)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPendingVisitorsByIDs indicates an expected call of FindPendingVisitorsByIDs.
+func (mr *MockShortLinkRepositoryMockRecorder) FindPendingVisitorsByIDs(ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPendingVisitorsByIDs", reflect.TypeOf((*MockShortLinkRepository)(nil).FindPendingVisitorsByIDs), ids)
+}
+
+// FindRules mocks base method.
+func (m *MockShortLinkRepository) FindRules(shortLinkID uuid.UUID) ([]*models.LinkRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRules", shortLinkID)
+	ret0, _ := ret[0].([]*models.LinkRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRules indicates an expected call of FindRules.
+func (mr *MockShortLinkRepositoryMockRecorder) FindRules(shortLinkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRules", reflect.TypeOf((*MockShortLinkRepository)(nil).FindRules), shortLinkID)
 }
 
 // FindShortLinkCache mocks base method.
-func (m *MockShortLinkRepository) FindShortLinkCache(slashCode string) (string, error) {
+func (m *MockShortLinkRepository) FindShortLinkCache(host, slashCode string) (*domain.ShortLinkCache, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindShortLinkCache", slashCode)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "FindShortLinkCache", host, slashCode)
+	ret0, _ := ret[0].(*domain.ShortLinkCache)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindShortLinkCache indicates an expected call of FindShortLinkCache.
-func (mr *MockShortLinkRepositoryMockRecorder) FindShortLinkCache(slashCode any) *gomock.Call {
+func (mr *MockShortLinkRepositoryMockRecorder) FindShortLinkCache(host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindShortLinkCache", reflect.TypeOf((*MockShortLinkRepository)(nil).FindShortLinkCache), host, slashCode)
+}
+
+// FindVariants mocks base method.
+func (m *MockShortLinkRepository) FindVariants(shortLinkID uuid.UUID) ([]*models.LinkVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVariants", shortLinkID)
+	ret0, _ := ret[0].([]*models.LinkVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVariants indicates an expected call of FindVariants.
+func (mr *MockShortLinkRepositoryMockRecorder) FindVariants(shortLinkID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVariants", reflect.TypeOf((*MockShortLinkRepository)(nil).FindVariants), shortLinkID)
+}
+
+// IncrementFailedUnlocks mocks base method.
+func (m *MockShortLinkRepository) IncrementFailedUnlocks(id uuid.UUID, window time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementFailedUnlocks", id, window)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementFailedUnlocks indicates an expected call of IncrementFailedUnlocks.
+func (mr *MockShortLinkRepositoryMockRecorder) IncrementFailedUnlocks(id, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementFailedUnlocks", reflect.TypeOf((*MockShortLinkRepository)(nil).IncrementFailedUnlocks), id, window)
+}
+
+// IncrementPendingVisitors mocks base method.
+func (m *MockShortLinkRepository) IncrementPendingVisitors(counts map[string]int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementPendingVisitors", counts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementPendingVisitors indicates an expected call of IncrementPendingVisitors.
+func (mr *MockShortLinkRepositoryMockRecorder) IncrementPendingVisitors(counts any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindShortLinkCache", reflect.TypeOf((*MockShortLinkRepository)(nil).FindShortLinkCache), slashCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementPendingVisitors", reflect.TypeOf((*MockShortLinkRepository)(nil).IncrementPendingVisitors), counts)
 }
 
 // IncrementVisitor mocks base method.
-func (m *MockShortLinkRepository) IncrementVisitor(slashCode string, visitors int) error {
+func (m *MockShortLinkRepository) IncrementVisitor(id uuid.UUID, visitors int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IncrementVisitor", slashCode, visitors)
+	ret := m.ctrl.Call(m, "IncrementVisitor", id, visitors)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // IncrementVisitor indicates an expected call of IncrementVisitor.
-func (mr *MockShortLinkRepositoryMockRecorder) IncrementVisitor(slashCode, visitors any) *gomock.Call {
+func (mr *MockShortLinkRepositoryMockRecorder) IncrementVisitor(id, visitors any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementVisitor", reflect.TypeOf((*MockShortLinkRepository)(nil).IncrementVisitor), id, visitors)
+}
+
+// List mocks base method.
+func (m *MockShortLinkRepository) List(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", filter)
+	ret0, _ := ret[0].([]*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockShortLinkRepositoryMockRecorder) List(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockShortLinkRepository)(nil).List), filter)
+}
+
+// ListenCacheInvalidation mocks base method.
+func (m *MockShortLinkRepository) ListenCacheInvalidation(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ListenCacheInvalidation", ctx)
+}
+
+// ListenCacheInvalidation indicates an expected call of ListenCacheInvalidation.
+func (mr *MockShortLinkRepositoryMockRecorder) ListenCacheInvalidation(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListenCacheInvalidation", reflect.TypeOf((*MockShortLinkRepository)(nil).ListenCacheInvalidation), ctx)
+}
+
+// LoadSlashCodeFilter mocks base method.
+func (m *MockShortLinkRepository) LoadSlashCodeFilter(maxAge time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadSlashCodeFilter", maxAge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadSlashCodeFilter indicates an expected call of LoadSlashCodeFilter.
+func (mr *MockShortLinkRepositoryMockRecorder) LoadSlashCodeFilter(maxAge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadSlashCodeFilter", reflect.TypeOf((*MockShortLinkRepository)(nil).LoadSlashCodeFilter), maxAge)
+}
+
+// MayExist mocks base method.
+func (m *MockShortLinkRepository) MayExist(host, slashCode string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MayExist", host, slashCode)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// MayExist indicates an expected call of MayExist.
+func (mr *MockShortLinkRepositoryMockRecorder) MayExist(host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MayExist", reflect.TypeOf((*MockShortLinkRepository)(nil).MayExist), host, slashCode)
+}
+
+// ReleasePendingVisitors mocks base method.
+func (m *MockShortLinkRepository) ReleasePendingVisitors() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleasePendingVisitors")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleasePendingVisitors indicates an expected call of ReleasePendingVisitors.
+func (mr *MockShortLinkRepositoryMockRecorder) ReleasePendingVisitors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleasePendingVisitors", reflect.TypeOf((*MockShortLinkRepository)(nil).ReleasePendingVisitors))
+}
+
+// ReplaceRules mocks base method.
+func (m *MockShortLinkRepository) ReplaceRules(shortLinkID uuid.UUID, rules []*models.LinkRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRules", shortLinkID, rules)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRules indicates an expected call of ReplaceRules.
+func (mr *MockShortLinkRepositoryMockRecorder) ReplaceRules(shortLinkID, rules any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRules", reflect.TypeOf((*MockShortLinkRepository)(nil).ReplaceRules), shortLinkID, rules)
+}
+
+// ReplaceVariants mocks base method.
+func (m *MockShortLinkRepository) ReplaceVariants(shortLinkID uuid.UUID, variants []*models.LinkVariant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceVariants", shortLinkID, variants)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceVariants indicates an expected call of ReplaceVariants.
+func (mr *MockShortLinkRepositoryMockRecorder) ReplaceVariants(shortLinkID, variants any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceVariants", reflect.TypeOf((*MockShortLinkRepository)(nil).ReplaceVariants), shortLinkID, variants)
+}
+
+// RetryCacheInvalidations mocks base method.
+func (m *MockShortLinkRepository) RetryCacheInvalidations() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryCacheInvalidations")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryCacheInvalidations indicates an expected call of RetryCacheInvalidations.
+func (mr *MockShortLinkRepositoryMockRecorder) RetryCacheInvalidations() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementVisitor", reflect.TypeOf((*MockShortLinkRepository)(nil).IncrementVisitor), slashCode, visitors)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryCacheInvalidations", reflect.TypeOf((*MockShortLinkRepository)(nil).RetryCacheInvalidations))
 }
 
 // SetShortLinkCache mocks base method.
-func (m *MockShortLinkRepository) SetShortLinkCache(slashCode, dest string, exp time.Duration) error {
+func (m *MockShortLinkRepository) SetShortLinkCache(host, slashCode string, cache *domain.ShortLinkCache, exp time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetShortLinkCache", slashCode, dest, exp)
+	ret := m.ctrl.Call(m, "SetShortLinkCache", host, slashCode, cache, exp)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetShortLinkCache indicates an expected call of SetShortLinkCache.
-func (mr *MockShortLinkRepositoryMockRecorder) SetShortLinkCache(slashCode, dest, exp any) *gomock.Call {
+func (mr *MockShortLinkRepositoryMockRecorder) SetShortLinkCache(host, slashCode, cache, exp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShortLinkCache", reflect.TypeOf((*MockShortLinkRepository)(nil).SetShortLinkCache), host, slashCode, cache, exp)
+}
+
+// TakePendingVisitors mocks base method.
+func (m *MockShortLinkRepository) TakePendingVisitors(lease time.Duration) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakePendingVisitors", lease)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakePendingVisitors indicates an expected call of TakePendingVisitors.
+func (mr *MockShortLinkRepositoryMockRecorder) TakePendingVisitors(lease any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShortLinkCache", reflect.TypeOf((*MockShortLinkRepository)(nil).SetShortLinkCache), slashCode, dest, exp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakePendingVisitors", reflect.TypeOf((*MockShortLinkRepository)(nil).TakePendingVisitors), lease)
+}
+
+// Update mocks base method.
+func (m *MockShortLinkRepository) Update(shortLink *models.ShortLink) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", shortLink)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockShortLinkRepositoryMockRecorder) Update(shortLink any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockShortLinkRepository)(nil).Update), shortLink)
 }
 
 // MockShortLinkUsecase is a mock of ShortLinkUsecase interface.
@@ -135,47 +511,310 @@ func (m *MockShortLinkUsecase) EXPECT() *MockShortLinkUsecaseMockRecorder {
 	return m.recorder
 }
 
+// ClaimUnownedShortLinks mocks base method.
+func (m *MockShortLinkUsecase) ClaimUnownedShortLinks(ownerID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUnownedShortLinks", ownerID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimUnownedShortLinks indicates an expected call of ClaimUnownedShortLinks.
+func (mr *MockShortLinkUsecaseMockRecorder) ClaimUnownedShortLinks(ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUnownedShortLinks", reflect.TypeOf((*MockShortLinkUsecase)(nil).ClaimUnownedShortLinks), ownerID)
+}
+
 // CreateShortLink mocks base method.
-func (m *MockShortLinkUsecase) CreateShortLink(req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+func (m *MockShortLinkUsecase) CreateShortLink(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateShortLink", req)
+	ret := m.ctrl.Call(m, "CreateShortLink", ownerID, req)
 	ret0, _ := ret[0].(*models.ShortLink)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateShortLink indicates an expected call of CreateShortLink.
-func (mr *MockShortLinkUsecaseMockRecorder) CreateShortLink(req any) *gomock.Call {
+func (mr *MockShortLinkUsecaseMockRecorder) CreateShortLink(ownerID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortLink", reflect.TypeOf((*MockShortLinkUsecase)(nil).CreateShortLink), ownerID, req)
+}
+
+// CreateShortLinks mocks base method.
+func (m *MockShortLinkUsecase) CreateShortLinks(ownerID uuid.UUID, reqs []*domain.CreateShortLinkRequest) ([]*domain.BulkCreateResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShortLinks", ownerID, reqs)
+	ret0, _ := ret[0].([]*domain.BulkCreateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateShortLinks indicates an expected call of CreateShortLinks.
+func (mr *MockShortLinkUsecaseMockRecorder) CreateShortLinks(ownerID, reqs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortLinks", reflect.TypeOf((*MockShortLinkUsecase)(nil).CreateShortLinks), ownerID, reqs)
+}
+
+// DeleteShortLink mocks base method.
+func (m *MockShortLinkUsecase) DeleteShortLink(ownerID uuid.UUID, host, slashCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteShortLink", ownerID, host, slashCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteShortLink indicates an expected call of DeleteShortLink.
+func (mr *MockShortLinkUsecaseMockRecorder) DeleteShortLink(ownerID, host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteShortLink", reflect.TypeOf((*MockShortLinkUsecase)(nil).DeleteShortLink), ownerID, host, slashCode)
+}
+
+// DrainVisitors mocks base method.
+func (m *MockShortLinkUsecase) DrainVisitors() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DrainVisitors")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DrainVisitors indicates an expected call of DrainVisitors.
+func (mr *MockShortLinkUsecaseMockRecorder) DrainVisitors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DrainVisitors", reflect.TypeOf((*MockShortLinkUsecase)(nil).DrainVisitors))
+}
+
+// ExportShortLinks mocks base method.
+func (m *MockShortLinkUsecase) ExportShortLinks(ownerID uuid.UUID, format string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportShortLinks", ownerID, format, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportShortLinks indicates an expected call of ExportShortLinks.
+func (mr *MockShortLinkUsecaseMockRecorder) ExportShortLinks(ownerID, format, w any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortLink", reflect.TypeOf((*MockShortLinkUsecase)(nil).CreateShortLink), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportShortLinks", reflect.TypeOf((*MockShortLinkUsecase)(nil).ExportShortLinks), ownerID, format, w)
 }
 
 // FindBySlashCode mocks base method.
-func (m *MockShortLinkUsecase) FindBySlashCode(slashCode string) (*models.ShortLink, error) {
+func (m *MockShortLinkUsecase) FindBySlashCode(ownerID uuid.UUID, host, slashCode string) (*models.ShortLink, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindBySlashCode", slashCode)
+	ret := m.ctrl.Call(m, "FindBySlashCode", ownerID, host, slashCode)
 	ret0, _ := ret[0].(*models.ShortLink)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindBySlashCode indicates an expected call of FindBySlashCode.
-func (mr *MockShortLinkUsecaseMockRecorder) FindBySlashCode(slashCode any) *gomock.Call {
+func (mr *MockShortLinkUsecaseMockRecorder) FindBySlashCode(ownerID, host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlashCode", reflect.TypeOf((*MockShortLinkUsecase)(nil).FindBySlashCode), ownerID, host, slashCode)
+}
+
+// FindRules mocks base method.
+func (m *MockShortLinkUsecase) FindRules(ownerID uuid.UUID, host, slashCode string) ([]*models.LinkRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRules", ownerID, host, slashCode)
+	ret0, _ := ret[0].([]*models.LinkRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRules indicates an expected call of FindRules.
+func (mr *MockShortLinkUsecaseMockRecorder) FindRules(ownerID, host, slashCode any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBySlashCode", reflect.TypeOf((*MockShortLinkUsecase)(nil).FindBySlashCode), slashCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRules", reflect.TypeOf((*MockShortLinkUsecase)(nil).FindRules), ownerID, host, slashCode)
+}
+
+// FindVariants mocks base method.
+func (m *MockShortLinkUsecase) FindVariants(ownerID uuid.UUID, host, slashCode string) ([]*models.LinkVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindVariants", ownerID, host, slashCode)
+	ret0, _ := ret[0].([]*models.LinkVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindVariants indicates an expected call of FindVariants.
+func (mr *MockShortLinkUsecaseMockRecorder) FindVariants(ownerID, host, slashCode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindVariants", reflect.TypeOf((*MockShortLinkUsecase)(nil).FindVariants), ownerID, host, slashCode)
+}
+
+// FlushVisitors mocks base method.
+func (m *MockShortLinkUsecase) FlushVisitors() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushVisitors")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushVisitors indicates an expected call of FlushVisitors.
+func (mr *MockShortLinkUsecaseMockRecorder) FlushVisitors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushVisitors", reflect.TypeOf((*MockShortLinkUsecase)(nil).FlushVisitors))
+}
+
+// GetStats mocks base method.
+func (m *MockShortLinkUsecase) GetStats(ownerID uuid.UUID, host, slashCode string, req *domain.StatsRequest) (*domain.ShortLinkStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ownerID, host, slashCode, req)
+	ret0, _ := ret[0].(*domain.ShortLinkStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockShortLinkUsecaseMockRecorder) GetStats(ownerID, host, slashCode, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockShortLinkUsecase)(nil).GetStats), ownerID, host, slashCode, req)
+}
+
+// ImportShortLinks mocks base method.
+func (m *MockShortLinkUsecase) ImportShortLinks(ownerID uuid.UUID, format string, r io.Reader) (*domain.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportShortLinks", ownerID, format, r)
+	ret0, _ := ret[0].(*domain.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportShortLinks indicates an expected call of ImportShortLinks.
+func (mr *MockShortLinkUsecaseMockRecorder) ImportShortLinks(ownerID, format, r any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportShortLinks", reflect.TypeOf((*MockShortLinkUsecase)(nil).ImportShortLinks), ownerID, format, r)
+}
+
+// ListShortLinks mocks base method.
+func (m *MockShortLinkUsecase) ListShortLinks(ownerID uuid.UUID, req *domain.ListShortLinksRequest) (*domain.ShortLinkPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListShortLinks", ownerID, req)
+	ret0, _ := ret[0].(*domain.ShortLinkPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListShortLinks indicates an expected call of ListShortLinks.
+func (mr *MockShortLinkUsecaseMockRecorder) ListShortLinks(ownerID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListShortLinks", reflect.TypeOf((*MockShortLinkUsecase)(nil).ListShortLinks), ownerID, req)
+}
+
+// PublishExpired mocks base method.
+func (m *MockShortLinkUsecase) PublishExpired() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishExpired")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishExpired indicates an expected call of PublishExpired.
+func (mr *MockShortLinkUsecaseMockRecorder) PublishExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishExpired", reflect.TypeOf((*MockShortLinkUsecase)(nil).PublishExpired))
 }
 
 // Redirect mocks base method.
-func (m *MockShortLinkUsecase) Redirect(slashCode string) (string, error) {
+func (m *MockShortLinkUsecase) Redirect(host, slashCode string, visit *domain.Visit) (*domain.Redirection, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Redirect", slashCode)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Redirect", host, slashCode, visit)
+	ret0, _ := ret[0].(*domain.Redirection)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Redirect indicates an expected call of Redirect.
-func (mr *MockShortLinkUsecaseMockRecorder) Redirect(slashCode any) *gomock.Call {
+func (mr *MockShortLinkUsecaseMockRecorder) Redirect(host, slashCode, visit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redirect", reflect.TypeOf((*MockShortLinkUsecase)(nil).Redirect), host, slashCode, visit)
+}
+
+// RefreshSlashCodeFilter mocks base method.
+func (m *MockShortLinkUsecase) RefreshSlashCodeFilter() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSlashCodeFilter")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshSlashCodeFilter indicates an expected call of RefreshSlashCodeFilter.
+func (mr *MockShortLinkUsecaseMockRecorder) RefreshSlashCodeFilter() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSlashCodeFilter", reflect.TypeOf((*MockShortLinkUsecase)(nil).RefreshSlashCodeFilter))
+}
+
+// ReplaceRules mocks base method.
+func (m *MockShortLinkUsecase) ReplaceRules(ownerID uuid.UUID, host, slashCode string, req *domain.ReplaceLinkRulesRequest) ([]*models.LinkRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRules", ownerID, host, slashCode, req)
+	ret0, _ := ret[0].([]*models.LinkRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceRules indicates an expected call of ReplaceRules.
+func (mr *MockShortLinkUsecaseMockRecorder) ReplaceRules(ownerID, host, slashCode, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRules", reflect.TypeOf((*MockShortLinkUsecase)(nil).ReplaceRules), ownerID, host, slashCode, req)
+}
+
+// ReplaceVariants mocks base method.
+func (m *MockShortLinkUsecase) ReplaceVariants(ownerID uuid.UUID, host, slashCode string, req *domain.ReplaceLinkVariantsRequest) ([]*models.LinkVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceVariants", ownerID, host, slashCode, req)
+	ret0, _ := ret[0].([]*models.LinkVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplaceVariants indicates an expected call of ReplaceVariants.
+func (mr *MockShortLinkUsecaseMockRecorder) ReplaceVariants(ownerID, host, slashCode, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceVariants", reflect.TypeOf((*MockShortLinkUsecase)(nil).ReplaceVariants), ownerID, host, slashCode, req)
+}
+
+// RetryCacheInvalidations mocks base method.
+func (m *MockShortLinkUsecase) RetryCacheInvalidations() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryCacheInvalidations")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryCacheInvalidations indicates an expected call of RetryCacheInvalidations.
+func (mr *MockShortLinkUsecaseMockRecorder) RetryCacheInvalidations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryCacheInvalidations", reflect.TypeOf((*MockShortLinkUsecase)(nil).RetryCacheInvalidations))
+}
+
+// Unlock mocks base method.
+func (m *MockShortLinkUsecase) Unlock(host, slashCode, password string, visit *domain.Visit) (*domain.Redirection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", host, slashCode, password, visit)
+	ret0, _ := ret[0].(*domain.Redirection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockShortLinkUsecaseMockRecorder) Unlock(host, slashCode, password, visit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockShortLinkUsecase)(nil).Unlock), host, slashCode, password, visit)
+}
+
+// UpdateShortLink mocks base method.
+func (m *MockShortLinkUsecase) UpdateShortLink(ownerID uuid.UUID, host, slashCode string, req *domain.UpdateShortLinkRequest) (*models.ShortLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateShortLink", ownerID, host, slashCode, req)
+	ret0, _ := ret[0].(*models.ShortLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateShortLink indicates an expected call of UpdateShortLink.
+func (mr *MockShortLinkUsecaseMockRecorder) UpdateShortLink(ownerID, host, slashCode, req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redirect", reflect.TypeOf((*MockShortLinkUsecase)(nil).Redirect), slashCode)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateShortLink", reflect.TypeOf((*MockShortLinkUsecase)(nil).UpdateShortLink), ownerID, host, slashCode, req)
 }
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+type QRCodeRepository interface {
+	SetQRCode(key string, image []byte, exp time.Duration) error
+	FindQRCode(key string) ([]byte, error)
+}
+
+type QRCodeRequest struct {
+	Format     string `query:"format" validate:"omitempty,oneof=png svg"`
+	Size       int    `query:"size" validate:"omitempty,min=64,max=2048"`
+	Level      string `query:"level" validate:"omitempty,oneof=L M Q H"`
+	Margin     *int   `query:"margin" validate:"omitempty,min=0,max=16"`
+	Foreground string `query:"fg" validate:"omitempty,len=6,hexadecimal"`
+	Background string `query:"bg" validate:"omitempty,len=6,hexadecimal"`
+}
+
+type QRCode struct {
+	ContentType string
+	Image       []byte
+}
+
+type QRCodeUsecase interface {
+	Render(content string, req *QRCodeRequest) (*QRCode, error)
+}
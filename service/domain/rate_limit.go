@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+type RateLimitRepository interface {
+	Allow(key string, limit int, period time.Duration) (*RateLimit, error)
+	FindBlock(client string) (time.Duration, error)
+	Block(client string, base time.Duration, max time.Duration) (time.Duration, error)
+}
+
+type RateLimit struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
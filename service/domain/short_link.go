@@ -1,26 +1,183 @@
 package domain
 
 import (
+	"context"
+	"io"
 	"time"
 	"url-shortener/models"
+
+	"github.com/google/uuid"
 )
 
 type ShortLinkRepository interface {
 	Create(shortLink *models.ShortLink) error
-	FindBySlashCode(slashCode string) (*models.ShortLink, error)
-	IncrementVisitor(slashCode string, visitors int) error
+	CreateBatch(shortLinks []*models.ShortLink) error
+	FindExistingSlashCodes(host string, slashCodes []string) ([]string, error)
+	FindBySlashCode(host string, slashCode string) (*models.ShortLink, error)
+	FindByIDs(ids []uuid.UUID) ([]*models.ShortLink, error)
+	FindExpired(from time.Time, to time.Time) ([]*models.ShortLink, error)
+	List(filter *ShortLinkFilter) ([]*models.ShortLink, error)
+	Update(shortLink *models.ShortLink) error
+	Delete(shortLink *models.ShortLink) error
+	ClaimUnowned(ownerID uuid.UUID) (int64, error)
+	IncrementVisitor(id uuid.UUID, visitors int) error
+	IncrementPendingVisitors(counts map[string]int) error
+	FindPendingVisitors(id string) (int, error)
+	FindPendingVisitorsByIDs(ids []string) (map[string]int, error)
+	TakePendingVisitors(lease time.Duration) (map[string]int, error)
+	AckPendingVisitors(id string) error
+	ReleasePendingVisitors() error
+
+	SetShortLinkCache(host string, slashCode string, cache *ShortLinkCache, exp time.Duration) error
+	FindShortLinkCache(host string, slashCode string) (*ShortLinkCache, error)
+	DeleteShortLinkCache(host string, slashCode string) error
+	RetryCacheInvalidations() error
+	ListenCacheInvalidation(ctx context.Context)
+	AnnounceShortLinks(shortLinks []*models.ShortLink) error
+	MayExist(host string, slashCode string) bool
+	LoadSlashCodeFilter(maxAge time.Duration) error
+	FindRules(shortLinkID uuid.UUID) ([]*models.LinkRule, error)
+	ReplaceRules(shortLinkID uuid.UUID, rules []*models.LinkRule) error
+	FindVariants(shortLinkID uuid.UUID) ([]*models.LinkVariant, error)
+	ReplaceVariants(shortLinkID uuid.UUID, variants []*models.LinkVariant) error
+	CountFailedUnlocks(id uuid.UUID) (int, error)
+	IncrementFailedUnlocks(id uuid.UUID, window time.Duration) error
+}
 
-	SetShortLinkCache(slashCode string, dest string, exp time.Duration) error
-	FindShortLinkCache(slashCode string) (string, error)
+type ShortLinkCache struct {
+	ID           uuid.UUID             `json:"id"`
+	Destination  string                `json:"destination"`
+	RedirectType int                   `json:"redirect_type"`
+	PasswordHash string                `json:"password_hash,omitempty"`
+	NotFound     bool                  `json:"not_found,omitempty"`
+	UTM          *models.UTM           `json:"utm,omitempty"`
+	Passthrough  string                `json:"query_passthrough,omitempty"`
+	OG           *models.OpenGraph     `json:"og,omitempty"`
+	Rules        []*models.LinkRule    `json:"rules,omitempty"`
+	Variants     []*models.LinkVariant `json:"variants,omitempty"`
+}
+
+type Redirection struct {
+	Destination string
+	StatusCode  int
+	Preview     *models.OpenGraph
+	// Private is set when the destination depends on the visitor, so the
+	// redirect mustn't be kept by shared caches.
+	Private bool
 }
 
 type CreateShortLinkRequest struct {
-	SlashCode   string `json:"slash_code" validate:"max=12"`
-	Destination string `json:"destination" validate:"required,url,max=512"`
+	SlashCode    string         `json:"slash_code" validate:"max=12"`
+	Domain       string         `json:"domain" validate:"omitempty,fqdn,max=255"`
+	Destination  string         `json:"destination" validate:"required,url,max=512"`
+	ExpiresAt    *time.Time     `json:"expires_at" validate:"omitempty,gt"`
+	MaxVisits    *int           `json:"max_visits" validate:"omitempty,min=1"`
+	RedirectType int            `json:"redirect_type" validate:"omitempty,oneof=301 302 307 308"`
+	Password     string         `json:"password" validate:"omitempty,min=4,max=72"`
+	UTM          *UTMParameters `json:"utm"`
+	Passthrough  string         `json:"query_passthrough" validate:"omitempty,oneof=off link request"`
+	OG           *OGParameters  `json:"og"`
+}
+
+type UTMParameters struct {
+	Source   string `json:"source,omitempty" validate:"max=255"`
+	Medium   string `json:"medium,omitempty" validate:"max=255"`
+	Campaign string `json:"campaign,omitempty" validate:"max=255"`
+	Term     string `json:"term,omitempty" validate:"max=255"`
+	Content  string `json:"content,omitempty" validate:"max=255"`
+}
+
+type OGParameters struct {
+	Title       string `json:"title,omitempty" validate:"max=255"`
+	Description string `json:"description,omitempty" validate:"max=512"`
+	Image       string `json:"image,omitempty" validate:"omitempty,http_url,max=512"`
+}
+
+type BulkCreateShortLinkRequest struct {
+	Items []*CreateShortLinkRequest `json:"items" validate:"required,min=1,max=1000"`
+}
+
+type BulkCreateResult struct {
+	Index int               `json:"index"`
+	Data  *models.ShortLink `json:"data,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
+
+type ImportResult struct {
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Errors  []*ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Line      int    `json:"line"`
+	SlashCode string `json:"slash_code,omitempty"`
+	Error     string `json:"error"`
+}
+
+// UpdateShortLinkRequest changes only the fields it sets. UTM and OG replace
+// the link's whole set, so an empty object clears them.
+type UpdateShortLinkRequest struct {
+	Destination  string         `json:"destination" validate:"omitempty,url,max=512"`
+	RedirectType int            `json:"redirect_type" validate:"omitempty,oneof=301 302 307 308"`
+	UTM          *UTMParameters `json:"utm"`
+	Passthrough  string         `json:"query_passthrough" validate:"omitempty,oneof=off link request"`
+	OG           *OGParameters  `json:"og"`
+}
+
+type ListShortLinksRequest struct {
+	Cursor      string `query:"cursor"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Sort        string `query:"sort" validate:"omitempty,oneof=created_at visitors"`
+	Order       string `query:"order" validate:"omitempty,oneof=asc desc"`
+	Host        string `query:"host" validate:"omitempty,max=255"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+type ShortLinkCursor struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Visitors  int       `json:"visitors"`
+}
+
+type ShortLinkFilter struct {
+	OwnerID     uuid.UUID
+	Host        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Sort        string
+	Desc        bool
+	After       *ShortLinkCursor
+	Limit       int
+}
+
+type ShortLinkPage struct {
+	Data       []*models.ShortLink `json:"data"`
+	NextCursor string              `json:"next_cursor"`
 }
 
 type ShortLinkUsecase interface {
-	CreateShortLink(req *CreateShortLinkRequest) (*models.ShortLink, error)
-	FindBySlashCode(slashCode string) (*models.ShortLink, error)
-	Redirect(slashCode string) (string, error)
+	CreateShortLink(ownerID uuid.UUID, req *CreateShortLinkRequest) (*models.ShortLink, error)
+	CreateShortLinks(ownerID uuid.UUID, reqs []*CreateShortLinkRequest) ([]*BulkCreateResult, error)
+	ImportShortLinks(ownerID uuid.UUID, format string, r io.Reader) (*ImportResult, error)
+	ExportShortLinks(ownerID uuid.UUID, format string, w io.Writer) error
+	FindBySlashCode(ownerID uuid.UUID, host string, slashCode string) (*models.ShortLink, error)
+	ListShortLinks(ownerID uuid.UUID, req *ListShortLinksRequest) (*ShortLinkPage, error)
+	UpdateShortLink(ownerID uuid.UUID, host string, slashCode string, req *UpdateShortLinkRequest) (*models.ShortLink, error)
+	DeleteShortLink(ownerID uuid.UUID, host string, slashCode string) error
+	ClaimUnownedShortLinks(ownerID uuid.UUID) (int64, error)
+	GetStats(ownerID uuid.UUID, host string, slashCode string, req *StatsRequest) (*ShortLinkStats, error)
+	FindRules(ownerID uuid.UUID, host string, slashCode string) ([]*models.LinkRule, error)
+	ReplaceRules(ownerID uuid.UUID, host string, slashCode string, req *ReplaceLinkRulesRequest) ([]*models.LinkRule, error)
+	FindVariants(ownerID uuid.UUID, host string, slashCode string) ([]*models.LinkVariant, error)
+	ReplaceVariants(ownerID uuid.UUID, host string, slashCode string, req *ReplaceLinkVariantsRequest) ([]*models.LinkVariant, error)
+	Redirect(host string, slashCode string, visit *Visit) (*Redirection, error)
+	Unlock(host string, slashCode string, password string, visit *Visit) (*Redirection, error)
+	RefreshSlashCodeFilter() error
+	FlushVisitors() error
+	RetryCacheInvalidations() error
+	DrainVisitors() error
+	PublishExpired() error
 }
@@ -0,0 +1,5 @@
+package domain
+
+type SlashCodeGenerator interface {
+	Generate(length int) (string, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type UserRepository interface {
+	Create(user *models.User) error
+	FindByID(id uuid.UUID) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+}
+
+type CreateUserRequest struct {
+	Email   string `json:"email" validate:"required,email,max=255"`
+	IsAdmin bool   `json:"is_admin"`
+}
+
+type UserUsecase interface {
+	CreateUser(req *CreateUserRequest) (*models.User, error)
+	FindByID(id uuid.UUID) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+}
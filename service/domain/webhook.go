@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	FindByID(id uuid.UUID) (*models.Webhook, error)
+	FindByOwnerID(ownerID uuid.UUID) ([]*models.Webhook, error)
+	HasSubscribers(events []string) (bool, error)
+	Delete(webhook *models.Webhook) error
+	Enqueue(key string, deliveries []*models.WebhookDelivery) (bool, error)
+	ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	DeleteDelivery(delivery *models.WebhookDelivery) error
+	RetryDelivery(delivery *models.WebhookDelivery) error
+	DeadLetter(delivery *models.WebhookDelivery) error
+	FindDeadLetters(webhookID uuid.UUID, limit int) ([]*models.WebhookDeadLetter, error)
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=512"`
+	Events []string `json:"events" validate:"required,min=1,max=5,dive,oneof=link.created link.updated link.deleted link.expired link.milestone"`
+}
+
+type CreateWebhookResponse struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the body sent to subscribers. Key makes the event one-off:
+// it is dropped if an event with the same key was published before.
+type WebhookEvent struct {
+	ID        uuid.UUID         `json:"id"`
+	Type      string            `json:"type"`
+	CreatedAt time.Time         `json:"created_at"`
+	Link      *models.ShortLink `json:"link"`
+	Milestone int               `json:"milestone,omitempty"`
+	Key       string            `json:"-"`
+}
+
+type WebhookUsecase interface {
+	CreateWebhook(ownerID uuid.UUID, req *CreateWebhookRequest) (*CreateWebhookResponse, error)
+	ListWebhooks(ownerID uuid.UUID) ([]*models.Webhook, error)
+	DeleteWebhook(ownerID uuid.UUID, id uuid.UUID) error
+	ListDeadLetters(ownerID uuid.UUID, id uuid.UUID) ([]*models.WebhookDeadLetter, error)
+	Publish(events ...*WebhookEvent) error
+	PublishVisitors(shortLink *models.ShortLink, before int, after int) error
+	SubscribesToVisitors() bool
+	Deliver() error
+}
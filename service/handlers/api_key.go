@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"url-shortener/domain"
+	"url-shortener/middleware"
+	"url-shortener/utils/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type apiKeyHandler struct {
+	apiKeyUcase domain.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(apiKeyUcase domain.APIKeyUsecase) *apiKeyHandler {
+	return &apiKeyHandler{apiKeyUcase}
+}
+
+func (h *apiKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	req := &domain.CreateAPIKeyRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	apiKey, err := h.apiKeyUcase.CreateAPIKey(middleware.CurrentUserID(c), req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(apiKey)
+}
+
+func (h *apiKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	apiKeys, err := h.apiKeyUcase.ListAPIKeys(middleware.CurrentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(apiKeys)
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "api key not found",
+		})
+	}
+
+	if err := h.apiKeyUcase.RevokeAPIKey(middleware.CurrentUserID(c), id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "api key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *apiKeyHandler) UpdateRateLimit(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "api key not found",
+		})
+	}
+
+	req := &domain.UpdateRateLimitRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	apiKey, err := h.apiKeyUcase.UpdateRateLimit(id, req)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "api key not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(apiKey)
+}
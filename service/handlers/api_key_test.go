@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewAPIKeyHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockAPIKeyUsecase(ctrl)
+	handler := NewAPIKeyHandler(mock)
+
+	assert.NotNil(t, handler.apiKeyUcase)
+}
+
+func TestAPIKeyCreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockAPIKeyUsecase)
+		requestBody  *domain.CreateAPIKeyRequest
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().CreateAPIKey(userID, gomock.Any()).Return(&domain.CreateAPIKeyResponse{
+					APIKey: &models.APIKey{Name: "default"},
+					Key:    "sk_foo",
+				}, nil)
+			},
+			requestBody:  &domain.CreateAPIKeyRequest{Name: "default"},
+			expectedCode: fiber.StatusCreated,
+		}, {
+			name:         "error invalid request",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error empty name",
+			requestBody:  &domain.CreateAPIKeyRequest{},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error create api key",
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().CreateAPIKey(userID, gomock.Any()).Return(nil, usecases.ErrCreateAPIKey)
+			},
+			requestBody:  &domain.CreateAPIKeyRequest{Name: "default"},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockAPIKeyUsecase(ctrl)
+		handler := NewAPIKeyHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/keys", handler.CreateAPIKey)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("POST", "/keys", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedCode == fiber.StatusCreated {
+			body := map[string]interface{}{}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, "sk_foo", body["key"])
+			assert.Equal(t, "default", body["name"])
+		}
+	}
+}
+
+func TestAPIKeyListAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockAPIKeyUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().ListAPIKeys(userID).Return([]*models.APIKey{{Name: "default"}}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name: "error",
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().ListAPIKeys(userID).Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockAPIKeyUsecase(ctrl)
+		handler := NewAPIKeyHandler(mock)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/keys", handler.ListAPIKeys)
+		req := httptest.NewRequest("GET", "/keys", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestAPIKeyRevokeAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	keyID := uuid.New()
+	tests := []struct {
+		name         string
+		id           string
+		setup        func(mu *mockDomain.MockAPIKeyUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().RevokeAPIKey(userID, keyID).Return(nil)
+			},
+			expectedCode: fiber.StatusNoContent,
+		}, {
+			name:         "invalid id",
+			id:           "foo",
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "not found",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().RevokeAPIKey(userID, keyID).Return(gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().RevokeAPIKey(userID, keyID).Return(usecases.ErrRevokeAPIKey)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockAPIKeyUsecase(ctrl)
+		handler := NewAPIKeyHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Delete("/keys/:id", handler.RevokeAPIKey)
+		req := httptest.NewRequest("DELETE", "/keys/"+tt.id, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestAPIKeyUpdateRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyID := uuid.New()
+	tests := []struct {
+		name         string
+		id           string
+		setup        func(mu *mockDomain.MockAPIKeyUsecase)
+		requestBody  *domain.UpdateRateLimitRequest
+		expectedCode int
+	}{
+		{
+			name: "success",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().UpdateRateLimit(keyID, gomock.Any()).Return(&models.APIKey{ID: keyID, RateLimitMultiplier: 2}, nil)
+			},
+			requestBody:  &domain.UpdateRateLimitRequest{Multiplier: 2},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "invalid id",
+			id:           "foo",
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name:         "error invalid request",
+			id:           keyID.String(),
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error negative multiplier",
+			id:           keyID.String(),
+			requestBody:  &domain.UpdateRateLimitRequest{Multiplier: -1},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "not found",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().UpdateRateLimit(keyID, gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			requestBody:  &domain.UpdateRateLimitRequest{Multiplier: 2},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error",
+			id:   keyID.String(),
+			setup: func(mu *mockDomain.MockAPIKeyUsecase) {
+				mu.EXPECT().UpdateRateLimit(keyID, gomock.Any()).Return(nil, usecases.ErrUpdateAPIKey)
+			},
+			requestBody:  &domain.UpdateRateLimitRequest{Multiplier: 2},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockAPIKeyUsecase(ctrl)
+		handler := NewAPIKeyHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Patch("/keys/:id/rate-limit", handler.UpdateRateLimit)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("PATCH", "/keys/"+tt.id+"/rate-limit", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"url-shortener/domain"
+	"url-shortener/middleware"
+	"url-shortener/usecases"
+	"url-shortener/utils/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type domainHandler struct {
+	domainUcase domain.DomainUsecase
+}
+
+func NewDomainHandler(domainUcase domain.DomainUsecase) *domainHandler {
+	return &domainHandler{domainUcase}
+}
+
+func (h *domainHandler) ListDomains(c *fiber.Ctx) error {
+	domains, err := h.domainUcase.ListDomains()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(domains)
+}
+
+func (h *domainHandler) CreateDomain(c *fiber.Ctx) error {
+	req := &domain.CreateDomainRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	customDomain, err := h.domainUcase.CreateDomain(middleware.CurrentUserID(c), req)
+	if err != nil {
+		if err == usecases.ErrDomainExists {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(customDomain)
+}
+
+func (h *domainHandler) DeleteDomain(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "domain not found",
+		})
+	}
+
+	if err := h.domainUcase.DeleteDomain(id); err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "domain not found",
+			})
+		case usecases.ErrDomainInUse:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
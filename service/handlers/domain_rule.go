@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"url-shortener/domain"
+	"url-shortener/middleware"
+	"url-shortener/usecases"
+	"url-shortener/utils/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type domainRuleHandler struct {
+	policyUcase domain.DestinationPolicyUsecase
+}
+
+func NewDomainRuleHandler(policyUcase domain.DestinationPolicyUsecase) *domainRuleHandler {
+	return &domainRuleHandler{policyUcase}
+}
+
+func (h *domainRuleHandler) ListDomainRules(c *fiber.Ctx) error {
+	rules, err := h.policyUcase.ListRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(rules)
+}
+
+func (h *domainRuleHandler) CreateDomainRule(c *fiber.Ctx) error {
+	req := &domain.CreateDomainRuleRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	rule, err := h.policyUcase.CreateRule(middleware.CurrentUserID(c), req)
+	if err != nil {
+		switch err {
+		case usecases.ErrInvalidDomain:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case usecases.ErrDomainRuleExists:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (h *domainRuleHandler) DeleteDomainRule(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "domain rule not found",
+		})
+	}
+
+	if err := h.policyUcase.DeleteRule(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "domain rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
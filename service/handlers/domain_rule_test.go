@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewDomainRuleHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockDestinationPolicyUsecase(ctrl)
+	handler := NewDomainRuleHandler(mock)
+
+	assert.NotNil(t, handler.policyUcase)
+}
+
+func TestDomainRuleListDomainRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockDestinationPolicyUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().ListRules().Return([]*models.DomainRule{{Domain: "example.com"}}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name: "error",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().ListRules().Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDestinationPolicyUsecase(ctrl)
+		handler := NewDomainRuleHandler(mock)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/domain-rules", handler.ListDomainRules)
+		req := httptest.NewRequest("GET", "/domain-rules", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestDomainRuleCreateDomainRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	request := &domain.CreateDomainRuleRequest{Domain: "evil.com", Type: models.DomainRuleBlock}
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockDestinationPolicyUsecase)
+		requestBody  *domain.CreateDomainRuleRequest
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().CreateRule(userID, gomock.Any()).Return(&models.DomainRule{Domain: "evil.com"}, nil)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusCreated,
+		}, {
+			name:         "error invalid request",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error invalid type",
+			requestBody:  &domain.CreateDomainRuleRequest{Domain: "evil.com", Type: "deny"},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid domain",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().CreateRule(userID, gomock.Any()).Return(nil, usecases.ErrInvalidDomain)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error rule exists",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().CreateRule(userID, gomock.Any()).Return(nil, usecases.ErrDomainRuleExists)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusConflict,
+		}, {
+			name: "error create rule",
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().CreateRule(userID, gomock.Any()).Return(nil, usecases.ErrCreateDomainRule)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDestinationPolicyUsecase(ctrl)
+		handler := NewDomainRuleHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/domain-rules", handler.CreateDomainRule)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("POST", "/domain-rules", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestDomainRuleDeleteDomainRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	ruleID := uuid.New()
+	tests := []struct {
+		name         string
+		id           string
+		setup        func(mu *mockDomain.MockDestinationPolicyUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			id:   ruleID.String(),
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().DeleteRule(ruleID).Return(nil)
+			},
+			expectedCode: fiber.StatusNoContent,
+		}, {
+			name:         "invalid id",
+			id:           "foo",
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "not found",
+			id:   ruleID.String(),
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().DeleteRule(ruleID).Return(gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error",
+			id:   ruleID.String(),
+			setup: func(mu *mockDomain.MockDestinationPolicyUsecase) {
+				mu.EXPECT().DeleteRule(ruleID).Return(usecases.ErrDeleteDomainRule)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDestinationPolicyUsecase(ctrl)
+		handler := NewDomainRuleHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Delete("/domain-rules/:id", handler.DeleteDomainRule)
+		req := httptest.NewRequest("DELETE", "/domain-rules/"+tt.id, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
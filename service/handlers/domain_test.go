@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewDomainHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockDomainUsecase(ctrl)
+	handler := NewDomainHandler(mock)
+
+	assert.NotNil(t, handler.domainUcase)
+}
+
+func TestDomainListDomains(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockDomainUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().ListDomains().Return([]*models.Domain{{Name: "go.example.com"}}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name: "error",
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().ListDomains().Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDomainUsecase(ctrl)
+		handler := NewDomainHandler(mock)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/domains", handler.ListDomains)
+		req := httptest.NewRequest("GET", "/domains", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestDomainCreateDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	request := &domain.CreateDomainRequest{Name: "go.example.com"}
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockDomainUsecase)
+		requestBody  *domain.CreateDomainRequest
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().CreateDomain(userID, gomock.Any()).Return(&models.Domain{Name: "go.example.com"}, nil)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusCreated,
+		}, {
+			name:         "error invalid request",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error invalid name",
+			requestBody:  &domain.CreateDomainRequest{Name: "not a domain"},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error domain exists",
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().CreateDomain(userID, gomock.Any()).Return(nil, usecases.ErrDomainExists)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusConflict,
+		}, {
+			name: "error create domain",
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().CreateDomain(userID, gomock.Any()).Return(nil, usecases.ErrCreateDomain)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDomainUsecase(ctrl)
+		handler := NewDomainHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/domains", handler.CreateDomain)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("POST", "/domains", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestDomainDeleteDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	domainID := uuid.New()
+	tests := []struct {
+		name         string
+		id           string
+		setup        func(mu *mockDomain.MockDomainUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			id:   domainID.String(),
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().DeleteDomain(domainID).Return(nil)
+			},
+			expectedCode: fiber.StatusNoContent,
+		}, {
+			name:         "invalid id",
+			id:           "foo",
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "not found",
+			id:   domainID.String(),
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().DeleteDomain(domainID).Return(gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "in use",
+			id:   domainID.String(),
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().DeleteDomain(domainID).Return(usecases.ErrDomainInUse)
+			},
+			expectedCode: fiber.StatusConflict,
+		}, {
+			name: "error",
+			id:   domainID.String(),
+			setup: func(mu *mockDomain.MockDomainUsecase) {
+				mu.EXPECT().DeleteDomain(domainID).Return(usecases.ErrDeleteDomain)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockDomainUsecase(ctrl)
+		handler := NewDomainHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Delete("/domains/:id", handler.DeleteDomain)
+		req := httptest.NewRequest("DELETE", "/domains/"+tt.id, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
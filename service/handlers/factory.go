@@ -1,23 +1,203 @@
 package handlers
 
 import (
+	"context"
+	"strconv"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/helpers"
+	"url-shortener/logs"
+	"url-shortener/middleware"
 	"url-shortener/repositories"
 	"url-shortener/usecases"
+	"url-shortener/utils/geoip"
+	"url-shortener/utils/slashcode"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type Factory struct {
-	ShortLink *shortLinkHandler
+	ShortLink      *shortLinkHandler
+	APIKey         *apiKeyHandler
+	DomainRule     *domainRuleHandler
+	Domain         *domainHandler
+	Webhook        *webhookHandler
+	Health         *healthHandler
+	Authenticate   fiber.Handler
+	RequireAdmin   fiber.Handler
+	Limiter        func(requests int, reset time.Duration) fiber.Handler
+	NotFoundBudget fiber.Handler
+
+	shortLinkRepo  domain.ShortLinkRepository
+	shortLinkUcase domain.ShortLinkUsecase
+	webhookUcase   domain.WebhookUsecase
+	healthUcase    domain.HealthUsecase
 }
 
 func NewFactory(db *gorm.DB, rdb *redis.Client) *Factory {
-	shortLinkRepo := repositories.NewShortLinkRepository(db, rdb)
-	shortLinkUcase := usecases.NewShortLinkUsecase(shortLinkRepo)
-	shortLinkHandler := NewShortLinkHandler(shortLinkUcase)
+	domainRuleRepo := repositories.NewDomainRuleRepository(db)
+	policyUcase := usecases.NewDestinationPolicyFromEnv(domainRuleRepo)
+	domainRuleHandler := NewDomainRuleHandler(policyUcase)
+
+	domainRepo := repositories.NewDomainRepository(db)
+	domainUcase := usecases.NewDomainUsecase(domainRepo)
+	domainHandler := NewDomainHandler(domainUcase)
+
+	webhookRepo := repositories.NewWebhookRepository(db)
+	webhookUcase := usecases.NewWebhookUsecaseFromEnv(webhookRepo, policyUcase)
+	webhookHandler := NewWebhookHandler(webhookUcase)
+
+	localSize, localTTL := localCacheFromEnv()
+	shortLinkRepo := repositories.NewShortLinkRepository(db, rdb, localSize, localTTL)
+	clickRepo := repositories.NewClickRepository(db)
+	generator, slashLength := slashcode.NewFromEnv(rdb)
+	shortLinkUcase := usecases.NewShortLinkUsecase(usecases.ShortLinkUsecaseOptions{
+		ShortLinkRepo: shortLinkRepo,
+		ClickRepo:     clickRepo,
+		Policy:        policyUcase,
+		Domains:       domainUcase,
+		Geo:           geoFromEnv(),
+		Webhooks:      webhookUcase,
+		Generator:     generator,
+		SlashLength:   slashLength,
+	})
+	qrCodeRepo := repositories.NewQRCodeRepository(rdb)
+	qrCodeUcase := usecases.NewQRCodeUsecase(qrCodeRepo)
+	shortLinkHandler := NewShortLinkHandler(shortLinkUcase, qrCodeUcase)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	apiKeyUcase := usecases.NewAPIKeyUsecase(apiKeyRepo)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyUcase)
+
+	userRepo := repositories.NewUserRepository(db)
+	userUcase := usecases.NewUserUsecase(userRepo)
+
+	rateLimitRepo := repositories.NewRateLimitRepository(rdb)
+	budget, window := notFoundBudgetFromEnv()
+
+	healthRepo := repositories.NewHealthRepository(db, rdb)
+	healthUcase := usecases.NewHealthUsecase(healthRepo)
+	healthHandler := NewHealthHandler(healthUcase)
 
 	return &Factory{
-		ShortLink: shortLinkHandler,
+		ShortLink:      shortLinkHandler,
+		APIKey:         apiKeyHandler,
+		DomainRule:     domainRuleHandler,
+		Domain:         domainHandler,
+		Webhook:        webhookHandler,
+		Health:         healthHandler,
+		Authenticate:   middleware.Authenticate(apiKeyUcase),
+		RequireAdmin:   middleware.RequireAdmin(userUcase),
+		Limiter:        middleware.Limiter(rateLimitRepo),
+		NotFoundBudget: middleware.NotFoundBudget(rateLimitRepo, budget, window),
+
+		shortLinkRepo:  shortLinkRepo,
+		shortLinkUcase: shortLinkUcase,
+		webhookUcase:   webhookUcase,
+		healthUcase:    healthUcase,
+	}
+}
+
+// RunJobs runs the background jobs until ctx is cancelled.
+func (f *Factory) RunJobs(ctx context.Context) {
+	go f.shortLinkRepo.ListenCacheInvalidation(ctx)
+	go f.shortLinkUcase.RefreshSlashCodeFilter()
+
+	interval, err := time.ParseDuration(helpers.Getenv("VISITOR_FLUSH_INTERVAL", "10s"))
+	if err != nil || interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	webhookInterval, err := time.ParseDuration(helpers.Getenv("WEBHOOK_INTERVAL", "5s"))
+	if err != nil || webhookInterval <= 0 {
+		webhookInterval = 5 * time.Second
+	}
+
+	webhookTicker := time.NewTicker(webhookInterval)
+	defer webhookTicker.Stop()
+
+	// The filter is dropped whenever invalidations may have been missed, so
+	// it is checked often and rebuilt as soon as it's gone.
+	filterTicker := time.NewTicker(time.Minute)
+	defer filterTicker.Stop()
+
+	expiredTicker := time.NewTicker(time.Minute)
+	defer expiredTicker.Stop()
+
+	invalidationTicker := time.NewTicker(5 * time.Second)
+	defer invalidationTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			f.shortLinkUcase.FlushVisitors()
+		case <-filterTicker.C:
+			go f.shortLinkUcase.RefreshSlashCodeFilter()
+		case <-expiredTicker.C:
+			go f.shortLinkUcase.PublishExpired()
+		case <-invalidationTicker.C:
+			f.shortLinkUcase.RetryCacheInvalidations()
+		case <-webhookTicker.C:
+			go f.webhookUcase.Deliver()
+		}
+	}
+}
+
+// Drain writes out the state buffered by the usecases. Errors are logged
+// by the usecases themselves.
+func (f *Factory) Drain() {
+	f.shortLinkUcase.DrainVisitors()
+}
+
+// Shutdown fails readiness checks so the orchestrator stops sending traffic
+// before the server stops accepting it.
+func (f *Factory) Shutdown() {
+	f.healthUcase.Shutdown()
+}
+
+func localCacheFromEnv() (int, time.Duration) {
+	size, err := strconv.Atoi(helpers.Getenv("LOCAL_CACHE_SIZE", "10000"))
+	if err != nil || size < 0 {
+		size = 10000
+	}
+	ttl, err := time.ParseDuration(helpers.Getenv("LOCAL_CACHE_TTL", "1m"))
+	if err != nil || ttl < 0 {
+		ttl = time.Minute
+	}
+	return size, ttl
+}
+
+func notFoundBudgetFromEnv() (int, time.Duration) {
+	budget, err := strconv.Atoi(helpers.Getenv("NOT_FOUND_BUDGET", "20"))
+	if err != nil || budget <= 0 {
+		budget = 20
+	}
+	window, err := time.ParseDuration(helpers.Getenv("NOT_FOUND_WINDOW", "10m"))
+	if err != nil || window <= 0 {
+		window = 10 * time.Minute
+	}
+	return budget, window
+}
+
+// geoFromEnv opens the GeoIP database at GEOIP_DATABASE. Without one, rules
+// on countries never match.
+func geoFromEnv() domain.GeoLocator {
+	path := helpers.Getenv("GEOIP_DATABASE", "")
+	if path == "" {
+		return nil
+	}
+
+	reader, err := geoip.Open(path)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil
 	}
+	return reader
 }
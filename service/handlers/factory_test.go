@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"testing"
+	"url-shortener/middleware"
+	"url-shortener/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
@@ -43,6 +47,13 @@ func SetupRedisMock(t *testing.T) (*miniredis.Miniredis, *redis.Client, func())
 	return mr, rdb, cleanup
 }
 
+func SetupAuthenticatedUser(userID uuid.UUID) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(middleware.APIKeyLocal, &models.APIKey{UserID: userID})
+		return c.Next()
+	}
+}
+
 func TestNewFactory(t *testing.T) {
 	db, _, closeDB := SetupDatabaseMock(t)
 	defer closeDB()
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"url-shortener/domain"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type healthHandler struct {
+	healthUcase domain.HealthUsecase
+}
+
+func NewHealthHandler(healthUcase domain.HealthUsecase) *healthHandler {
+	return &healthHandler{healthUcase}
+}
+
+func (h *healthHandler) Liveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": usecases.HealthStatusOK,
+	})
+}
+
+func (h *healthHandler) Readiness(c *fiber.Ctx) error {
+	readiness := h.healthUcase.Readiness()
+	if readiness.Status != usecases.HealthStatusOK && readiness.Status != usecases.HealthStatusDegraded {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(readiness)
+	}
+
+	return c.JSON(readiness)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewHealthHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockHealthUsecase(ctrl)
+	handler := NewHealthHandler(mock)
+
+	assert.NotNil(t, handler.healthUcase)
+}
+
+func TestHealthLiveness(t *testing.T) {
+	handler := NewHealthHandler(nil)
+
+	app := fiber.New()
+	app.Get("/healthz", handler.Liveness)
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	res, _ := app.Test(req)
+	defer res.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+}
+
+func TestHealthReadiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name         string
+		readiness    *domain.Readiness
+		expectedCode int
+	}{
+		{
+			name:         "ready",
+			readiness:    &domain.Readiness{Status: usecases.HealthStatusOK},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "degraded",
+			readiness:    &domain.Readiness{Status: usecases.HealthStatusDegraded},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "unavailable",
+			readiness:    &domain.Readiness{Status: usecases.HealthStatusUnavailable},
+			expectedCode: fiber.StatusServiceUnavailable,
+		}, {
+			name:         "shutting down",
+			readiness:    &domain.Readiness{Status: usecases.HealthStatusShuttingDown},
+			expectedCode: fiber.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockHealthUsecase(ctrl)
+		handler := NewHealthHandler(mock)
+		mock.EXPECT().Readiness().Return(tt.readiness)
+
+		app := fiber.New()
+		app.Get("/readyz", handler.Readiness)
+		req := httptest.NewRequest("GET", "/readyz", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"html/template"
 	"strings"
 	"url-shortener/domain"
+	"url-shortener/middleware"
+	"url-shortener/models"
 	"url-shortener/usecases"
 	"url-shortener/utils/validation"
 
@@ -10,12 +16,58 @@ import (
 	"gorm.io/gorm"
 )
 
+var unlockPage = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Password required</title>
+</head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>This link is password protected.</p>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<input type="password" name="password" autocomplete="current-password" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// previewPage is served to crawlers unfurling a link with its own Open Graph
+// tags. Anything following the refresh goes on to the destination.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{.Destination}}">
+<title>{{.Title}}</title>
+{{if .Title}}<meta property="og:title" content="{{.Title}}">
+<meta name="twitter:title" content="{{.Title}}">
+{{end}}{{if .Description}}<meta name="description" content="{{.Description}}">
+<meta property="og:description" content="{{.Description}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{end}}{{if .Image}}<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">
+{{else}}<meta name="twitter:card" content="summary">
+{{end}}<script>window.location.replace({{.Destination}});</script>
+</head>
+<body>
+<a href="{{.Destination}}">Continue</a>
+</body>
+</html>
+`))
+
 type shortLinkHandler struct {
 	shortLinkUcase domain.ShortLinkUsecase
+	qrCodeUcase    domain.QRCodeUsecase
 }
 
-func NewShortLinkHandler(shortLinkUcase domain.ShortLinkUsecase) *shortLinkHandler {
-	return &shortLinkHandler{shortLinkUcase}
+func NewShortLinkHandler(shortLinkUcase domain.ShortLinkUsecase, qrCodeUcase domain.QRCodeUsecase) *shortLinkHandler {
+	return &shortLinkHandler{shortLinkUcase, qrCodeUcase}
 }
 
 func (h *shortLinkHandler) CreateShortLink(c *fiber.Ctx) error {
@@ -27,54 +79,594 @@ func (h *shortLinkHandler) CreateShortLink(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Destination == "" {
+	dest, err := usecases.NormalizeDestination(req.Destination)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "destination is required",
+			"message": err.Error(),
 		})
-	} else if !strings.Contains(req.Destination, ".") && !strings.Contains(req.Destination, ":") {
+	}
+	req.Destination = dest
+
+	if errs := validator.ValidateStruct(req); errs != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "destination invalid",
+			"message": errs[0].Message,
 		})
-	} else if !strings.Contains(req.Destination, "://") {
-		req.Destination = "https://" + req.Destination
 	}
 
-	if errs := validator.ValidateStruct(req); errs != nil {
+	// ?qr=png|svg adds the link's QR code to the response as a data URI.
+	qrReq := &domain.QRCodeRequest{Format: c.Query("qr")}
+	if errs := validator.ValidateStruct(qrReq); errs != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": errs[0].Message,
 		})
 	}
 
-	shortLink, err := h.shortLinkUcase.CreateShortLink(req)
+	shortLink, err := h.shortLinkUcase.CreateShortLink(middleware.CurrentUserID(c), req)
 	if err != nil {
 		if err == usecases.ErrSlashCodeExists {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"message": err.Error(),
 			})
 		}
+		if isDestinationPolicyError(err) || err == usecases.ErrDomainNotRegistered {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
 
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
-	shortLink.Origin = c.BaseURL() + "/" + shortLink.SlashCode
+	setOrigin(c, shortLink)
+
+	// The link exists already, so a QR code that can't be drawn is left out
+	// instead of failing the request.
+	if qrReq.Format != "" {
+		if qr, err := h.qrCodeUcase.Render(shortLink.Origin, qrReq); err == nil {
+			shortLink.QRCode = "data:" + qr.ContentType + ";base64," + base64.StdEncoding.EncodeToString(qr.Image)
+		}
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(shortLink)
 }
 
+func (h *shortLinkHandler) BulkCreateShortLinks(c *fiber.Ctx) error {
+	req := &domain.BulkCreateShortLinkRequest{}
+
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	results := make([]*domain.BulkCreateResult, len(req.Items))
+	var items []*domain.CreateShortLinkRequest
+	var indexes []int
+	for i, item := range req.Items {
+		results[i] = &domain.BulkCreateResult{Index: i}
+		if item == nil {
+			results[i].Error = usecases.ErrDestinationRequired.Error()
+			continue
+		}
+
+		dest, err := usecases.NormalizeDestination(item.Destination)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		item.Destination = dest
+
+		if errs := validator.ValidateStruct(item); errs != nil {
+			results[i].Error = errs[0].Message
+			continue
+		}
+
+		items = append(items, item)
+		indexes = append(indexes, i)
+	}
+
+	if len(items) > 0 {
+		created, err := h.shortLinkUcase.CreateShortLinks(middleware.CurrentUserID(c), items)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		for j, result := range created {
+			result.Index = indexes[j]
+			if result.Data != nil {
+				setOrigin(c, result.Data)
+			}
+			results[indexes[j]] = result
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data": results,
+	})
+}
+
+func (h *shortLinkHandler) ImportShortLinks(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		format = contentFormat(c.Get(fiber.HeaderContentType))
+	}
+
+	result, err := h.shortLinkUcase.ImportShortLinks(middleware.CurrentUserID(c), format, bytes.NewReader(c.Body()))
+	if err != nil {
+		if err == usecases.ErrInvalidFormat || err == usecases.ErrInvalidImport {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+func (h *shortLinkHandler) ExportShortLinks(c *fiber.Ctx) error {
+	format := c.Query("format", usecases.FormatCSV)
+	contentType := map[string]string{
+		usecases.FormatCSV:    "text/csv",
+		usecases.FormatNDJSON: "application/x-ndjson",
+	}[format]
+	if contentType == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": usecases.ErrInvalidFormat.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="links.`+format+`"`)
+
+	// The status is already sent once streaming starts, so a failure halfway
+	// can only end the response early. The usecase logs it.
+	ownerID := middleware.CurrentUserID(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		h.shortLinkUcase.ExportShortLinks(ownerID, format, w)
+		w.Flush()
+	})
+
+	return nil
+}
+
+func (h *shortLinkHandler) FindShortLink(c *fiber.Ctx) error {
+	shortLink, err := h.shortLinkUcase.FindBySlashCode(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"))
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	setOrigin(c, shortLink)
+
+	return c.JSON(shortLink)
+}
+
+func (h *shortLinkHandler) ListShortLinks(c *fiber.Ctx) error {
+	req := &domain.ListShortLinksRequest{}
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid query",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	page, err := h.shortLinkUcase.ListShortLinks(middleware.CurrentUserID(c), req)
+	if err != nil {
+		if err == usecases.ErrInvalidCursor || err == usecases.ErrInvalidFilter {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return shortLinkErrorResponse(c, err)
+	}
+
+	for _, shortLink := range page.Data {
+		setOrigin(c, shortLink)
+	}
+
+	return c.JSON(page)
+}
+
+func (h *shortLinkHandler) UpdateShortLink(c *fiber.Ctx) error {
+	req := &domain.UpdateShortLinkRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if *req == (domain.UpdateShortLinkRequest{}) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "nothing to update",
+		})
+	}
+
+	if req.Destination != "" {
+		dest, err := usecases.NormalizeDestination(req.Destination)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		req.Destination = dest
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	shortLink, err := h.shortLinkUcase.UpdateShortLink(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"), req)
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	setOrigin(c, shortLink)
+
+	return c.JSON(shortLink)
+}
+
+func (h *shortLinkHandler) FindRules(c *fiber.Ctx) error {
+	rules, err := h.shortLinkUcase.FindRules(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"))
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rules,
+	})
+}
+
+func (h *shortLinkHandler) ReplaceRules(c *fiber.Ctx) error {
+	req := &domain.ReplaceLinkRulesRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	for _, rule := range req.Rules {
+		if rule == nil {
+			continue
+		}
+		dest, err := usecases.NormalizeDestination(rule.Destination)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		rule.Destination = dest
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	rules, err := h.shortLinkUcase.ReplaceRules(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"), req)
+	if err != nil {
+		if err == usecases.ErrInvalidTimeWindow {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": rules,
+	})
+}
+
+func (h *shortLinkHandler) FindVariants(c *fiber.Ctx) error {
+	variants, err := h.shortLinkUcase.FindVariants(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"))
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": variants,
+	})
+}
+
+func (h *shortLinkHandler) ReplaceVariants(c *fiber.Ctx) error {
+	req := &domain.ReplaceLinkVariantsRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	for _, variant := range req.Variants {
+		if variant == nil {
+			continue
+		}
+		dest, err := usecases.NormalizeDestination(variant.Destination)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		variant.Destination = dest
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	variants, err := h.shortLinkUcase.ReplaceVariants(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"), req)
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": variants,
+	})
+}
+
+func (h *shortLinkHandler) DeleteShortLink(c *fiber.Ctx) error {
+	if err := h.shortLinkUcase.DeleteShortLink(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash")); err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *shortLinkHandler) GetStats(c *fiber.Ctx) error {
+	req := &domain.StatsRequest{}
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid query",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	stats, err := h.shortLinkUcase.GetStats(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"), req)
+	if err != nil {
+		if err == usecases.ErrInvalidFilter {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return shortLinkErrorResponse(c, err)
+	}
+
+	return c.JSON(stats)
+}
+
+func (h *shortLinkHandler) GetQRCode(c *fiber.Ctx) error {
+	req := &domain.QRCodeRequest{}
+
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "invalid query",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	shortLink, err := h.shortLinkUcase.FindBySlashCode(middleware.CurrentUserID(c), c.Query("domain"), c.Params("slash"))
+	if err != nil {
+		return shortLinkErrorResponse(c, err)
+	}
+
+	setOrigin(c, shortLink)
+
+	qr, err := h.qrCodeUcase.Render(shortLink.Origin, req)
+	if err != nil {
+		if err == usecases.ErrInvalidQRCodeFormat || err == usecases.ErrInvalidColor {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, qr.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	return c.Send(qr.Image)
+}
+
 func (h *shortLinkHandler) Redirect(c *fiber.Ctx) error {
 	slash := c.Params("slash")
-	dest, err := h.shortLinkUcase.Redirect(slash)
+	visit := &domain.Visit{
+		Referrer:       c.Get(fiber.HeaderReferer),
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		IP:             c.IP(),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+		Query:          string(c.Request().URI().QueryString()),
+	}
+
+	redirection, err := h.shortLinkUcase.Redirect(c.Hostname(), slash, visit)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.SendStatus(fiber.StatusNotFound)
+		if err == usecases.ErrPasswordRequired {
+			return renderUnlockPage(c, fiber.StatusOK, slash, "")
 		}
+		return redirectErrorResponse(c, err)
+	}
+	if redirection.Preview != nil {
+		return renderPreviewPage(c, redirection)
+	}
+
+	// Temporary redirects must reach the server every time so clicks are
+	// counted and the destination can still be changed. Redirects that
+	// depend on the visitor are never shared.
+	switch {
+	case redirection.Private:
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+	case redirection.StatusCode == fiber.StatusMovedPermanently || redirection.StatusCode == fiber.StatusPermanentRedirect:
+		c.Set(fiber.HeaderCacheControl, "max-age=180")
+	default:
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	}
+	return c.Redirect(redirection.Destination, redirection.StatusCode)
+}
+
+// Unlock takes the password posted from the unlock page. It always answers
+// with 303 so the browser follows up with a GET and the password is never
+// sent on to the destination.
+func (h *shortLinkHandler) Unlock(c *fiber.Ctx) error {
+	slash := c.Params("slash")
+	visit := &domain.Visit{
+		Referrer:       c.Get(fiber.HeaderReferer),
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		IP:             c.IP(),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+		Query:          string(c.Request().URI().QueryString()),
+	}
+
+	redirection, err := h.shortLinkUcase.Unlock(c.Hostname(), slash, c.FormValue("password"), visit)
+	if err != nil {
+		switch err {
+		case usecases.ErrInvalidPassword:
+			return renderUnlockPage(c, fiber.StatusUnauthorized, slash, err.Error())
+		case usecases.ErrTooManyAttempts:
+			return renderUnlockPage(c, fiber.StatusTooManyRequests, slash, err.Error())
+		}
+		return redirectErrorResponse(c, err)
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Redirect(redirection.Destination, fiber.StatusSeeOther)
+}
+
+func renderUnlockPage(c *fiber.Ctx, status int, slash string, message string) error {
+	var buf bytes.Buffer
+	// The query is kept so it can still be passed through once unlocked.
+	action := "/" + slash
+	if query := c.Request().URI().QueryString(); len(query) > 0 {
+		action += "?" + string(query)
+	}
+	data := struct {
+		Action string
+		Error  string
+	}{action, message}
+	if err := unlockPage.Execute(&buf, data); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(status).Send(buf.Bytes())
+}
+
+func renderPreviewPage(c *fiber.Ctx, redirection *domain.Redirection) error {
+	var buf bytes.Buffer
+	data := struct {
+		*models.OpenGraph
+		Destination string
+	}{redirection.Preview, redirection.Destination}
+	if err := previewPage.Execute(&buf, data); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": err.Error(),
 		})
 	}
 
-	c.Set("Cache-Control", "max-age=180")
-	return c.Redirect(dest, fiber.StatusMovedPermanently)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+func redirectErrorResponse(c *fiber.Ctx, err error) error {
+	switch err {
+	case gorm.ErrRecordNotFound:
+		return c.SendStatus(fiber.StatusNotFound)
+	case usecases.ErrShortLinkExpired:
+		return c.SendStatus(fiber.StatusGone)
+	case usecases.ErrShortLinkBlocked:
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func contentFormat(contentType string) string {
+	if strings.Contains(contentType, "csv") {
+		return usecases.FormatCSV
+	}
+	if strings.Contains(contentType, "ndjson") {
+		return usecases.FormatNDJSON
+	}
+	return ""
+}
+
+func setOrigin(c *fiber.Ctx, shortLink *models.ShortLink) {
+	if shortLink.Domain != "" {
+		shortLink.Origin = c.Protocol() + "://" + shortLink.Domain + "/" + shortLink.SlashCode
+	} else {
+		shortLink.Origin = c.BaseURL() + "/" + shortLink.SlashCode
+	}
+	shortLink.Protected = shortLink.PasswordHash != ""
+}
+
+func shortLinkErrorResponse(c *fiber.Ctx, err error) error {
+	if err == gorm.ErrRecordNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "short link not found",
+		})
+	}
+	if err == usecases.ErrNotOwner {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if isDestinationPolicyError(err) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
+
+func isDestinationPolicyError(err error) bool {
+	switch err {
+	case usecases.ErrInvalidDestination, usecases.ErrSchemeNotAllowed, usecases.ErrPrivateDestination,
+		usecases.ErrDomainBlocked, usecases.ErrDomainNotAllowed:
+		return true
+	}
+	return false
 }
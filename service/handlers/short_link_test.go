@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 	"url-shortener/domain"
 	mockDomain "url-shortener/domain/mocks"
 	"url-shortener/helpers"
@@ -13,6 +16,7 @@ import (
 	"url-shortener/usecases"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"gorm.io/gorm"
@@ -23,7 +27,7 @@ func TestNewShortLinkHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mock := mockDomain.NewMockShortLinkUsecase(ctrl)
-	handler := NewShortLinkHandler(mock)
+	handler := NewShortLinkHandler(mock, nil)
 
 	assert.NotNil(t, handler.shortLinkUcase)
 }
@@ -32,11 +36,15 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	userID := uuid.New()
+
 	mockShortLink := &models.ShortLink{
 		SlashCode:   "foo",
 		Origin:      "http://example.com/foo",
 		Destination: "https://www.google.com",
 	}
+	past := time.Now().Add(-1 * time.Hour)
+	zero := 0
 
 	tests := []struct {
 		name         string
@@ -48,7 +56,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 		{
 			name: "success without schema",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().CreateShortLink(gomock.Any()).DoAndReturn(func(req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
 					return &models.ShortLink{
 						SlashCode:   mockShortLink.SlashCode,
 						Destination: req.Destination,
@@ -67,7 +75,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 		}, {
 			name: "success with schema",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().CreateShortLink(gomock.Any()).DoAndReturn(func(req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
 					return &models.ShortLink{
 						SlashCode:   mockShortLink.SlashCode,
 						Destination: req.Destination,
@@ -86,7 +94,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 		}, {
 			name: "success with custom slash code",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().CreateShortLink(gomock.Any()).DoAndReturn(func(req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
 					return &models.ShortLink{
 						SlashCode:   req.SlashCode,
 						Destination: req.Destination,
@@ -110,6 +118,13 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 			name:         "error empty request",
 			requestBody:  &domain.CreateShortLinkRequest{},
 			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid redirect type",
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination:  mockShortLink.Destination,
+				RedirectType: 303,
+			},
+			expectedCode: fiber.StatusBadRequest,
 		}, {
 			name: "error invalid url",
 			requestBody: &domain.CreateShortLinkRequest{
@@ -122,10 +137,31 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockShortLink.Destination + "/" + helpers.StrRandom(512),
 			},
 			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error expires in the past",
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+				ExpiresAt:   &past,
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid max visits",
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+				MaxVisits:   &zero,
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error og image not a web url",
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+				OG:          &domain.OGParameters{Title: "Spring sale", Image: "javascript:alert(1)"},
+			},
+			expectedCode: fiber.StatusBadRequest,
 		}, {
 			name: "error create short link",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().CreateShortLink(gomock.Any()).Return(nil, errors.New("error"))
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 			},
 			requestBody: &domain.CreateShortLinkRequest{
 				Destination: mockShortLink.Destination,
@@ -134,24 +170,63 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 		}, {
 			name: "error slash code is exists",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().CreateShortLink(gomock.Any()).Return(nil, usecases.ErrSlashCodeExists)
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(nil, usecases.ErrSlashCodeExists)
 			},
 			requestBody: &domain.CreateShortLinkRequest{
 				SlashCode:   mockShortLink.SlashCode,
 				Destination: mockShortLink.Destination,
 			},
 			expectedCode: fiber.StatusConflict,
+		}, {
+			name: "error scheme not allowed",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+					assert.Equal(t, "javascript:alert(1)", req.Destination)
+					return nil, usecases.ErrSchemeNotAllowed
+				})
+			},
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: "javascript:alert(1)",
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error blocked destination",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(nil, usecases.ErrDomainBlocked)
+			},
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error domain not registered",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(nil, usecases.ErrDomainNotRegistered)
+			},
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+				Domain:      "go.example.org",
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid domain",
+			requestBody: &domain.CreateShortLinkRequest{
+				Destination: mockShortLink.Destination,
+				Domain:      "not a domain",
+			},
+			expectedCode: fiber.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
-		handler := NewShortLinkHandler(mock)
+		handler := NewShortLinkHandler(mock, nil)
 		if tt.setup != nil {
 			tt.setup(mock)
 		}
 
 		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
 		app.Post("/links", handler.CreateShortLink)
 
 		var buf bytes.Buffer
@@ -178,36 +253,207 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 	}
 }
 
-func TestShortLinkRedirect(t *testing.T) {
+func TestShortLinkCreateShortLinkQRCode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	destination := "http://www.example.com"
-	err := errors.New("internal error")
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase)
+		expectedCode int
+		expectedQR   string
+	}{
+		{
+			name:  "success",
+			query: "?qr=png",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(&models.ShortLink{SlashCode: "foo"}, nil)
+				mq.EXPECT().Render("http://example.com/foo", gomock.Any()).Return(&domain.QRCode{ContentType: "image/png", Image: []byte("png")}, nil)
+			},
+			expectedCode: fiber.StatusCreated,
+			expectedQR:   "data:image/png;base64,cG5n",
+		}, {
+			name:         "error invalid format",
+			query:        "?qr=gif",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:  "render error leaves qr code out",
+			query: "?qr=svg",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().CreateShortLink(gomock.Any(), gomock.Any()).Return(&models.ShortLink{SlashCode: "foo"}, nil)
+				mq.EXPECT().Render("http://example.com/foo", gomock.Any()).Return(nil, usecases.ErrRenderQRCode)
+			},
+			expectedCode: fiber.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		mockQR := mockDomain.NewMockQRCodeUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, mockQR)
+		if tt.setup != nil {
+			tt.setup(mock, mockQR)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/links", handler.CreateShortLink)
+		req := httptest.NewRequest("POST", "/links"+tt.query, strings.NewReader(`{"destination": "https://www.google.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedCode == fiber.StatusCreated {
+			body := &models.ShortLink{}
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(body))
+			assert.Equal(t, tt.expectedQR, body.QRCode)
+		}
+	}
+}
+
+func TestShortLinkBulkCreateShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name           string
+		setup          func(mu *mockDomain.MockShortLinkUsecase)
+		requestBody    string
+		expectedCode   int
+		expectedErrors []string
+	}{
+		{
+			name: "success with invalid items",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().CreateShortLinks(userID, gomock.Len(2)).DoAndReturn(func(ownerID uuid.UUID, reqs []*domain.CreateShortLinkRequest) ([]*domain.BulkCreateResult, error) {
+					assert.Equal(t, "https://example.com", reqs[0].Destination)
+					return []*domain.BulkCreateResult{
+						{Index: 0, Data: &models.ShortLink{SlashCode: "foo", Destination: reqs[0].Destination}},
+						{Index: 1, Error: usecases.ErrSlashCodeExists.Error()},
+					}, nil
+				})
+			},
+			requestBody:  `{"items": [{"destination": "example.com"}, {"destination": ""}, {"slash_code": "bar", "destination": "https://example.org"}, {"destination": "https://example.org", "redirect_type": 303}]}`,
+			expectedCode: fiber.StatusOK,
+			expectedErrors: []string{
+				"",
+				"destination is required",
+				usecases.ErrSlashCodeExists.Error(),
+				"[redirect_type]: need to implement 'oneof[301 302 307 308]'",
+			},
+		}, {
+			name:         "all items invalid",
+			requestBody:  `{"items": [{"destination": "foo"}]}`,
+			expectedCode: fiber.StatusOK,
+			expectedErrors: []string{
+				"destination invalid",
+			},
+		}, {
+			name:         "error invalid request",
+			requestBody:  `{"items": `,
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error empty items",
+			requestBody:  `{"items": []}`,
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error create short links",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().CreateShortLinks(userID, gomock.Len(1)).Return(nil, usecases.ErrUnexpected)
+			},
+			requestBody:  `{"items": [{"destination": "https://example.com"}]}`,
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/links/bulk", handler.BulkCreateShortLinks)
+
+		req := httptest.NewRequest("POST", "/links/bulk", strings.NewReader(tt.requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+		if tt.expectedErrors != nil {
+			body := struct {
+				Data []*domain.BulkCreateResult `json:"data"`
+			}{}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+
+			assert.Len(t, body.Data, len(tt.expectedErrors))
+			for i, result := range body.Data {
+				assert.Equal(t, i, result.Index)
+				assert.Equal(t, tt.expectedErrors[i], result.Error)
+				if result.Error == "" {
+					assert.Equal(t, "http://example.com/"+result.Data.SlashCode, result.Data.Origin)
+				}
+			}
+		}
+	}
+}
+
+func TestShortLinkImportShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	result := &domain.ImportResult{Total: 1, Created: 1, Errors: []*domain.ImportError{}}
 
 	tests := []struct {
 		name         string
+		url          string
+		contentType  string
 		setup        func(mu *mockDomain.MockShortLinkUsecase)
-		expected     string
 		expectedCode int
 	}{
 		{
-			name: "redirect",
+			name:        "success with format query",
+			url:         "/links/import?format=ndjson",
+			contentType: "application/json",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().Redirect(gomock.Any()).Return(destination, nil)
+				mu.EXPECT().ImportShortLinks(userID, usecases.FormatNDJSON, gomock.Any()).Return(result, nil)
 			},
-			expected:     destination,
-			expectedCode: fiber.StatusMovedPermanently,
+			expectedCode: fiber.StatusOK,
 		}, {
-			name: "not found",
+			name:        "success with content type",
+			url:         "/links/import",
+			contentType: "text/csv",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().Redirect(gomock.Any()).Return("", gorm.ErrRecordNotFound)
+				mu.EXPECT().ImportShortLinks(userID, usecases.FormatCSV, gomock.Any()).Return(result, nil)
 			},
-			expectedCode: fiber.StatusNotFound,
+			expectedCode: fiber.StatusOK,
 		}, {
-			name: "internal error",
+			name:        "error invalid format",
+			url:         "/links/import",
+			contentType: "text/plain",
 			setup: func(mu *mockDomain.MockShortLinkUsecase) {
-				mu.EXPECT().Redirect(gomock.Any()).Return("", err)
+				mu.EXPECT().ImportShortLinks(userID, "", gomock.Any()).Return(nil, usecases.ErrInvalidFormat)
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:        "error import",
+			url:         "/links/import",
+			contentType: "text/csv",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ImportShortLinks(userID, usecases.FormatCSV, gomock.Any()).Return(nil, usecases.ErrUnexpected)
 			},
 			expectedCode: fiber.StatusInternalServerError,
 		},
@@ -215,18 +461,1077 @@ func TestShortLinkRedirect(t *testing.T) {
 
 	for _, tt := range tests {
 		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
-		handler := NewShortLinkHandler(mock)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/links/import", handler.ImportShortLinks)
+
+		req := httptest.NewRequest("POST", tt.url, strings.NewReader("destination\nhttps://example.com\n"))
+		req.Header.Set("Content-Type", tt.contentType)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+	}
+}
+
+func TestShortLinkExportShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		url          string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+		expectedType string
+		expectedBody string
+	}{
+		{
+			name: "csv",
+			url:  "/links/export",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ExportShortLinks(userID, usecases.FormatCSV, gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, format string, w io.Writer) error {
+					_, err := io.WriteString(w, "slash_code\nfoo\n")
+					return err
+				})
+			},
+			expectedCode: fiber.StatusOK,
+			expectedType: "text/csv",
+			expectedBody: "slash_code\nfoo\n",
+		}, {
+			name: "ndjson",
+			url:  "/links/export?format=ndjson",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ExportShortLinks(userID, usecases.FormatNDJSON, gomock.Any()).Return(nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedType: "application/x-ndjson",
+		}, {
+			name:         "error invalid format",
+			url:          "/links/export?format=xml",
+			expectedCode: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
 		if tt.setup != nil {
 			tt.setup(mock)
 		}
 
 		app := fiber.New()
-		app.Get("/:slash", handler.Redirect)
-		req := httptest.NewRequest("GET", "/valid-slash", nil)
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/export", handler.ExportShortLinks)
+
+		req := httptest.NewRequest("GET", tt.url, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+		if tt.expectedType != "" {
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, tt.expectedType, res.Header.Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, string(body))
+		}
+	}
+}
+
+func TestShortLinkFindShortLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+		expectedBody *models.ShortLink
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(&models.ShortLink{
+					SlashCode:   "foo",
+					Destination: "https://www.google.com",
+				}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedBody: &models.ShortLink{
+				SlashCode:   "foo",
+				Origin:      "http://example.com/foo",
+				Destination: "https://www.google.com",
+			},
+		}, {
+			name: "custom domain",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(&models.ShortLink{
+					Domain:      "go.example.com",
+					SlashCode:   "foo",
+					Destination: "https://www.google.com",
+				}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedBody: &models.ShortLink{
+				Domain:      "go.example.com",
+				SlashCode:   "foo",
+				Origin:      "http://go.example.com/foo",
+				Destination: "https://www.google.com",
+			},
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "internal error",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/:slash", handler.FindShortLink)
+		req := httptest.NewRequest("GET", "/links/foo", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedBody != nil {
+			body := &models.ShortLink{}
+			err := json.NewDecoder(res.Body).Decode(body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+		}
+	}
+}
+
+func TestShortLinkListShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+		expectedBody *domain.ShortLinkPage
+	}{
+		{
+			name:  "success",
+			query: "?sort=visitors&order=asc&limit=1",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ListShortLinks(userID, gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, req *domain.ListShortLinksRequest) (*domain.ShortLinkPage, error) {
+					assert.Equal(t, "visitors", req.Sort)
+					assert.Equal(t, "asc", req.Order)
+					assert.Equal(t, 1, req.Limit)
+					return &domain.ShortLinkPage{
+						Data:       []*models.ShortLink{{SlashCode: "foo", Destination: "https://www.google.com"}},
+						NextCursor: "next",
+					}, nil
+				})
+			},
+			expectedCode: fiber.StatusOK,
+			expectedBody: &domain.ShortLinkPage{
+				Data: []*models.ShortLink{{
+					SlashCode:   "foo",
+					Origin:      "http://example.com/foo",
+					Destination: "https://www.google.com",
+				}},
+				NextCursor: "next",
+			},
+		}, {
+			name:         "error invalid sort",
+			query:        "?sort=destination",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:         "error invalid date",
+			query:        "?created_from=yesterday",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:  "error invalid cursor",
+			query: "?cursor=invalid",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ListShortLinks(userID, gomock.Any()).Return(nil, usecases.ErrInvalidCursor)
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "internal error",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ListShortLinks(userID, gomock.Any()).Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links", handler.ListShortLinks)
+		req := httptest.NewRequest("GET", "/links"+tt.query, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedBody != nil {
+			body := &domain.ShortLinkPage{}
+			err := json.NewDecoder(res.Body).Decode(body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+		}
+	}
+}
+
+func TestShortLinkUpdateShortLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		requestBody  *domain.UpdateShortLinkRequest
+		expectedCode int
+		expectedBody *models.ShortLink
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().UpdateShortLink(userID, "", "foo", gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, host string, slashCode string, req *domain.UpdateShortLinkRequest) (*models.ShortLink, error) {
+					return &models.ShortLink{
+						SlashCode:   slashCode,
+						Destination: req.Destination,
+					}, nil
+				})
+			},
+			requestBody:  &domain.UpdateShortLinkRequest{Destination: "www.google.com"},
+			expectedCode: fiber.StatusOK,
+			expectedBody: &models.ShortLink{
+				SlashCode:   "foo",
+				Origin:      "http://example.com/foo",
+				Destination: "https://www.google.com",
+			},
+		}, {
+			name: "success without destination",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().UpdateShortLink(userID, "", "foo", &domain.UpdateShortLinkRequest{RedirectType: 302}).Return(&models.ShortLink{
+					SlashCode:    "foo",
+					Destination:  "https://www.google.com",
+					RedirectType: 302,
+				}, nil)
+			},
+			requestBody:  &domain.UpdateShortLinkRequest{RedirectType: 302},
+			expectedCode: fiber.StatusOK,
+			expectedBody: &models.ShortLink{
+				SlashCode:    "foo",
+				Origin:       "http://example.com/foo",
+				Destination:  "https://www.google.com",
+				RedirectType: 302,
+			},
+		}, {
+			name:         "error invalid request",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error empty request",
+			requestBody:  &domain.UpdateShortLinkRequest{},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:         "error invalid url",
+			requestBody:  &domain.UpdateShortLinkRequest{Destination: "invalid-url"},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().UpdateShortLink(userID, "", "foo", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			requestBody:  &domain.UpdateShortLinkRequest{Destination: "https://www.google.com"},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error update short link",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().UpdateShortLink(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrUpdateShortLink)
+			},
+			requestBody:  &domain.UpdateShortLinkRequest{Destination: "https://www.google.com"},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Patch("/links/:slash", handler.UpdateShortLink)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("PATCH", "/links/foo", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedBody != nil {
+			body := &models.ShortLink{}
+			err := json.NewDecoder(res.Body).Decode(body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody, body)
+		}
+	}
+}
+
+func TestShortLinkFindRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	rules := []*models.LinkRule{{ID: uuid.New(), OS: "ios", Destination: "https://apps.apple.com/app/foo"}}
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+		expectedBody []*models.LinkRule
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindRules(userID, "", "foo").Return(rules, nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedBody: rules,
+		}, {
+			name: "not owner",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindRules(userID, "", "foo").Return(nil, usecases.ErrNotOwner)
+			},
+			expectedCode: fiber.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/:slash/rules", handler.FindRules)
+
+		req := httptest.NewRequest("GET", "/links/foo/rules", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedBody != nil {
+			body := struct {
+				Data []*models.LinkRule `json:"data"`
+			}{}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody[0].ID, body.Data[0].ID)
+			assert.Equal(t, tt.expectedBody[0].OS, body.Data[0].OS)
+		}
+	}
+}
+
+func TestShortLinkReplaceRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		requestBody  any
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceRules(userID, "", "foo", gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, host string, slashCode string, req *domain.ReplaceLinkRulesRequest) ([]*models.LinkRule, error) {
+					assert.Equal(t, "https://apps.apple.com/app/foo", req.Rules[0].Destination)
+					return []*models.LinkRule{{OS: "ios", Destination: req.Rules[0].Destination}}, nil
+				})
+			},
+			requestBody: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{OS: "ios", Destination: "apps.apple.com/app/foo"},
+			}},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "error invalid request",
+			requestBody:  "foo",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name: "error invalid os",
+			requestBody: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{OS: "symbian", Destination: "https://example.com"},
+			}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid country",
+			requestBody: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{Countries: []string{"THA"}, Destination: "https://example.com"},
+			}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid destination",
+			requestBody: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{Destination: "invalid-url"},
+			}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid time window",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceRules(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrInvalidTimeWindow)
+			},
+			requestBody:  &domain.ReplaceLinkRulesRequest{},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error blocked destination",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceRules(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrDomainBlocked)
+			},
+			requestBody:  &domain.ReplaceLinkRulesRequest{},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error update short link",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceRules(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrUpdateShortLink)
+			},
+			requestBody:  &domain.ReplaceLinkRulesRequest{},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Put("/links/:slash/rules", handler.ReplaceRules)
+
+		var buf bytes.Buffer
+		err := json.NewEncoder(&buf).Encode(tt.requestBody)
+		if err != nil {
+			t.Errorf("failed to encode request body: %v", err)
+		}
+		req := httptest.NewRequest("PUT", "/links/foo/rules", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+	}
+}
+
+func TestShortLinkFindVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	variants := []*models.LinkVariant{{ID: uuid.New(), Destination: "https://example.com/a", Weight: 70}}
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+		expectedBody []*models.LinkVariant
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindVariants(userID, "", "foo").Return(variants, nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedBody: variants,
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().FindVariants(userID, "", "foo").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/:slash/variants", handler.FindVariants)
+
+		req := httptest.NewRequest("GET", "/links/foo/variants", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedBody != nil {
+			body := struct {
+				Data []*models.LinkVariant `json:"data"`
+			}{}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, tt.expectedBody[0].ID, body.Data[0].ID)
+			assert.Equal(t, tt.expectedBody[0].Weight, body.Data[0].Weight)
+		}
+	}
+}
+
+func TestShortLinkReplaceVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		requestBody  any
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceVariants(userID, "", "foo", gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, host string, slashCode string, req *domain.ReplaceLinkVariantsRequest) ([]*models.LinkVariant, error) {
+					assert.Equal(t, "https://example.com/a", req.Variants[0].Destination)
+					return []*models.LinkVariant{{Destination: req.Variants[0].Destination, Weight: req.Variants[0].Weight}}, nil
+				})
+			},
+			requestBody: &domain.ReplaceLinkVariantsRequest{Variants: []*domain.LinkVariantRequest{
+				{Destination: "example.com/a", Weight: 50},
+			}},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "error invalid request",
+			requestBody:  "foo",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name: "error invalid weight",
+			requestBody: &domain.ReplaceLinkVariantsRequest{Variants: []*domain.LinkVariantRequest{
+				{Destination: "https://example.com/a", Weight: 0},
+			}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error invalid destination",
+			requestBody: &domain.ReplaceLinkVariantsRequest{Variants: []*domain.LinkVariantRequest{
+				{Destination: "invalid-url", Weight: 1},
+			}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error blocked destination",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceVariants(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrDomainBlocked)
+			},
+			requestBody:  &domain.ReplaceLinkVariantsRequest{},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error update short link",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().ReplaceVariants(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrUpdateShortLink)
+			},
+			requestBody:  &domain.ReplaceLinkVariantsRequest{},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Put("/links/:slash/variants", handler.ReplaceVariants)
+
+		var buf bytes.Buffer
+		err := json.NewEncoder(&buf).Encode(tt.requestBody)
+		if err != nil {
+			t.Errorf("failed to encode request body: %v", err)
+		}
+		req := httptest.NewRequest("PUT", "/links/foo/variants", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+	}
+}
+
+func TestShortLinkDeleteShortLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().DeleteShortLink(userID, "", "foo").Return(nil)
+			},
+			expectedCode: fiber.StatusNoContent,
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().DeleteShortLink(userID, "", "foo").Return(gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "not owner",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().DeleteShortLink(userID, "", "foo").Return(usecases.ErrNotOwner)
+			},
+			expectedCode: fiber.StatusForbidden,
+		}, {
+			name: "error delete short link",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().DeleteShortLink(userID, "", "foo").Return(usecases.ErrDeleteShortLink)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Delete("/links/:slash", handler.DeleteShortLink)
+		req := httptest.NewRequest("DELETE", "/links/foo", nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestShortLinkGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(mu *mockDomain.MockShortLinkUsecase)
+		expectedCode int
+	}{
+		{
+			name:  "success",
+			query: "?interval=hour&from=2023-10-01T00:00:00Z",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().GetStats(userID, "", "foo", gomock.Any()).DoAndReturn(func(ownerID uuid.UUID, host string, slashCode string, req *domain.StatsRequest) (*domain.ShortLinkStats, error) {
+					assert.Equal(t, "hour", req.Interval)
+					assert.Equal(t, "2023-10-01T00:00:00Z", req.From)
+					return &domain.ShortLinkStats{SlashCode: slashCode, Interval: req.Interval}, nil
+				})
+			},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name:         "error invalid interval",
+			query:        "?interval=week",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:  "error invalid range",
+			query: "?from=2023-10-02T00:00:00Z&to=2023-10-01T00:00:00Z",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().GetStats(userID, "", "foo", gomock.Any()).Return(nil, usecases.ErrInvalidFilter)
+			},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().GetStats(userID, "", "foo", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/:slash/stats", handler.GetStats)
+		req := httptest.NewRequest("GET", "/links/foo/stats"+tt.query, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+	}
+}
+
+func TestShortLinkGetQRCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		query        string
+		setup        func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase)
+		expectedCode int
+		expectedType string
+	}{
+		{
+			name:  "success",
+			query: "?format=svg&size=512&level=H&margin=2&fg=112233&bg=ffffff",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(&models.ShortLink{SlashCode: "foo"}, nil)
+				mq.EXPECT().Render("http://example.com/foo", gomock.Any()).DoAndReturn(func(content string, req *domain.QRCodeRequest) (*domain.QRCode, error) {
+					assert.Equal(t, "svg", req.Format)
+					assert.Equal(t, 512, req.Size)
+					assert.Equal(t, "H", req.Level)
+					assert.Equal(t, 2, *req.Margin)
+					assert.Equal(t, "112233", req.Foreground)
+					return &domain.QRCode{ContentType: "image/svg+xml", Image: []byte("<svg/>")}, nil
+				})
+			},
+			expectedCode: fiber.StatusOK,
+			expectedType: "image/svg+xml",
+		}, {
+			name:  "custom domain",
+			query: "?domain=go.example.com",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "go.example.com", "foo").Return(&models.ShortLink{Domain: "go.example.com", SlashCode: "foo"}, nil)
+				mq.EXPECT().Render("http://go.example.com/foo", gomock.Any()).Return(&domain.QRCode{ContentType: "image/png", Image: []byte("png")}, nil)
+			},
+			expectedCode: fiber.StatusOK,
+			expectedType: "image/png",
+		}, {
+			name:         "error invalid size",
+			query:        "?size=10",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name:         "error invalid color",
+			query:        "?fg=red",
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error render",
+			setup: func(mu *mockDomain.MockShortLinkUsecase, mq *mockDomain.MockQRCodeUsecase) {
+				mu.EXPECT().FindBySlashCode(userID, "", "foo").Return(&models.ShortLink{SlashCode: "foo"}, nil)
+				mq.EXPECT().Render(gomock.Any(), gomock.Any()).Return(nil, usecases.ErrRenderQRCode)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		mockQR := mockDomain.NewMockQRCodeUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, mockQR)
+		if tt.setup != nil {
+			tt.setup(mock, mockQR)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Get("/links/:slash/qr", handler.GetQRCode)
+		req := httptest.NewRequest("GET", "/links/foo/qr"+tt.query, nil)
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expectedType != "" {
+			assert.Equal(t, tt.expectedType, res.Header.Get("Content-Type"))
+		}
+	}
+}
+
+func TestShortLinkRedirect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	destination := "http://www.example.com"
+	err := errors.New("internal error")
+
+	tests := []struct {
+		name          string
+		setup         func(mu *mockDomain.MockShortLinkUsecase)
+		expected      string
+		expectedCode  int
+		expectedCache string
+	}{
+		{
+			name: "redirect",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect("example.com", "valid-slash", gomock.Any()).DoAndReturn(func(host string, slashCode string, visit *domain.Visit) (*domain.Redirection, error) {
+					assert.Equal(t, "https://example.org", visit.Referrer)
+					assert.Equal(t, "Mozilla/5.0", visit.UserAgent)
+					assert.Equal(t, "th-TH", visit.AcceptLanguage)
+					assert.Equal(t, "ref=ad", visit.Query)
+					assert.NotEmpty(t, visit.IP)
+					return &domain.Redirection{Destination: destination, StatusCode: fiber.StatusMovedPermanently}, nil
+				})
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusMovedPermanently,
+			expectedCache: "max-age=180",
+		}, {
+			name: "permanent redirect",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.Redirection{Destination: destination, StatusCode: fiber.StatusPermanentRedirect}, nil)
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusPermanentRedirect,
+			expectedCache: "max-age=180",
+		}, {
+			name: "permanent redirect depending on the visitor",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.Redirection{Destination: destination, StatusCode: fiber.StatusMovedPermanently, Private: true}, nil)
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusMovedPermanently,
+			expectedCache: "private, no-store",
+		}, {
+			name: "temporary redirect",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.Redirection{Destination: destination, StatusCode: fiber.StatusFound}, nil)
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusFound,
+			expectedCache: "no-store",
+		}, {
+			name: "temporary redirect keeping method",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(&domain.Redirection{Destination: destination, StatusCode: fiber.StatusTemporaryRedirect}, nil)
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusTemporaryRedirect,
+			expectedCache: "no-store",
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "expired",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, usecases.ErrShortLinkExpired)
+			},
+			expectedCode: fiber.StatusGone,
+		}, {
+			name: "password required",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, usecases.ErrPasswordRequired)
+			},
+			expectedCode: fiber.StatusOK,
+		}, {
+			name: "blocked",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, usecases.ErrShortLinkBlocked)
+			},
+			expectedCode: fiber.StatusForbidden,
+		}, {
+			name: "internal error",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Redirect(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, err)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Get("/:slash", handler.Redirect)
+		req := httptest.NewRequest("GET", "/valid-slash?ref=ad", nil)
+		req.Header.Set("Referer", "https://example.org")
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.Header.Set("Accept-Language", "th-TH")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		if tt.expected != "" {
+			assert.Equal(t, tt.expected, res.Header.Get("Location"))
+			assert.Equal(t, tt.expectedCache, res.Header.Get("Cache-Control"))
+		}
+	}
+}
+
+func TestShortLinkRedirectPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+	handler := NewShortLinkHandler(mock, nil)
+	mock.EXPECT().Redirect("example.com", "valid-slash", gomock.Any()).Return(&domain.Redirection{
+		Destination: "https://www.example.com/?a=1&b=\"2\"",
+		StatusCode:  fiber.StatusMovedPermanently,
+		Preview: &models.OpenGraph{
+			Title:       "Spring <sale>",
+			Description: "Everything half off",
+			Image:       "https://cdn.example.com/sale.png",
+		},
+	}, nil)
+
+	app := fiber.New()
+	app.Get("/:slash", handler.Redirect)
+	req := httptest.NewRequest("GET", "/valid-slash", nil)
+	req.Header.Set("User-Agent", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)")
+	res, _ := app.Test(req)
+	defer res.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	assert.Empty(t, res.Header.Get("Location"))
+	assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+	assert.Contains(t, res.Header.Get("Content-Type"), "text/html")
+
+	body, _ := io.ReadAll(res.Body)
+	page := string(body)
+	assert.Contains(t, page, `<meta property="og:title" content="Spring &lt;sale&gt;">`)
+	assert.Contains(t, page, `<meta property="og:description" content="Everything half off">`)
+	assert.Contains(t, page, `<meta property="og:image" content="https://cdn.example.com/sale.png">`)
+	assert.Contains(t, page, `<meta name="twitter:card" content="summary_large_image">`)
+	assert.Contains(t, page, `<meta http-equiv="refresh" content="0; url=https://www.example.com/?a=1&amp;b=&#34;2&#34;">`)
+	assert.NotContains(t, page, `b="2"`)
+}
+
+func TestShortLinkUnlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	destination := "http://www.example.com"
+
+	tests := []struct {
+		name          string
+		setup         func(mu *mockDomain.MockShortLinkUsecase)
+		expected      string
+		expectedCode  int
+		expectedCache string
+	}{
+		{
+			name: "unlock",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Unlock("example.com", "valid-slash", "secret", gomock.Any()).Return(&domain.Redirection{
+					Destination: destination,
+					StatusCode:  fiber.StatusPermanentRedirect,
+				}, nil)
+			},
+			expected:      destination,
+			expectedCode:  fiber.StatusSeeOther,
+			expectedCache: "no-store",
+		}, {
+			name: "invalid password",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Unlock("example.com", "valid-slash", "secret", gomock.Any()).Return(nil, usecases.ErrInvalidPassword)
+			},
+			expectedCode:  fiber.StatusUnauthorized,
+			expectedCache: "no-store",
+		}, {
+			name: "too many attempts",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Unlock("example.com", "valid-slash", "secret", gomock.Any()).Return(nil, usecases.ErrTooManyAttempts)
+			},
+			expectedCode:  fiber.StatusTooManyRequests,
+			expectedCache: "no-store",
+		}, {
+			name: "not found",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Unlock("example.com", "valid-slash", "secret", gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "internal error",
+			setup: func(mu *mockDomain.MockShortLinkUsecase) {
+				mu.EXPECT().Unlock("example.com", "valid-slash", "secret", gomock.Any()).Return(nil, usecases.ErrUnexpected)
+			},
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockShortLinkUsecase(ctrl)
+		handler := NewShortLinkHandler(mock, nil)
+		tt.setup(mock)
+
+		app := fiber.New()
+		app.Post("/:slash", handler.Unlock)
+		req := httptest.NewRequest("POST", "/valid-slash", strings.NewReader("password=secret"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		res, _ := app.Test(req)
 		defer res.Body.Close()
 
 		assert.Equal(t, tt.expectedCode, res.StatusCode)
+		assert.Equal(t, tt.expectedCache, res.Header.Get("Cache-Control"))
 		if tt.expected != "" {
 			assert.Equal(t, tt.expected, res.Header.Get("Location"))
 		}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"url-shortener/domain"
+	"url-shortener/middleware"
+	"url-shortener/usecases"
+	"url-shortener/utils/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type webhookHandler struct {
+	webhookUcase domain.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUcase domain.WebhookUsecase) *webhookHandler {
+	return &webhookHandler{webhookUcase}
+}
+
+func (h *webhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	req := &domain.CreateWebhookRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"message": "unprocessable entity",
+		})
+	}
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": errs[0].Message,
+		})
+	}
+
+	webhook, err := h.webhookUcase.CreateWebhook(middleware.CurrentUserID(c), req)
+	if err != nil {
+		switch err {
+		case usecases.ErrInvalidDestination, usecases.ErrSchemeNotAllowed, usecases.ErrPrivateDestination,
+			usecases.ErrDomainBlocked, usecases.ErrDomainNotAllowed:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case usecases.ErrTooManyWebhooks:
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(webhook)
+}
+
+func (h *webhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	webhooks, err := h.webhookUcase.ListWebhooks(middleware.CurrentUserID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(webhooks)
+}
+
+func (h *webhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return webhookNotFound(c)
+	}
+
+	if err := h.webhookUcase.DeleteWebhook(middleware.CurrentUserID(c), id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return webhookNotFound(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *webhookHandler) ListDeadLetters(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return webhookNotFound(c)
+	}
+
+	deadLetters, err := h.webhookUcase.ListDeadLetters(middleware.CurrentUserID(c), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return webhookNotFound(c)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(deadLetters)
+}
+
+func webhookNotFound(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+		"message": "webhook not found",
+	})
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewWebhookHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockWebhookUsecase(ctrl)
+	handler := NewWebhookHandler(mock)
+
+	assert.NotNil(t, handler.webhookUcase)
+}
+
+func TestWebhookCreateWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	request := &domain.CreateWebhookRequest{URL: "https://crm.example.com/hooks", Events: []string{"link.created"}}
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockWebhookUsecase)
+		requestBody  *domain.CreateWebhookRequest
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().CreateWebhook(userID, gomock.Any()).Return(&domain.CreateWebhookResponse{
+					Webhook: &models.Webhook{URL: request.URL, Secret: "whsec_foo"},
+					Secret:  "whsec_foo",
+				}, nil)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusCreated,
+		}, {
+			name:         "error invalid request",
+			expectedCode: fiber.StatusUnprocessableEntity,
+		}, {
+			name:         "error unknown event",
+			requestBody:  &domain.CreateWebhookRequest{URL: request.URL, Events: []string{"link.visited"}},
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error private url",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().CreateWebhook(userID, gomock.Any()).Return(nil, usecases.ErrPrivateDestination)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusBadRequest,
+		}, {
+			name: "error too many webhooks",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().CreateWebhook(userID, gomock.Any()).Return(nil, usecases.ErrTooManyWebhooks)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusConflict,
+		}, {
+			name: "error create webhook",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().CreateWebhook(userID, gomock.Any()).Return(nil, usecases.ErrCreateWebhook)
+			},
+			requestBody:  request,
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockWebhookUsecase(ctrl)
+		handler := NewWebhookHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Post("/webhooks", handler.CreateWebhook)
+
+		var buf bytes.Buffer
+		if tt.requestBody != nil {
+			err := json.NewEncoder(&buf).Encode(tt.requestBody)
+			if err != nil {
+				t.Errorf("failed to encode request body: %v", err)
+			}
+		}
+		req := httptest.NewRequest("POST", "/webhooks", &buf)
+		req.Header.Set("Content-Type", "application/json")
+		res, _ := app.Test(req)
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+		if tt.expectedCode == fiber.StatusCreated {
+			body := map[string]interface{}{}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			if err != nil {
+				t.Errorf("failed to decode response body: %v", err)
+			}
+			assert.Equal(t, "whsec_foo", body["secret"])
+			assert.Equal(t, request.URL, body["url"])
+		}
+	}
+}
+
+func TestWebhookListWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	mock := mockDomain.NewMockWebhookUsecase(ctrl)
+	handler := NewWebhookHandler(mock)
+	mock.EXPECT().ListWebhooks(userID).Return([]*models.Webhook{{URL: "https://crm.example.com/hooks", Secret: "whsec_foo"}}, nil)
+
+	app := fiber.New()
+	app.Use(SetupAuthenticatedUser(userID))
+	app.Get("/webhooks", handler.ListWebhooks)
+
+	res, _ := app.Test(httptest.NewRequest("GET", "/webhooks", nil))
+	defer res.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+	body := []map[string]interface{}{}
+	err := json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		t.Errorf("failed to decode response body: %v", err)
+	}
+	assert.Equal(t, "https://crm.example.com/hooks", body[0]["url"])
+	assert.NotContains(t, body[0], "secret")
+}
+
+func TestWebhookDeleteWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	id := uuid.New()
+	tests := []struct {
+		name         string
+		setup        func(mu *mockDomain.MockWebhookUsecase)
+		path         string
+		expectedCode int
+	}{
+		{
+			name: "success",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().DeleteWebhook(userID, id).Return(nil)
+			},
+			path:         "/webhooks/" + id.String(),
+			expectedCode: fiber.StatusNoContent,
+		}, {
+			name:         "error invalid id",
+			path:         "/webhooks/foo",
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error not found",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().DeleteWebhook(userID, id).Return(gorm.ErrRecordNotFound)
+			},
+			path:         "/webhooks/" + id.String(),
+			expectedCode: fiber.StatusNotFound,
+		}, {
+			name: "error delete webhook",
+			setup: func(mu *mockDomain.MockWebhookUsecase) {
+				mu.EXPECT().DeleteWebhook(userID, id).Return(usecases.ErrDeleteWebhook)
+			},
+			path:         "/webhooks/" + id.String(),
+			expectedCode: fiber.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		mock := mockDomain.NewMockWebhookUsecase(ctrl)
+		handler := NewWebhookHandler(mock)
+		if tt.setup != nil {
+			tt.setup(mock)
+		}
+
+		app := fiber.New()
+		app.Use(SetupAuthenticatedUser(userID))
+		app.Delete("/webhooks/:id", handler.DeleteWebhook)
+
+		res, _ := app.Test(httptest.NewRequest("DELETE", tt.path, nil))
+		defer res.Body.Close()
+
+		assert.Equal(t, tt.expectedCode, res.StatusCode, tt.name)
+	}
+}
+
+func TestWebhookListDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	id := uuid.New()
+	mock := mockDomain.NewMockWebhookUsecase(ctrl)
+	handler := NewWebhookHandler(mock)
+
+	app := fiber.New()
+	app.Use(SetupAuthenticatedUser(userID))
+	app.Get("/webhooks/:id/dead-letters", handler.ListDeadLetters)
+
+	mock.EXPECT().ListDeadLetters(userID, id).Return([]*models.WebhookDeadLetter{{WebhookID: id, LastError: "unexpected status 500"}}, nil)
+	res, _ := app.Test(httptest.NewRequest("GET", "/webhooks/"+id.String()+"/dead-letters", nil))
+	defer res.Body.Close()
+	assert.Equal(t, fiber.StatusOK, res.StatusCode)
+
+	mock.EXPECT().ListDeadLetters(userID, id).Return(nil, gorm.ErrRecordNotFound)
+	res, _ = app.Test(httptest.NewRequest("GET", "/webhooks/"+id.String()+"/dead-letters", nil))
+	defer res.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, res.StatusCode)
+}
@@ -1,6 +1,8 @@
 package helpers
 
 import (
+	crand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"time"
 )
@@ -16,3 +18,16 @@ func StrRandom(length uint) string {
 	}
 	return string(b)
 }
+
+func StrSecureRandom(length uint) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(charset)))
+	for i := range b {
+		n, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b), nil
+}
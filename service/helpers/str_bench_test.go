@@ -7,3 +7,9 @@ func BenchmarkStrRandom(b *testing.B) {
 		StrRandom(16)
 	}
 }
+
+func BenchmarkStrSecureRandom(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		StrSecureRandom(16)
+	}
+}
@@ -27,3 +27,27 @@ func TestStrRandom(t *testing.T) {
 		})
 	}
 }
+
+func TestStrSecureRandom(t *testing.T) {
+	tests := []struct {
+		name   string
+		length uint
+	}{
+		{
+			name:   "random 64 characters",
+			length: 64,
+		},
+		{
+			name:   "random 0 characters",
+			length: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := StrSecureRandom(tt.length)
+			assert.NoError(t, err)
+			assert.Equal(t, len(str), int(tt.length))
+		})
+	}
+}
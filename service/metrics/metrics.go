@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	RequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to answer HTTP requests by route.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "route", "status"})
+
+	RedirectCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_redirect_cache_total",
+		Help: "Short link cache lookups on redirect by result (hit, miss or error).",
+	}, []string{"result"})
+
+	RedirectFiltered = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shortener_redirect_filtered_total",
+		Help: "Redirects for slash codes the filter knows don't exist.",
+	})
+
+	RedirectErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shortener_redirect_errors_total",
+		Help: "Redirects that failed because the database couldn't be read.",
+	})
+
+	PendingVisitors = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shortener_pending_visitors",
+		Help: "Visits counted in memory that haven't been handed to Redis or MySQL yet.",
+	})
+
+	SlashCodeCollisions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shortener_slash_code_collisions_total",
+		Help: "Generated slash codes that were taken already.",
+	})
+
+	RedisBreakerOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shortener_redis_breaker_open",
+		Help: "1 while Redis calls are skipped because Redis is failing.",
+	})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_rate_limited_total",
+		Help: "Requests rejected by the rate limiter by route.",
+	}, []string{"route"})
+
+	ClientsBlocked = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "shortener_clients_blocked_total",
+		Help: "Clients blocked for requesting too many slash codes that don't exist.",
+	})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shortener_webhook_deliveries_total",
+		Help: "Webhook delivery attempts by result (delivered, retried or dead).",
+	}, []string{"result"})
+)
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"url-shortener/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdmin must run after Authenticate.
+func RequireAdmin(userUcase domain.UserUsecase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := userUcase.FindByID(CurrentUserID(c))
+		if err != nil || !user.IsAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"message": "admin access is required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"strings"
+	"url-shortener/domain"
+	"url-shortener/models"
+	"url-shortener/usecases"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const APIKeyLocal = "api_key"
+
+func Authenticate(apiKeyUcase domain.APIKeyUsecase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		}
+
+		if key == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": "api key is required",
+			})
+		}
+
+		apiKey, err := apiKeyUcase.Authenticate(key)
+		if err != nil {
+			if err == usecases.ErrInvalidAPIKey {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"message": err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+
+		c.Locals(APIKeyLocal, apiKey)
+		return c.Next()
+	}
+}
+
+func CurrentAPIKey(c *fiber.Ctx) *models.APIKey {
+	apiKey, _ := c.Locals(APIKeyLocal).(*models.APIKey)
+	return apiKey
+}
+
+func CurrentUserID(c *fiber.Ctx) uuid.UUID {
+	if apiKey := CurrentAPIKey(c); apiKey != nil {
+		return apiKey.UserID
+	}
+	return uuid.Nil
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"url-shortener/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records request latency under the route pattern rather than the
+// path, so every slash code shares one series.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+		}
+
+		metrics.RequestDuration.
+			WithLabelValues(c.Method(), c.Route().Path, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/logs"
+	"url-shortener/metrics"
+	"url-shortener/utils/breaker"
+	"url-shortener/utils/lru"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	notFoundBlock    = 15 * time.Minute
+	maxNotFoundBlock = 24 * time.Hour
+	blockedClients   = 10000
+)
+
+// NotFoundBudget lets each IP run into a limited number of 404s per window.
+// Clients that spend it are blocked, for twice as long on every repeat, so
+// slash codes can't be enumerated by scanning. Blocks are kept in memory and
+// Redis is only asked after a 404, so redirects that are found don't reach
+// it. If Redis can't be reached the request is let through.
+func NotFoundBudget(rateLimitRepo domain.RateLimitRepository, budget int, window time.Duration) fiber.Handler {
+	blocks := lru.New[string, time.Time](blockedClients)
+
+	return func(c *fiber.Ctx) error {
+		client := "ip:" + c.IP()
+
+		if until, ok := blocks.Get(client); ok {
+			return tooManyNotFound(c, time.Until(until))
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != fiber.StatusNotFound {
+			return nil
+		}
+
+		rateLimit, err := rateLimitRepo.Allow("not_found:"+client, budget, window)
+		if err != nil {
+			logRateLimitError(err)
+			return nil
+		}
+		if rateLimit.Allowed {
+			return nil
+		}
+
+		// Another replica may have blocked the client already.
+		blocked, err := rateLimitRepo.FindBlock(client)
+		if err != nil {
+			logRateLimitError(err)
+			return nil
+		}
+		if blocked <= 0 {
+			blocked, err = rateLimitRepo.Block(client, notFoundBlock, maxNotFoundBlock)
+			if err != nil {
+				logRateLimitError(err)
+				return nil
+			}
+			metrics.ClientsBlocked.Inc()
+		}
+		blocks.Set(client, time.Now().Add(blocked), blocked)
+
+		return tooManyNotFound(c, blocked)
+	}
+}
+
+func tooManyNotFound(c *fiber.Ctx, retryAfter time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"message": "too many requests for links that don't exist",
+	})
+}
+
+func logRateLimitError(err error) {
+	if !errors.Is(err, breaker.ErrOpen) {
+		logs.Error(err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotFoundBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockRateLimitRepository(ctrl)
+	app := fiber.New()
+	app.Get("/:slash", NotFoundBudget(mock, 1, time.Minute), func(c *fiber.Ctx) error {
+		if c.Params("slash") == "found" {
+			return c.Redirect("https://example.com")
+		}
+		return c.SendStatus(fiber.StatusNotFound)
+	})
+	request := func(path string) int {
+		res, _ := app.Test(httptest.NewRequest("GET", path, nil))
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+
+	// Links that are found don't reach Redis.
+	assert.Equal(t, fiber.StatusFound, request("/found"))
+
+	mock.EXPECT().Allow(gomock.Any(), 1, time.Minute).Return(&domain.RateLimit{Allowed: true}, nil)
+	assert.Equal(t, fiber.StatusNotFound, request("/foo"))
+
+	mock.EXPECT().Allow(gomock.Any(), 1, time.Minute).Return(&domain.RateLimit{}, nil)
+	mock.EXPECT().FindBlock(gomock.Any()).Return(time.Duration(0), nil)
+	mock.EXPECT().Block(gomock.Any(), notFoundBlock, maxNotFoundBlock).Return(notFoundBlock, nil)
+	assert.Equal(t, fiber.StatusTooManyRequests, request("/bar"))
+
+	// The block is then applied without asking Redis.
+	assert.Equal(t, fiber.StatusTooManyRequests, request("/found"))
+}
@@ -1,27 +1,48 @@
 package middleware
 
 import (
+	"math"
+	"strconv"
 	"time"
+	"url-shortener/domain"
+	"url-shortener/metrics"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
-func Limiter(requests int, reset time.Duration) fiber.Handler {
-	limiter := limiter.New(limiter.Config{
-		Max:        requests,
-		Expiration: reset,
-		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.IP()
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"message": "rate limit exceeded",
-			})
-		},
-	})
+// Limiter returns a constructor for per-route limits shared by every replica
+// through Redis. Authenticated requests are counted per API key and scaled by
+// its rate limit multiplier, the rest per IP. If Redis can't be reached the
+// request is let through.
+func Limiter(rateLimitRepo domain.RateLimitRepository) func(requests int, reset time.Duration) fiber.Handler {
+	return func(requests int, reset time.Duration) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			limit, client := requests, "ip:"+c.IP()
+			if apiKey := CurrentAPIKey(c); apiKey != nil {
+				client = "key:" + apiKey.ID.String()
+				if apiKey.RateLimitMultiplier > 0 {
+					limit = max(int(math.Round(float64(requests)*apiKey.RateLimitMultiplier)), 1)
+				}
+			}
 
-	return func(c *fiber.Ctx) error {
-		return limiter(c)
+			rateLimit, err := rateLimitRepo.Allow(c.Method()+":"+c.Route().Path+":"+client, limit, reset)
+			if err != nil {
+				logRateLimitError(err)
+				return c.Next()
+			}
+
+			c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			c.Set("X-RateLimit-Remaining", strconv.Itoa(rateLimit.Remaining))
+
+			if !rateLimit.Allowed {
+				metrics.RateLimited.WithLabelValues(c.Route().Path).Inc()
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(rateLimit.RetryAfter.Seconds()))))
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+					"message": "rate limit exceeded",
+				})
+			}
+
+			return c.Next()
+		}
 	}
 }
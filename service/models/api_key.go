@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKey struct {
+	ID                  uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	UserID              uuid.UUID  `gorm:"type:char(36);not null;index" json:"user_id"`
+	Name                string     `gorm:"not null;type:varchar(64)" json:"name"`
+	Prefix              string     `gorm:"not null;type:varchar(12)" json:"prefix"`
+	KeyHash             string     `gorm:"not null;type:char(64);uniqueIndex" json:"-"`
+	RateLimitMultiplier float64    `gorm:"not null;type:decimal(6,2);default:1" json:"rate_limit_multiplier"`
+	RevokedAt           *time.Time `json:"revoked_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Click struct {
+	ID             uint64     `gorm:"primaryKey;autoIncrement" json:"-"`
+	ShortLinkID    uuid.UUID  `gorm:"type:char(36);not null;index:idx_clicks_short_link_id_clicked_at,priority:1" json:"short_link_id"`
+	VariantID      *uuid.UUID `gorm:"type:char(36)" json:"variant_id"`
+	ClickedAt      time.Time  `gorm:"not null;index:idx_clicks_short_link_id_clicked_at,priority:2" json:"clicked_at"`
+	Referrer       string     `gorm:"not null;type:varchar(512)" json:"referrer"`
+	UserAgent      string     `gorm:"not null;type:varchar(512)" json:"user_agent"`
+	IPPrefix       string     `gorm:"not null;type:varchar(64)" json:"ip_prefix"`
+	AcceptLanguage string     `gorm:"not null;type:varchar(255)" json:"accept_language"`
+}
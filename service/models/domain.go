@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Domain struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	Name      string    `gorm:"not null;type:varchar(255);uniqueIndex" json:"name"`
+	CreatedBy uuid.UUID `gorm:"type:char(36)" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
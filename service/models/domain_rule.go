@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DomainRuleBlock = "block"
+	DomainRuleAllow = "allow"
+)
+
+type DomainRule struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	Domain    string    `gorm:"not null;type:varchar(255);uniqueIndex" json:"domain"`
+	Type      string    `gorm:"not null;type:varchar(8)" json:"type"`
+	Reason    string    `gorm:"not null;type:varchar(255)" json:"reason"`
+	CreatedBy uuid.UUID `gorm:"type:char(36)" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkRule sends the visitors it matches to its own destination. Empty
+// conditions match everyone.
+type LinkRule struct {
+	ID          uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	ShortLinkID uuid.UUID  `gorm:"type:char(36);not null;index:idx_link_rules_short_link_id_position,priority:1" json:"-"`
+	Position    int        `gorm:"not null;index:idx_link_rules_short_link_id_position,priority:2" json:"position"`
+	OS          string     `gorm:"not null;type:varchar(16)" json:"os,omitempty"`
+	Device      string     `gorm:"not null;type:varchar(16)" json:"device,omitempty"`
+	Countries   []string   `gorm:"serializer:json;type:varchar(512)" json:"countries,omitempty"`
+	Languages   []string   `gorm:"serializer:json;type:varchar(512)" json:"languages,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Destination string     `gorm:"not null;type:varchar(512)" json:"destination"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkVariant is one of the destinations of a link split between several,
+// chosen for a share of visitors proportional to its weight.
+type LinkVariant struct {
+	ID          uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	ShortLinkID uuid.UUID `gorm:"type:char(36);not null;index:idx_link_variants_short_link_id_position,priority:1" json:"-"`
+	Position    int       `gorm:"not null;index:idx_link_variants_short_link_id_position,priority:2" json:"position"`
+	Destination string    `gorm:"not null;type:varchar(512)" json:"destination"`
+	Weight      int       `gorm:"not null" json:"weight"`
+	CreatedAt   time.Time `json:"created_at"`
+}
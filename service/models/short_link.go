@@ -7,11 +7,40 @@ import (
 )
 
 type ShortLink struct {
-	ID          uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
-	SlashCode   string    `gorm:"not null;type:varchar(12);uniqueIndex;" json:"slash_code"`
-	Origin      string    `gorm:"-:all" json:"origin"`
-	Destination string    `gorm:"not null;type:varchar(512)" json:"destination"`
-	Visitors    int       `json:"visitors"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	OwnerID         *uuid.UUID `gorm:"type:char(36);index" json:"owner_id"`
+	Domain          string     `gorm:"not null;type:varchar(255);uniqueIndex:idx_short_links_domain_slash_code,priority:1" json:"domain"`
+	SlashCode       string     `gorm:"not null;type:varchar(12);uniqueIndex:idx_short_links_domain_slash_code,priority:2" json:"slash_code"`
+	Origin          string     `gorm:"-:all" json:"origin"`
+	Destination     string     `gorm:"not null;type:varchar(512)" json:"destination"`
+	DestinationHost string     `gorm:"not null;type:varchar(255);index" json:"-"`
+	RedirectType    int        `gorm:"not null;default:301" json:"redirect_type"`
+	PasswordHash    string     `gorm:"not null;type:varchar(60)" json:"-"`
+	UTM             UTM        `gorm:"embedded;embeddedPrefix:utm_" json:"utm"`
+	Passthrough     string     `gorm:"not null;type:varchar(8);default:off" json:"query_passthrough"`
+	OG              OpenGraph  `gorm:"embedded;embeddedPrefix:og_" json:"og"`
+	Protected       bool       `gorm:"-:all" json:"protected"`
+	Visitors        int        `json:"visitors"`
+	MaxVisits       *int       `json:"max_visits"`
+	ExpiresAt       *time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	QRCode          string     `gorm:"-:all" json:"qr_code,omitempty"`
+}
+
+// UTM holds the campaign parameters added to the destination on redirect.
+type UTM struct {
+	Source   string `gorm:"not null;type:varchar(255)" json:"source,omitempty"`
+	Medium   string `gorm:"not null;type:varchar(255)" json:"medium,omitempty"`
+	Campaign string `gorm:"not null;type:varchar(255)" json:"campaign,omitempty"`
+	Term     string `gorm:"not null;type:varchar(255)" json:"term,omitempty"`
+	Content  string `gorm:"not null;type:varchar(255)" json:"content,omitempty"`
+}
+
+// OpenGraph is what chat apps and social networks show when unfurling the
+// link, in place of the destination's own tags.
+type OpenGraph struct {
+	Title       string `gorm:"not null;type:varchar(255)" json:"title,omitempty"`
+	Description string `gorm:"not null;type:varchar(512)" json:"description,omitempty"`
+	Image       string `gorm:"not null;type:varchar(512)" json:"image,omitempty"`
 }
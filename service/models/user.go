@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	Email     string    `gorm:"not null;type:varchar(255);uniqueIndex" json:"email"`
+	IsAdmin   bool      `gorm:"not null;default:false" json:"is_admin"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
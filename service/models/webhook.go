@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook subscribes a URL to events on its owner's links. Payloads are
+// signed with the secret, which is only shown when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	OwnerID   uuid.UUID `gorm:"type:char(36);not null;index" json:"owner_id"`
+	URL       string    `gorm:"not null;type:varchar(512)" json:"url"`
+	Secret    string    `gorm:"not null;type:varchar(64)" json:"-"`
+	Events    []string  `gorm:"serializer:json;type:varchar(512)" json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an event waiting to be sent, or to be retried once
+// NextAttemptAt has passed.
+type WebhookDelivery struct {
+	ID            uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	WebhookID     uuid.UUID `gorm:"type:char(36);not null;index" json:"webhook_id"`
+	Webhook       *Webhook  `gorm:"-:all" json:"-"`
+	Event         string    `gorm:"not null;type:varchar(32)" json:"event"`
+	Payload       string    `gorm:"not null;type:text" json:"payload"`
+	Attempts      int       `gorm:"not null" json:"attempts"`
+	LastError     string    `gorm:"not null;type:varchar(512)" json:"last_error"`
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// WebhookDeadLetter is a delivery that failed every attempt.
+type WebhookDeadLetter struct {
+	ID        uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
+	WebhookID uuid.UUID `gorm:"type:char(36);not null;index:idx_webhook_dead_letters_webhook_id_failed_at,priority:1" json:"webhook_id"`
+	Event     string    `gorm:"not null;type:varchar(32)" json:"event"`
+	Payload   string    `gorm:"not null;type:text" json:"payload"`
+	Attempts  int       `gorm:"not null" json:"attempts"`
+	LastError string    `gorm:"not null;type:varchar(512)" json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+	FailedAt  time.Time `gorm:"index:idx_webhook_dead_letters_webhook_id_failed_at,priority:2" json:"failed_at"`
+}
+
+// WebhookEventKey records an event that must only be sent once, such as a
+// link reaching a milestone.
+type WebhookEventKey struct {
+	Key       string `gorm:"type:varchar(128);primaryKey"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+func (r *apiKeyRepository) Create(apiKey *models.APIKey) error {
+	return r.db.Create(apiKey).Error
+}
+
+func (r *apiKeyRepository) FindByID(id uuid.UUID) (*models.APIKey, error) {
+	apiKey := &models.APIKey{}
+	if err := r.db.Where("id = ?", id).First(apiKey).Error; err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	apiKey := &models.APIKey{}
+	if err := r.db.Where("key_hash = ?", hash).First(apiKey).Error; err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(userID uuid.UUID) ([]*models.APIKey, error) {
+	apiKeys := []*models.APIKey{}
+	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) UpdateRateLimit(apiKey *models.APIKey) error {
+	return r.db.Model(apiKey).UpdateColumn("rate_limit_multiplier", apiKey.RateLimitMultiplier).Error
+}
+
+func (r *apiKeyRepository) Revoke(apiKey *models.APIKey) error {
+	return r.db.Model(apiKey).UpdateColumn("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewAPIKeyRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewAPIKeyRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestAPIKeyCreate(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	apiKey := &models.APIKey{
+		ID:      uuid.New(),
+		UserID:  uuid.New(),
+		Name:    "default",
+		Prefix:  "sk_abcde",
+		KeyHash: "hash",
+
+		RateLimitMultiplier: 1,
+	}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `api_keys`").
+					WithArgs(apiKey.ID, apiKey.UserID, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, apiKey.RateLimitMultiplier, nil, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `api_keys`").WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &apiKeyRepository{db: db}
+			err := repo.Create(apiKey)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFind(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	apiKey := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		Name:      "default",
+		Prefix:    "sk_abcde",
+		KeyHash:   "hash",
+		CreatedAt: time.Now(),
+	}
+	columns := []string{"id", "user_id", "name", "prefix", "key_hash", "created_at"}
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(apiKey.ID, apiKey.UserID, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, apiKey.CreatedAt)
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		find        func(repo *apiKeyRepository) (*models.APIKey, error)
+		expectedErr error
+	}{
+		{
+			name: "find by id",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE id = ?").WithArgs(apiKey.ID).WillReturnRows(row())
+			},
+			find: func(repo *apiKeyRepository) (*models.APIKey, error) {
+				return repo.FindByID(apiKey.ID)
+			},
+		}, {
+			name: "find by id not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE id = ?").WithArgs(apiKey.ID).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *apiKeyRepository) (*models.APIKey, error) {
+				return repo.FindByID(apiKey.ID)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "find by hash",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE key_hash = ?").WithArgs(apiKey.KeyHash).WillReturnRows(row())
+			},
+			find: func(repo *apiKeyRepository) (*models.APIKey, error) {
+				return repo.FindByHash(apiKey.KeyHash)
+			},
+		}, {
+			name: "find by hash not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `api_keys` WHERE key_hash = ?").WithArgs(apiKey.KeyHash).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *apiKeyRepository) (*models.APIKey, error) {
+				return repo.FindByHash(apiKey.KeyHash)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &apiKeyRepository{db: db}
+			res, err := tt.find(repo)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, apiKey, res)
+			}
+		})
+	}
+}
+
+func TestAPIKeyFindByUserID(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	userID := uuid.New()
+	query := regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE user_id = ? ORDER BY created_at DESC")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+						AddRow(uuid.New(), userID).
+						AddRow(uuid.New(), userID))
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs(userID).WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &apiKeyRepository{db: db}
+			res, err := repo.FindByUserID(userID)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, res, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRevoke(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("UPDATE `api_keys` SET `revoked_at`=? WHERE `id` = ?")
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock, apiKey *models.APIKey)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock, apiKey *models.APIKey) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), apiKey.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock, apiKey *models.APIKey) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), apiKey.ID).WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKey := &models.APIKey{ID: uuid.New()}
+			tt.setup(mock, apiKey)
+			repo := &apiKeyRepository{db: db}
+			err := repo.Revoke(apiKey)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, apiKey.RevokedAt)
+			}
+		})
+	}
+}
+
+func TestAPIKeyUpdateRateLimit(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("UPDATE `api_keys` SET `rate_limit_multiplier`=? WHERE `id` = ?")
+	apiKey := &models.APIKey{ID: uuid.New(), RateLimitMultiplier: 2.5}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(2.5, apiKey.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(2.5, apiKey.ID).WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &apiKeyRepository{db: db}
+			err := repo.UpdateRateLimit(apiKey)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
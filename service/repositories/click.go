@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"time"
+	"url-shortener/domain"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const clickBatchSize = 500
+
+var intervalFormats = map[string]string{
+	"hour":  "%Y-%m-%d %H:00:00",
+	"day":   "%Y-%m-%d 00:00:00",
+	"month": "%Y-%m-01 00:00:00",
+}
+
+type clickRepository struct {
+	db *gorm.DB
+}
+
+func NewClickRepository(db *gorm.DB) *clickRepository {
+	return &clickRepository{db}
+}
+
+func (r *clickRepository) CreateBatch(clicks []*models.Click) error {
+	return r.db.CreateInBatches(clicks, clickBatchSize).Error
+}
+
+func (r *clickRepository) CountByInterval(shortLinkID uuid.UUID, interval string, from time.Time, to time.Time) ([]*domain.StatsBucket, error) {
+	rows := []struct {
+		Bucket string
+		Count  int
+	}{}
+
+	err := r.db.Model(&models.Click{}).
+		Select("DATE_FORMAT(clicked_at, ?) AS bucket, COUNT(*) AS count", intervalFormats[interval]).
+		Where("short_link_id = ? AND clicked_at >= ? AND clicked_at < ?", shortLinkID, from, to).
+		Group("bucket").
+		Order("bucket").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]*domain.StatsBucket, 0, len(rows))
+	for _, row := range rows {
+		t, err := time.ParseInLocation(time.DateTime, row.Bucket, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, &domain.StatsBucket{Time: t, Count: row.Count})
+	}
+	return buckets, nil
+}
+
+// CountByVariant counts clicks per variant. Clicks that went to no variant
+// aren't counted.
+func (r *clickRepository) CountByVariant(shortLinkID uuid.UUID, from time.Time, to time.Time) (map[uuid.UUID]int, error) {
+	rows := []struct {
+		VariantID uuid.UUID
+		Count     int
+	}{}
+
+	err := r.db.Model(&models.Click{}).
+		Select("variant_id, COUNT(*) AS count").
+		Where("short_link_id = ? AND variant_id IS NOT NULL AND clicked_at >= ? AND clicked_at < ?", shortLinkID, from, to).
+		Group("variant_id").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.VariantID] = row.Count
+	}
+	return counts, nil
+}
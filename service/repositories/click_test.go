@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClickRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewClickRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestClickCreateBatch(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	variantID := uuid.New()
+	clickedAt := time.Now()
+	clicks := []*models.Click{
+		{ShortLinkID: shortLinkID, ClickedAt: clickedAt, Referrer: "https://example.org", IPPrefix: "203.0.113.0/24"},
+		{ShortLinkID: shortLinkID, VariantID: &variantID, ClickedAt: clickedAt, UserAgent: "Mozilla/5.0"},
+	}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `clicks`").
+					WithArgs(
+						shortLinkID, nil, clickedAt, "https://example.org", "", "203.0.113.0/24", "",
+						shortLinkID, &variantID, clickedAt, "", "Mozilla/5.0", "", "",
+					).
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `clicks`").WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &clickRepository{db: db}
+			err := repo.CreateBatch(clicks)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClickCountByInterval(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	from := time.Date(2023, 10, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2023, 10, 3, 0, 0, 0, 0, time.Local)
+	query := regexp.QuoteMeta("SELECT DATE_FORMAT(clicked_at, ?) AS bucket, COUNT(*) AS count FROM `clicks` WHERE short_link_id = ? AND clicked_at >= ? AND clicked_at < ? GROUP BY `bucket` ORDER BY bucket")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expected    []*domain.StatsBucket
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(intervalFormats["day"], shortLinkID, from, to).
+					WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+						AddRow("2023-10-01 00:00:00", 3).
+						AddRow("2023-10-02 00:00:00", 5))
+			},
+			expected: []*domain.StatsBucket{
+				{Time: from, Count: 3},
+				{Time: from.AddDate(0, 0, 1), Count: 5},
+			},
+		}, {
+			name: "invalid bucket",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs(intervalFormats["day"], shortLinkID, from, to).
+					WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow("foo", 3))
+			},
+			expectedErr: errors.New(`parsing time "foo" as "2006-01-02 15:04:05": cannot parse "foo" as "2006"`),
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &clickRepository{db: db}
+			res, err := repo.CountByInterval(shortLinkID, "day", from, to)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestClickCountByVariant(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	variantA, variantB := uuid.New(), uuid.New()
+	from := time.Date(2023, 10, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2023, 10, 3, 0, 0, 0, 0, time.Local)
+	query := regexp.QuoteMeta("SELECT variant_id, COUNT(*) AS count FROM `clicks` WHERE short_link_id = ? AND variant_id IS NOT NULL AND clicked_at >= ? AND clicked_at < ? GROUP BY `variant_id`")
+
+	mock.ExpectQuery(query).
+		WithArgs(shortLinkID, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"variant_id", "count"}).
+			AddRow(variantA.String(), 7).
+			AddRow(variantB.String(), 3))
+
+	repo := &clickRepository{db: db}
+	res, err := repo.CountByVariant(shortLinkID, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]int{variantA: 7, variantB: 3}, res)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+
+	res, err = repo.CountByVariant(shortLinkID, from, to)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
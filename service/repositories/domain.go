@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type domainRepository struct {
+	db *gorm.DB
+}
+
+func NewDomainRepository(db *gorm.DB) *domainRepository {
+	return &domainRepository{db}
+}
+
+func (r *domainRepository) Create(domain *models.Domain) error {
+	return r.db.Create(domain).Error
+}
+
+func (r *domainRepository) FindAll() ([]*models.Domain, error) {
+	domains := []*models.Domain{}
+	if err := r.db.Order("name").Find(&domains).Error; err != nil {
+		return nil, err
+	}
+	return domains, nil
+}
+
+func (r *domainRepository) FindByID(id uuid.UUID) (*models.Domain, error) {
+	domain := &models.Domain{}
+	if err := r.db.Where("id = ?", id).First(domain).Error; err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (r *domainRepository) FindByName(name string) (*models.Domain, error) {
+	domain := &models.Domain{}
+	if err := r.db.Where("name = ?", name).First(domain).Error; err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (r *domainRepository) CountShortLinks(name string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ShortLink{}).Where("domain = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (r *domainRepository) Delete(domain *models.Domain) error {
+	return r.db.Delete(domain).Error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type domainRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewDomainRuleRepository(db *gorm.DB) *domainRuleRepository {
+	return &domainRuleRepository{db}
+}
+
+func (r *domainRuleRepository) Create(rule *models.DomainRule) error {
+	return r.db.Create(rule).Error
+}
+
+func (r *domainRuleRepository) FindAll() ([]*models.DomainRule, error) {
+	rules := []*models.DomainRule{}
+	if err := r.db.Order("domain").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *domainRuleRepository) FindByID(id uuid.UUID) (*models.DomainRule, error) {
+	rule := &models.DomainRule{}
+	if err := r.db.Where("id = ?", id).First(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *domainRuleRepository) FindByDomain(domain string) (*models.DomainRule, error) {
+	rule := &models.DomainRule{}
+	if err := r.db.Where("domain = ?", domain).First(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *domainRuleRepository) Delete(rule *models.DomainRule) error {
+	return r.db.Delete(rule).Error
+}
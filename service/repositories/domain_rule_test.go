@@ -0,0 +1,242 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewDomainRuleRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewDomainRuleRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestDomainRuleCreate(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	rule := &models.DomainRule{
+		ID:        uuid.New(),
+		Domain:    "example.com",
+		Type:      models.DomainRuleBlock,
+		Reason:    "phishing",
+		CreatedBy: uuid.New(),
+	}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `domain_rules`").
+					WithArgs(rule.ID, rule.Domain, rule.Type, rule.Reason, rule.CreatedBy, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `domain_rules`").WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRuleRepository{db: db}
+			err := repo.Create(rule)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDomainRuleFindAll(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("SELECT * FROM `domain_rules` ORDER BY domain")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "type"}).
+						AddRow(uuid.New(), "a.example.com", models.DomainRuleAllow).
+						AddRow(uuid.New(), "example.com", models.DomainRuleBlock))
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRuleRepository{db: db}
+			res, err := repo.FindAll()
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, res, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestDomainRuleFind(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	rule := &models.DomainRule{
+		ID:        uuid.New(),
+		Domain:    "example.com",
+		Type:      models.DomainRuleBlock,
+		Reason:    "phishing",
+		CreatedBy: uuid.New(),
+		CreatedAt: time.Now(),
+	}
+	columns := []string{"id", "domain", "type", "reason", "created_by", "created_at"}
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(rule.ID, rule.Domain, rule.Type, rule.Reason, rule.CreatedBy, rule.CreatedAt)
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		find        func(repo *domainRuleRepository) (*models.DomainRule, error)
+		expectedErr error
+	}{
+		{
+			name: "find by id",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domain_rules` WHERE id = ?").WithArgs(rule.ID).WillReturnRows(row())
+			},
+			find: func(repo *domainRuleRepository) (*models.DomainRule, error) {
+				return repo.FindByID(rule.ID)
+			},
+		}, {
+			name: "find by id not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domain_rules` WHERE id = ?").WithArgs(rule.ID).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *domainRuleRepository) (*models.DomainRule, error) {
+				return repo.FindByID(rule.ID)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "find by domain",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domain_rules` WHERE domain = ?").WithArgs(rule.Domain).WillReturnRows(row())
+			},
+			find: func(repo *domainRuleRepository) (*models.DomainRule, error) {
+				return repo.FindByDomain(rule.Domain)
+			},
+		}, {
+			name: "find by domain not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domain_rules` WHERE domain = ?").WithArgs(rule.Domain).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *domainRuleRepository) (*models.DomainRule, error) {
+				return repo.FindByDomain(rule.Domain)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRuleRepository{db: db}
+			res, err := tt.find(repo)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, rule, res)
+			}
+		})
+	}
+}
+
+func TestDomainRuleDelete(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	rule := &models.DomainRule{ID: uuid.New()}
+	query := regexp.QuoteMeta("DELETE FROM `domain_rules` WHERE `domain_rules`.`id` = ?")
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(rule.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(rule.ID).WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRuleRepository{db: db}
+			err := repo.Delete(rule)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewDomainRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewDomainRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestDomainCreate(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	customDomain := &models.Domain{
+		ID:        uuid.New(),
+		Name:      "go.example.com",
+		CreatedBy: uuid.New(),
+	}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `domains`").
+					WithArgs(customDomain.ID, customDomain.Name, customDomain.CreatedBy, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `domains`").WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRepository{db: db}
+			err := repo.Create(customDomain)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDomainFindAll(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("SELECT * FROM `domains` ORDER BY name")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+						AddRow(uuid.New(), "go.example.com").
+						AddRow(uuid.New(), "s.example.org"))
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRepository{db: db}
+			res, err := repo.FindAll()
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, res, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestDomainFind(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	customDomain := &models.Domain{
+		ID:        uuid.New(),
+		Name:      "go.example.com",
+		CreatedBy: uuid.New(),
+		CreatedAt: time.Now(),
+	}
+	columns := []string{"id", "name", "created_by", "created_at"}
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(customDomain.ID, customDomain.Name, customDomain.CreatedBy, customDomain.CreatedAt)
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		find        func(repo *domainRepository) (*models.Domain, error)
+		expectedErr error
+	}{
+		{
+			name: "find by id",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domains` WHERE id = ?").WithArgs(customDomain.ID).WillReturnRows(row())
+			},
+			find: func(repo *domainRepository) (*models.Domain, error) {
+				return repo.FindByID(customDomain.ID)
+			},
+		}, {
+			name: "find by id not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domains` WHERE id = ?").WithArgs(customDomain.ID).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *domainRepository) (*models.Domain, error) {
+				return repo.FindByID(customDomain.ID)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "find by name",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domains` WHERE name = ?").WithArgs(customDomain.Name).WillReturnRows(row())
+			},
+			find: func(repo *domainRepository) (*models.Domain, error) {
+				return repo.FindByName(customDomain.Name)
+			},
+		}, {
+			name: "find by name not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `domains` WHERE name = ?").WithArgs(customDomain.Name).WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			find: func(repo *domainRepository) (*models.Domain, error) {
+				return repo.FindByName(customDomain.Name)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRepository{db: db}
+			res, err := tt.find(repo)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, customDomain, res)
+			}
+		})
+	}
+}
+
+func TestDomainCountShortLinks(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("SELECT count(*) FROM `short_links` WHERE domain = ?")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expected    int64
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("go.example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+			},
+			expected: 3,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).WithArgs("go.example.com").WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRepository{db: db}
+			res, err := repo.CountShortLinks("go.example.com")
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestDomainDelete(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	customDomain := &models.Domain{ID: uuid.New()}
+	query := regexp.QuoteMeta("DELETE FROM `domains` WHERE `domains`.`id` = ?")
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(customDomain.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(query).WithArgs(customDomain.ID).WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &domainRepository{db: db}
+			err := repo.Delete(customDomain)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
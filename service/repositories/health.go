@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type healthRepository struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+func NewHealthRepository(db *gorm.DB, rdb *redis.Client) *healthRepository {
+	return &healthRepository{db, rdb}
+}
+
+func (r *healthRepository) PingDatabase(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *healthRepository) PingRedis(ctx context.Context) error {
+	return r.rdb.Ping(ctx).Err()
+}
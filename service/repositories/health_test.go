@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	_, rdb, closeRedis := SetupRedisMock(t)
+	defer closeRedis()
+
+	repo := NewHealthRepository(db, rdb)
+
+	assert.NotNil(t, repo.db)
+	assert.NotNil(t, repo.rdb)
+}
+
+func TestHealthPing(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mr, rdb, closeRedis := SetupRedisMock(t)
+	defer closeRedis()
+
+	repo := &healthRepository{db: db, rdb: rdb}
+
+	assert.NoError(t, repo.PingDatabase(context.Background()))
+	assert.NoError(t, repo.PingRedis(context.Background()))
+
+	mr.Close()
+	assert.Error(t, repo.PingRedis(context.Background()))
+}
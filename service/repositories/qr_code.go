@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const qrCodePrefix = "qr_code_"
+
+type qrCodeRepository struct {
+	rdb *redis.Client
+}
+
+func NewQRCodeRepository(rdb *redis.Client) *qrCodeRepository {
+	return &qrCodeRepository{rdb}
+}
+
+func (r *qrCodeRepository) SetQRCode(key string, image []byte, exp time.Duration) error {
+	return r.rdb.Set(context.Background(), qrCodePrefix+key, image, exp).Err()
+}
+
+func (r *qrCodeRepository) FindQRCode(key string) ([]byte, error) {
+	return r.rdb.Get(context.Background(), qrCodePrefix+key).Bytes()
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQRCodeRepository(t *testing.T) {
+	_, rdb, closeRedis := SetupRedisMock(t)
+	defer closeRedis()
+
+	repo := NewQRCodeRepository(rdb)
+
+	assert.NotNil(t, repo.rdb)
+}
+
+func TestQRCodeCache(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &qrCodeRepository{rdb: rdb}
+
+	_, err := repo.FindQRCode("foo")
+	assert.ErrorIs(t, err, redis.Nil)
+
+	assert.NoError(t, repo.SetQRCode("foo", []byte("png"), time.Minute))
+	image, err := repo.FindQRCode("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("png"), image)
+	assert.Equal(t, time.Minute, mr.TTL(qrCodePrefix+"foo"))
+
+	mr.SetError("error")
+	assert.Error(t, repo.SetQRCode("foo", []byte("png"), time.Minute))
+	_, err = repo.FindQRCode("foo")
+	assert.Error(t, err)
+}
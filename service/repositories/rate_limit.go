@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"url-shortener/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	rateLimitPrefix   = "rate_limit_"
+	blockPrefix       = "block_"
+	blockStrikePrefix = "block_strikes_"
+)
+
+// gcraScript keeps one theoretical arrival time per key, in microseconds of
+// the Redis clock so replicas with skewed clocks agree. A full budget can be
+// spent at once and refills evenly over the period.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local next_tat = tat + emission
+local used = next_tat - now
+if used > period then
+	return {0, 0, math.ceil((used - period) / 1000)}
+end
+
+redis.call("SET", KEYS[1], string.format("%.0f", next_tat), "PX", math.ceil(used / 1000))
+return {1, math.floor((period - used) / emission), 0}
+`)
+
+// blockScript doubles the block on every strike, up to max. Strikes are
+// forgotten once a client has behaved for twice max.
+var blockScript = redis.NewScript(`
+local base = tonumber(ARGV[1])
+local max = tonumber(ARGV[2])
+
+local strikes = redis.call("INCR", KEYS[2])
+redis.call("PEXPIRE", KEYS[2], max * 2)
+
+local ttl = math.floor(math.min(base * 2 ^ (strikes - 1), max))
+redis.call("SET", KEYS[1], strikes, "PX", ttl)
+return ttl
+`)
+
+type rateLimitRepository struct {
+	rdb *redis.Client
+}
+
+func NewRateLimitRepository(rdb *redis.Client) *rateLimitRepository {
+	return &rateLimitRepository{rdb}
+}
+
+func (r *rateLimitRepository) Allow(key string, limit int, period time.Duration) (*domain.RateLimit, error) {
+	emission := period.Microseconds() / int64(limit)
+
+	res, err := gcraScript.Run(context.Background(), r.rdb, []string{rateLimitPrefix + key}, emission, emission*int64(limit)).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RateLimit{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+	}, nil
+}
+
+func (r *rateLimitRepository) FindBlock(client string) (time.Duration, error) {
+	ttl, err := r.rdb.PTTL(context.Background(), blockPrefix+client).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return max(ttl, 0), nil
+}
+
+func (r *rateLimitRepository) Block(client string, base time.Duration, max time.Duration) (time.Duration, error) {
+	ttl, err := blockScript.Run(context.Background(), r.rdb, []string{blockPrefix + client, blockStrikePrefix + client}, base.Milliseconds(), max.Milliseconds()).Int64()
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(ttl) * time.Millisecond, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRateLimitRepository(t *testing.T) {
+	_, rdb, closeRedis := SetupRedisMock(t)
+	defer closeRedis()
+
+	repo := NewRateLimitRepository(rdb)
+
+	assert.NotNil(t, repo.rdb)
+}
+
+func TestRateLimitAllow(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mr.SetTime(now)
+	repo := &rateLimitRepository{rdb: rdb}
+
+	for i := 2; i >= 0; i-- {
+		rateLimit, err := repo.Allow("foo", 3, time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, rateLimit.Allowed)
+		assert.Equal(t, i, rateLimit.Remaining)
+	}
+
+	rateLimit, err := repo.Allow("foo", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, rateLimit.Allowed)
+	assert.Equal(t, 0, rateLimit.Remaining)
+	assert.Equal(t, 20*time.Second, rateLimit.RetryAfter)
+
+	rateLimit, err = repo.Allow("bar", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, rateLimit.Allowed)
+
+	mr.SetTime(now.Add(20 * time.Second))
+	rateLimit, err = repo.Allow("foo", 3, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, rateLimit.Allowed)
+	assert.Equal(t, 0, rateLimit.Remaining)
+
+	mr.SetError("error")
+	_, err = repo.Allow("foo", 3, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestRateLimitBlock(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &rateLimitRepository{rdb: rdb}
+
+	ttl, err := repo.FindBlock("ip:127.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		ttl, err = repo.Block("ip:127.0.0.1", time.Minute, 5*time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, want, ttl)
+	}
+
+	ttl, err = repo.FindBlock("ip:127.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, ttl)
+
+	ttl, err = repo.FindBlock("ip:127.0.0.2")
+	assert.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	mr.FastForward(5 * time.Minute)
+	ttl, err = repo.FindBlock("ip:127.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	mr.SetError("error")
+	_, err = repo.FindBlock("ip:127.0.0.1")
+	assert.Error(t, err)
+	_, err = repo.Block("ip:127.0.0.1", time.Minute, 5*time.Minute)
+	assert.Error(t, err)
+}
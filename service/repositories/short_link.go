@@ -2,53 +2,602 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
+	"url-shortener/domain"
 	"url-shortener/models"
+	"url-shortener/utils/bloom"
+	"url-shortener/utils/lru"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
-const cacheDestPrefix = "dest_slash_"
+const (
+	shortLinkBatchSize  = 500
+	cacheDestPrefix     = "dest_slash_"
+	pendingVisitorsKey  = "pending_visitors"
+	flushingVisitorsKey = "pending_visitors_flushing"
+	flushVisitorsLock   = "pending_visitors_lock"
+	failedUnlocksPrefix = "failed_unlocks_"
+	invalidationChannel = "short_link_invalidations"
+	createdChannel      = "short_link_created"
 
+	minFilterSize        = 100000
+	filterFalsePositives = 0.01
+
+	subscriptionPing  = 10 * time.Second
+	subscriptionRetry = time.Second
+)
+
+// takePendingVisitors moves the pending counts aside so new visits keep
+// accumulating while they are written to MySQL. Counts left over from an
+// interrupted flush are returned again before new ones are taken.
+var takePendingVisitors = redis.NewScript(`
+if redis.call("SET", KEYS[3], "1", "NX", "PX", ARGV[1]) == false then
+	return {}
+end
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return {}
+	end
+	redis.call("RENAME", KEYS[1], KEYS[2])
+end
+return redis.call("HGETALL", KEYS[2])
+`)
+
+// shortLinkRepository keeps hot cache entries in process as well, so most
+// redirects don't reach Redis and keep being served while it is unavailable.
+// A localSize of 0 turns the local cache off.
 type shortLinkRepository struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db       *gorm.DB
+	rdb      *redis.Client
+	local    *lru.Cache[string, domain.ShortLinkCache]
+	localTTL time.Duration
+
+	// invalidations holds the cache keys that couldn't be dropped from
+	// Redis, with a count so a key queued again during a retry is kept.
+	invalidations   map[string]int
+	invalidationsMu sync.Mutex
+
+	filter         atomic.Pointer[bloom.Filter]
+	loading        atomic.Pointer[bloom.Filter]
+	filterLoadedAt atomic.Int64
+	filterGen      atomic.Int64
+	// unsubscribed is set while new links announced by other replicas may
+	// be missed, and the filter can't be trusted.
+	unsubscribed atomic.Bool
 }
 
-func NewShortLinkRepository(db *gorm.DB, rdb *redis.Client) *shortLinkRepository {
-	return &shortLinkRepository{db, rdb}
+func NewShortLinkRepository(db *gorm.DB, rdb *redis.Client, localSize int, localTTL time.Duration) *shortLinkRepository {
+	repo := &shortLinkRepository{db: db, rdb: rdb, localTTL: localTTL}
+	if localSize > 0 && localTTL > 0 {
+		repo.local = lru.New[string, domain.ShortLinkCache](localSize)
+	}
+	return repo
 }
 
 func (r *shortLinkRepository) Create(shortLink *models.ShortLink) error {
 	return r.db.Create(shortLink).Error
 }
 
-func (r *shortLinkRepository) FindBySlashCode(slashCode string) (*models.ShortLink, error) {
+func (r *shortLinkRepository) CreateBatch(shortLinks []*models.ShortLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(shortLinks, shortLinkBatchSize).Error
+	})
+}
+
+func (r *shortLinkRepository) FindExistingSlashCodes(host string, slashCodes []string) ([]string, error) {
+	existing := []string{}
+	err := r.db.Model(&models.ShortLink{}).
+		Where("domain = ? AND slash_code IN ?", host, slashCodes).
+		Pluck("slash_code", &existing).
+		Error
+	return existing, err
+}
+
+func (r *shortLinkRepository) FindBySlashCode(host string, slashCode string) (*models.ShortLink, error) {
 	shortLink := &models.ShortLink{}
-	if err := r.db.Where("slash_code = ?", slashCode).First(shortLink).Error; err != nil {
+	if err := r.db.Where("domain = ? AND slash_code = ?", host, slashCode).First(shortLink).Error; err != nil {
 		return nil, err
 	}
 	return shortLink, nil
 }
 
-func (r *shortLinkRepository) IncrementVisitor(slashCode string, visitors int) error {
+func (r *shortLinkRepository) FindByIDs(ids []uuid.UUID) ([]*models.ShortLink, error) {
+	shortLinks := []*models.ShortLink{}
+	if err := r.db.Where("id IN ?", ids).Find(&shortLinks).Error; err != nil {
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
+// FindExpired returns the links whose expiry fell after from and no later
+// than to.
+func (r *shortLinkRepository) FindExpired(from time.Time, to time.Time) ([]*models.ShortLink, error) {
+	shortLinks := []*models.ShortLink{}
+	if err := r.db.Where("expires_at > ? AND expires_at <= ?", from, to).Order("expires_at").Find(&shortLinks).Error; err != nil {
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
+func (r *shortLinkRepository) List(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+	query := r.db.Model(&models.ShortLink{}).Where("owner_id = ?", filter.OwnerID)
+
+	if filter.Host != "" {
+		query = query.Where("destination_host = ?", filter.Host)
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	column, direction, operator := "created_at", "ASC", ">"
+	if filter.Sort == "visitors" {
+		column = "visitors"
+	}
+	if filter.Desc {
+		direction, operator = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		var value interface{} = filter.After.CreatedAt
+		if column == "visitors" {
+			value = filter.After.Visitors
+		}
+		query = query.Where(fmt.Sprintf("(%v, id) %v (?, ?)", column, operator), value, filter.After.ID)
+	}
+
+	shortLinks := []*models.ShortLink{}
+	err := query.
+		Order(fmt.Sprintf("%v %v, id %v", column, direction, direction)).
+		Limit(filter.Limit).
+		Find(&shortLinks).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
+func (r *shortLinkRepository) Update(shortLink *models.ShortLink) error {
+	return r.db.Model(shortLink).
+		Select("*").
+		Omit("id", "owner_id", "domain", "slash_code", "visitors", "created_at").
+		Updates(shortLink).
+		Error
+}
+
+func (r *shortLinkRepository) Delete(shortLink *models.ShortLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("short_link_id = ?", shortLink.ID).Delete(&models.LinkRule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("short_link_id = ?", shortLink.ID).Delete(&models.LinkVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(shortLink).Error
+	})
+}
+
+func (r *shortLinkRepository) FindRules(shortLinkID uuid.UUID) ([]*models.LinkRule, error) {
+	rules := []*models.LinkRule{}
+	if err := r.db.Where("short_link_id = ?", shortLinkID).Order("position").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ReplaceRules swaps every rule of a link at once, so visitors never see
+// half of the new list.
+func (r *shortLinkRepository) ReplaceRules(shortLinkID uuid.UUID, rules []*models.LinkRule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&models.LinkRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(rules).Error
+	})
+}
+
+func (r *shortLinkRepository) FindVariants(shortLinkID uuid.UUID) ([]*models.LinkVariant, error) {
+	variants := []*models.LinkVariant{}
+	if err := r.db.Where("short_link_id = ?", shortLinkID).Order("position").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (r *shortLinkRepository) ReplaceVariants(shortLinkID uuid.UUID, variants []*models.LinkVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&models.LinkVariant{}).Error; err != nil {
+			return err
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		return tx.Create(variants).Error
+	})
+}
+
+// ClaimUnowned gives the links created before links had owners to ownerID.
+func (r *shortLinkRepository) ClaimUnowned(ownerID uuid.UUID) (int64, error) {
+	result := r.db.Model(&models.ShortLink{}).
+		Where("owner_id IS NULL").
+		UpdateColumn("owner_id", ownerID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *shortLinkRepository) IncrementVisitor(id uuid.UUID, visitors int) error {
 	return r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Model(&models.ShortLink{}).
-		Where("slash_code = ?", slashCode).
+		Where("id = ?", id).
 		UpdateColumn("visitors", gorm.Expr("visitors + ?", visitors)).
 		Error
 }
 
-func (r *shortLinkRepository) SetShortLinkCache(slashCode string, dest string, exp time.Duration) error {
-	return r.rdb.Set(context.Background(), cacheDestPrefix+slashCode, dest, exp).Err()
+func (r *shortLinkRepository) SetShortLinkCache(host string, slashCode string, cache *domain.ShortLinkCache, exp time.Duration) error {
+	value, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	key := linkKey(host, slashCode)
+	r.local.Set(key, *cache, min(exp, r.localTTL))
+	return r.rdb.Set(context.Background(), cacheDestPrefix+key, value, exp).Err()
+}
+
+// FindShortLinkCache copies entries found in Redis into the local cache for
+// no longer than they have left in Redis.
+func (r *shortLinkRepository) FindShortLinkCache(host string, slashCode string) (*domain.ShortLinkCache, error) {
+	key := linkKey(host, slashCode)
+	if cache, ok := r.local.Get(key); ok {
+		return &cache, nil
+	}
+	// Redis may still hold the entry that couldn't be dropped.
+	if r.isInvalidationPending(key) {
+		return nil, redis.Nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	get := pipe.Get(context.Background(), cacheDestPrefix+key)
+	ttl := pipe.PTTL(context.Background(), cacheDestPrefix+key)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return nil, err
+	}
+
+	cache := &domain.ShortLinkCache{}
+	if err := json.Unmarshal([]byte(get.Val()), cache); err != nil {
+		return nil, err
+	}
+	r.local.Set(key, *cache, min(ttl.Val(), r.localTTL))
+	return cache, nil
+}
+
+// DeleteShortLinkCache tells every replica to drop its local copy as well.
+// When Redis can't be reached the key is queued for
+// RetryCacheInvalidations, and not read from Redis until then.
+func (r *shortLinkRepository) DeleteShortLinkCache(host string, slashCode string) error {
+	key := linkKey(host, slashCode)
+	r.local.Delete(key)
+
+	if err := r.invalidate([]string{key}); err != nil {
+		r.queueInvalidations([]string{key})
+		return err
+	}
+	return nil
+}
+
+func (r *shortLinkRepository) queueInvalidations(keys []string) {
+	r.invalidationsMu.Lock()
+	defer r.invalidationsMu.Unlock()
+	if r.invalidations == nil {
+		r.invalidations = map[string]int{}
+	}
+	for _, key := range keys {
+		r.invalidations[key]++
+	}
+}
+
+// RetryCacheInvalidations drops the cache keys queued while Redis was
+// unavailable and tells the other replicas about them.
+func (r *shortLinkRepository) RetryCacheInvalidations() error {
+	r.invalidationsMu.Lock()
+	queued := make(map[string]int, len(r.invalidations))
+	keys := make([]string, 0, len(r.invalidations))
+	for key, n := range r.invalidations {
+		queued[key] = n
+		keys = append(keys, key)
+	}
+	r.invalidationsMu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := r.invalidate(keys); err != nil {
+		return err
+	}
+
+	r.invalidationsMu.Lock()
+	defer r.invalidationsMu.Unlock()
+	for key, n := range queued {
+		if r.invalidations[key] == n {
+			delete(r.invalidations, key)
+		}
+	}
+	return nil
+}
+
+func (r *shortLinkRepository) invalidate(keys []string) error {
+	pipe := r.rdb.Pipeline()
+	for _, key := range keys {
+		pipe.Del(context.Background(), cacheDestPrefix+key)
+		pipe.Publish(context.Background(), invalidationChannel, key)
+	}
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (r *shortLinkRepository) isInvalidationPending(key string) bool {
+	r.invalidationsMu.Lock()
+	defer r.invalidationsMu.Unlock()
+	_, ok := r.invalidations[key]
+	return ok
+}
+
+// AnnounceShortLinks drops cached misses of new links and tells every
+// replica to add them to its slash code filter. If Redis can't be reached
+// the links are queued with the invalidations, which are added to the
+// filters as well once they are retried.
+func (r *shortLinkRepository) AnnounceShortLinks(shortLinks []*models.ShortLink) error {
+	keys := make([]string, len(shortLinks))
+	pipe := r.rdb.Pipeline()
+	for i, shortLink := range shortLinks {
+		keys[i] = linkKey(shortLink.Domain, shortLink.SlashCode)
+		r.addToFilter(keys[i])
+		pipe.Del(context.Background(), cacheDestPrefix+keys[i])
+		pipe.Publish(context.Background(), createdChannel, keys[i])
+	}
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		r.queueInvalidations(keys)
+		return err
+	}
+	return nil
+}
+
+// MayExist is false only for slash codes that are known not to exist. It is
+// true while the filter isn't loaded, and while the subscription is down so
+// links created on other replicas in the meantime aren't turned away.
+func (r *shortLinkRepository) MayExist(host string, slashCode string) bool {
+	filter := r.filter.Load()
+	return filter == nil || r.unsubscribed.Load() || filter.MayContain(linkKey(host, slashCode))
 }
 
-func (r *shortLinkRepository) FindShortLinkCache(slashCode string) (string, error) {
-	dest, err := r.rdb.Get(context.Background(), cacheDestPrefix+slashCode).Result()
+// LoadSlashCodeFilter rebuilds the filter from every link unless it was
+// built less than maxAge ago. Links created while it loads are added to both
+// the old and the new filter. A filter that was loading while the
+// subscription dropped may have missed links, so it is thrown away.
+func (r *shortLinkRepository) LoadSlashCodeFilter(maxAge time.Duration) error {
+	if r.filter.Load() != nil && time.Since(time.Unix(0, r.filterLoadedAt.Load())) < maxAge {
+		return nil
+	}
+	gen := r.filterGen.Load()
+
+	var count int64
+	if err := r.db.Model(&models.ShortLink{}).Count(&count).Error; err != nil {
+		return err
+	}
+
+	filter := bloom.New(max(2*int(count), minFilterSize), filterFalsePositives)
+	r.loading.Store(filter)
+	defer r.loading.Store(nil)
+
+	rows := []*models.ShortLink{}
+	err := r.db.Model(&models.ShortLink{}).Select("id", "domain", "slash_code").
+		FindInBatches(&rows, shortLinkBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, row := range rows {
+				filter.Add(linkKey(row.Domain, row.SlashCode))
+			}
+			return nil
+		}).Error
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	if r.filterGen.Load() == gen {
+		r.filter.Store(filter)
+		r.filterLoadedAt.Store(time.Now().UnixNano())
+	}
+	return nil
+}
+
+func (r *shortLinkRepository) addToFilter(key string) {
+	if filter := r.filter.Load(); filter != nil {
+		filter.Add(key)
+	}
+	if filter := r.loading.Load(); filter != nil {
+		filter.Add(key)
+	}
+}
+
+// ListenCacheInvalidation applies invalidations and new links from other
+// replicas until ctx is done. Messages sent while disconnected are lost, so
+// the filter is bypassed as soon as the subscription drops, and every time
+// it is made again the local cache is cleared and the filter is dropped
+// until it is loaded again.
+func (r *shortLinkRepository) ListenCacheInvalidation(ctx context.Context) {
+	for {
+		r.listen(ctx)
+		r.unsubscribed.Store(true)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(subscriptionRetry):
+		}
+	}
+}
+
+// listen returns once the subscription fails, or a ping goes unanswered.
+// Invalidated keys are added to the filter too, since a retried
+// announcement is sent as an invalidation.
+func (r *shortLinkRepository) listen(ctx context.Context) {
+	pubsub := r.rdb.Subscribe(ctx, invalidationChannel, createdChannel)
+	defer pubsub.Close()
+	// Reads don't watch ctx, closing the subscription ends them.
+	stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+	defer stop()
+
+	pinged := false
+	for {
+		msg, err := pubsub.ReceiveTimeout(ctx, subscriptionPing)
+		if err != nil {
+			var netErr net.Error
+			if pinged || !errors.As(err, &netErr) || !netErr.Timeout() {
+				return
+			}
+			pinged = true
+			if err := pubsub.Ping(ctx); err != nil {
+				return
+			}
+			continue
+		}
+		pinged = false
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			r.local.Purge()
+			r.filterGen.Add(1)
+			r.filter.Store(nil)
+			r.unsubscribed.Store(false)
+		case *redis.Message:
+			r.local.Delete(msg.Payload)
+			r.addToFilter(msg.Payload)
+		}
+	}
+}
+
+func (r *shortLinkRepository) IncrementPendingVisitors(counts map[string]int) error {
+	pipe := r.rdb.Pipeline()
+	for id, visitors := range counts {
+		pipe.HIncrBy(context.Background(), pendingVisitorsKey, id, int64(visitors))
+	}
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (r *shortLinkRepository) FindPendingVisitors(id string) (int, error) {
+	pipe := r.rdb.Pipeline()
+	pending := pipe.HGet(context.Background(), pendingVisitorsKey, id)
+	flushing := pipe.HGet(context.Background(), flushingVisitorsKey, id)
+	if _, err := pipe.Exec(context.Background()); err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	visitors := 0
+	for _, cmd := range []*redis.StringCmd{pending, flushing} {
+		n, err := cmd.Int()
+		if err != nil && err != redis.Nil {
+			return 0, err
+		}
+		visitors += n
+	}
+	return visitors, nil
+}
+
+// FindPendingVisitorsByIDs reads the buffered visitors of several links in a
+// single round trip. Links without any are left out.
+func (r *shortLinkRepository) FindPendingVisitorsByIDs(ids []string) (map[string]int, error) {
+	visitors := make(map[string]int, len(ids))
+	if len(ids) == 0 {
+		return visitors, nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	pending := pipe.HMGet(context.Background(), pendingVisitorsKey, ids...)
+	flushing := pipe.HMGet(context.Background(), flushingVisitorsKey, ids...)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return nil, err
+	}
+
+	for _, cmd := range []*redis.SliceCmd{pending, flushing} {
+		for i, value := range cmd.Val() {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			visitors[ids[i]] += n
+		}
+	}
+	return visitors, nil
+}
+
+func (r *shortLinkRepository) TakePendingVisitors(lease time.Duration) (map[string]int, error) {
+	keys := []string{pendingVisitorsKey, flushingVisitorsKey, flushVisitorsLock}
+	values, err := takePendingVisitors.Run(context.Background(), r.rdb, keys, lease.Milliseconds()).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		visitors, err := strconv.Atoi(values[i+1])
+		if err != nil {
+			return nil, err
+		}
+		counts[values[i]] = visitors
+	}
+	return counts, nil
+}
+
+func (r *shortLinkRepository) AckPendingVisitors(id string) error {
+	return r.rdb.HDel(context.Background(), flushingVisitorsKey, id).Err()
+}
+
+func (r *shortLinkRepository) ReleasePendingVisitors() error {
+	return r.rdb.Del(context.Background(), flushVisitorsLock).Err()
+}
+
+func (r *shortLinkRepository) CountFailedUnlocks(id uuid.UUID) (int, error) {
+	count, err := r.rdb.Get(context.Background(), failedUnlocksPrefix+id.String()).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// IncrementFailedUnlocks starts the window on the first failure, so attempts
+// are allowed again once it has passed since then.
+func (r *shortLinkRepository) IncrementFailedUnlocks(id uuid.UUID, window time.Duration) error {
+	pipe := r.rdb.TxPipeline()
+	pipe.SetNX(context.Background(), failedUnlocksPrefix+id.String(), 0, window)
+	pipe.Incr(context.Background(), failedUnlocksPrefix+id.String())
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+// linkKey leaves keys of links on the default domain as they were before
+// custom domains existed.
+func linkKey(host string, slashCode string) string {
+	if host == "" {
+		return slashCode
 	}
-	return dest, nil
+	return host + "/" + slashCode
 }
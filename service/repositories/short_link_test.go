@@ -3,9 +3,13 @@ package repositories
 import (
 	"context"
 	"errors"
+	"regexp"
 	"testing"
 	"time"
+	"url-shortener/domain"
 	"url-shortener/models"
+	"url-shortener/utils/bloom"
+	"url-shortener/utils/lru"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/alicebob/miniredis/v2"
@@ -59,10 +63,15 @@ func TestNewShortLinkRepository(t *testing.T) {
 	_, rdb, closeRedis := SetupRedisMock(t)
 	defer closeRedis()
 
-	repo := NewShortLinkRepository(db, rdb)
+	repo := NewShortLinkRepository(db, rdb, 10, time.Minute)
 
 	assert.NotNil(t, repo.db)
 	assert.NotNil(t, repo.rdb)
+	assert.NotNil(t, repo.local)
+	assert.Equal(t, time.Minute, repo.localTTL)
+
+	repo = NewShortLinkRepository(db, rdb, 0, time.Minute)
+	assert.Nil(t, repo.local)
 }
 
 func TestShortLinkCreate(t *testing.T) {
@@ -74,9 +83,11 @@ func TestShortLinkCreate(t *testing.T) {
 		err       error
 	}{
 		shortLink: &models.ShortLink{
-			SlashCode:   "example",
-			Destination: "https://example.com",
-			Visitors:    0,
+			SlashCode:       "example",
+			Destination:     "https://example.com",
+			DestinationHost: "example.com",
+			RedirectType:    302,
+			Visitors:        0,
 		},
 		err: errors.New("error"),
 	}
@@ -93,9 +104,25 @@ func TestShortLinkCreate(t *testing.T) {
 				mock.ExpectExec("INSERT INTO `short_links`").
 					WithArgs(
 						sqlmock.AnyArg(),
+						mockData.shortLink.OwnerID,
+						mockData.shortLink.Domain,
 						mockData.shortLink.SlashCode,
 						mockData.shortLink.Destination,
+						mockData.shortLink.DestinationHost,
+						mockData.shortLink.RedirectType,
+						mockData.shortLink.PasswordHash,
+						mockData.shortLink.UTM.Source,
+						mockData.shortLink.UTM.Medium,
+						mockData.shortLink.UTM.Campaign,
+						mockData.shortLink.UTM.Term,
+						mockData.shortLink.UTM.Content,
+						"off",
+						mockData.shortLink.OG.Title,
+						mockData.shortLink.OG.Description,
+						mockData.shortLink.OG.Image,
 						mockData.shortLink.Visitors,
+						mockData.shortLink.MaxVisits,
+						mockData.shortLink.ExpiresAt,
 						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 					).
@@ -109,9 +136,25 @@ func TestShortLinkCreate(t *testing.T) {
 				mock.ExpectExec("INSERT INTO `short_links`").
 					WithArgs(
 						sqlmock.AnyArg(),
+						mockData.shortLink.OwnerID,
+						mockData.shortLink.Domain,
 						mockData.shortLink.SlashCode,
 						mockData.shortLink.Destination,
+						mockData.shortLink.DestinationHost,
+						mockData.shortLink.RedirectType,
+						mockData.shortLink.PasswordHash,
+						mockData.shortLink.UTM.Source,
+						mockData.shortLink.UTM.Medium,
+						mockData.shortLink.UTM.Campaign,
+						mockData.shortLink.UTM.Term,
+						mockData.shortLink.UTM.Content,
+						"off",
+						mockData.shortLink.OG.Title,
+						mockData.shortLink.OG.Description,
+						mockData.shortLink.OG.Image,
 						mockData.shortLink.Visitors,
+						mockData.shortLink.MaxVisits,
+						mockData.shortLink.ExpiresAt,
 						sqlmock.AnyArg(),
 						sqlmock.AnyArg(),
 					).
@@ -136,6 +179,103 @@ func TestShortLinkCreate(t *testing.T) {
 	}
 }
 
+func TestShortLinkCreateBatch(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinks := []*models.ShortLink{
+		{ID: uuid.New(), SlashCode: "foo", Destination: "https://example.com", DestinationHost: "example.com", RedirectType: 301},
+		{ID: uuid.New(), SlashCode: "bar", Destination: "https://example.org", DestinationHost: "example.org", RedirectType: 302},
+	}
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `short_links`").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `short_links`").
+					WillReturnError(err)
+				mock.ExpectRollback()
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			err := repo.CreateBatch(shortLinks)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShortLinkFindExistingSlashCodes(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("SELECT `slash_code` FROM `short_links` WHERE domain = ? AND slash_code IN (?,?)")
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("", "foo", "bar").
+					WillReturnRows(sqlmock.NewRows([]string{"slash_code"}).AddRow("bar"))
+			},
+			expected: []string{"bar"},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(query).
+					WithArgs("", "foo", "bar").
+					WillReturnError(err)
+			},
+			expectedErr: err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			res, err := repo.FindExistingSlashCodes("", []string{"foo", "bar"})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
 func TestShortLinkFindBySlashCode(t *testing.T) {
 	db, mock, closeDB := SetupDatabaseMock(t)
 	defer closeDB()
@@ -153,7 +293,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		},
-		query: "SELECT (.+) FROM `short_links` WHERE slash_code = ?",
+		query: "SELECT (.+) FROM `short_links` WHERE domain = (.+) AND slash_code = ?",
 		err:   errors.New("error"),
 	}
 
@@ -167,7 +307,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 			name: "success",
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery(mockData.query).
-					WithArgs(mockData.shortLink.SlashCode).
+					WithArgs(mockData.shortLink.Domain, mockData.shortLink.SlashCode).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "slash_code", "destination", "visitors", "created_at", "updated_at"}).
 						AddRow(
 							mockData.shortLink.ID,
@@ -183,7 +323,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 			name: "not found",
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery(mockData.query).
-					WithArgs(mockData.shortLink.SlashCode).
+					WithArgs(mockData.shortLink.Domain, mockData.shortLink.SlashCode).
 					WillReturnRows(sqlmock.NewRows([]string{}))
 			},
 			expectedErr: gorm.ErrRecordNotFound,
@@ -191,7 +331,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 			name: "error",
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectQuery(mockData.query).
-					WithArgs(mockData.shortLink.SlashCode).
+					WithArgs(mockData.shortLink.Domain, mockData.shortLink.SlashCode).
 					WillReturnError(mockData.err)
 			},
 			expectedErr: mockData.err,
@@ -202,7 +342,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup(mock)
 			repo := &shortLinkRepository{db: db}
-			res, err := repo.FindBySlashCode(mockData.shortLink.SlashCode)
+			res, err := repo.FindBySlashCode(mockData.shortLink.Domain, mockData.shortLink.SlashCode)
 			if tt.expectedErr != nil {
 				assert.ErrorIs(t, err, tt.expectedErr)
 				assert.Nil(t, res)
@@ -214,18 +354,461 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 	}
 }
 
-func TestShortLinkIncrementVisitor(t *testing.T) {
+func TestShortLinkFindByIDs(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	query := regexp.QuoteMeta("SELECT * FROM `short_links` WHERE id IN (?,?)")
+
+	mock.ExpectQuery(query).
+		WithArgs(ids[0], ids[1]).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "visitors"}).
+			AddRow(ids[0], 99).
+			AddRow(ids[1], 1000))
+
+	repo := &shortLinkRepository{db: db}
+	res, err := repo.FindByIDs(ids)
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, 1000, res[1].Visitors)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+
+	res, err = repo.FindByIDs(ids)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestShortLinkFindExpired(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	from := time.Date(2023, 10, 1, 0, 0, 0, 0, time.Local)
+	to := from.Add(time.Minute)
+	query := regexp.QuoteMeta("SELECT * FROM `short_links` WHERE expires_at > ? AND expires_at <= ? ORDER BY expires_at")
+
+	mock.ExpectQuery(query).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "expires_at"}).AddRow(uuid.New(), from.Add(time.Second)))
+
+	repo := &shortLinkRepository{db: db}
+	res, err := repo.FindExpired(from, to)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+
+	res, err = repo.FindExpired(from, to)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestShortLinkList(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	createdFrom := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	ownerID := uuid.New()
+	after := &domain.ShortLinkCursor{ID: uuid.New(), CreatedAt: time.Now(), Visitors: 10}
+	columns := []string{"id", "slash_code", "destination", "visitors", "created_at", "updated_at"}
+
+	tests := []struct {
+		name        string
+		filter      *domain.ShortLinkFilter
+		setup       func(mock sqlmock.Sqlmock)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name:   "newest first",
+			filter: &domain.ShortLinkFilter{OwnerID: ownerID, Sort: "created_at", Desc: true, Limit: 2},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `short_links` WHERE owner_id = ? ORDER BY created_at DESC, id DESC LIMIT 2")).
+					WithArgs(ownerID).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(uuid.New(), "foo", "https://example.com", 0, time.Now(), time.Now()).
+						AddRow(uuid.New(), "bar", "https://example.com", 0, time.Now(), time.Now()))
+			},
+			expectedLen: 2,
+		}, {
+			name: "filtered after cursor",
+			filter: &domain.ShortLinkFilter{
+				OwnerID:     ownerID,
+				Host:        "example.com",
+				CreatedFrom: &createdFrom,
+				Sort:        "visitors",
+				After:       after,
+				Limit:       2,
+			},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `short_links` WHERE owner_id = ? AND destination_host = ? AND created_at >= ? AND (visitors, id) > (?, ?) ORDER BY visitors ASC, id ASC LIMIT 2")).
+					WithArgs(ownerID, "example.com", createdFrom, after.Visitors, after.ID).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+		}, {
+			name:   "error",
+			filter: &domain.ShortLinkFilter{Sort: "created_at", Limit: 2},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM `short_links`").WillReturnError(errors.New("error"))
+			},
+			expectedErr: errors.New("error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			res, err := repo.List(tt.filter)
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, res, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestShortLinkUpdate(t *testing.T) {
 	db, mock, closeDB := SetupDatabaseMock(t)
 	defer closeDB()
 
 	mockData := struct {
-		slashCode string
+		shortLink *models.ShortLink
 		query     string
 		err       error
 	}{
-		slashCode: "foo",
-		query:     "UPDATE `short_links`",
-		err:       errors.New("error"),
+		shortLink: &models.ShortLink{
+			ID:              uuid.New(),
+			SlashCode:       "foo",
+			Destination:     "https://example.com",
+			DestinationHost: "example.com",
+			RedirectType:    307,
+			UTM:             models.UTM{Source: "newsletter"},
+			Passthrough:     "link",
+		},
+		query: "UPDATE `short_links` SET `destination`=?,`destination_host`=?,`redirect_type`=?,`password_hash`=?,`utm_source`=?,`utm_medium`=?,`utm_campaign`=?,`utm_term`=?,`utm_content`=?,`passthrough`=?,`og_title`=?,`og_description`=?,`og_image`=?,`max_visits`=?,`expires_at`=?,`updated_at`=? WHERE `id` = ?",
+		err:   errors.New("error"),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(mockData.query)).
+					WithArgs(mockData.shortLink.Destination, mockData.shortLink.DestinationHost, mockData.shortLink.RedirectType, mockData.shortLink.PasswordHash, "newsletter", "", "", "", "", "link", "", "", "", nil, nil, sqlmock.AnyArg(), mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(mockData.query)).
+					WithArgs(mockData.shortLink.Destination, mockData.shortLink.DestinationHost, mockData.shortLink.RedirectType, mockData.shortLink.PasswordHash, "newsletter", "", "", "", "", "link", "", "", "", nil, nil, sqlmock.AnyArg(), mockData.shortLink.ID).
+					WillReturnError(mockData.err)
+				mock.ExpectRollback()
+			},
+			expectedErr: mockData.err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			err := repo.Update(mockData.shortLink)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShortLinkDelete(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mockData := struct {
+		shortLink *models.ShortLink
+		query     string
+		err       error
+	}{
+		shortLink: &models.ShortLink{
+			ID:        uuid.New(),
+			SlashCode: "foo",
+		},
+		query: "DELETE FROM `short_links` WHERE `short_links`.`id` = ?",
+		err:   errors.New("error"),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `link_rules` WHERE short_link_id = ?")).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `link_variants` WHERE short_link_id = ?")).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta(mockData.query)).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `link_rules` WHERE short_link_id = ?")).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `link_variants` WHERE short_link_id = ?")).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta(mockData.query)).
+					WithArgs(mockData.shortLink.ID).
+					WillReturnError(mockData.err)
+				mock.ExpectRollback()
+			},
+			expectedErr: mockData.err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			err := repo.Delete(mockData.shortLink)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShortLinkFindRules(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	query := "SELECT * FROM `link_rules` WHERE short_link_id = ? ORDER BY position"
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(shortLinkID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_link_id", "position", "os", "countries", "destination"}).
+			AddRow(uuid.New(), shortLinkID, 0, "ios", nil, "https://apps.apple.com").
+			AddRow(uuid.New(), shortLinkID, 1, "", `["TH","LA"]`, "https://example.co.th"))
+
+	repo := &shortLinkRepository{db: db}
+	rules, err := repo.FindRules(shortLinkID)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "ios", rules[0].OS)
+	assert.Nil(t, rules[0].Countries)
+	assert.Equal(t, []string{"TH", "LA"}, rules[1].Countries)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(shortLinkID).
+		WillReturnError(errors.New("error"))
+
+	_, err = repo.FindRules(shortLinkID)
+	assert.Error(t, err)
+}
+
+func TestShortLinkReplaceRules(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	rules := []*models.LinkRule{
+		{ID: uuid.New(), ShortLinkID: shortLinkID, Position: 0, OS: "android", Destination: "https://play.google.com"},
+	}
+	deleteQuery := "DELETE FROM `link_rules` WHERE short_link_id = ?"
+
+	tests := []struct {
+		name        string
+		rules       []*models.LinkRule
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr bool
+	}{
+		{
+			name:  "success",
+			rules: rules,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `link_rules`").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "success clearing rules",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name:  "error",
+			rules: rules,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `link_rules`").
+					WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			err := repo.ReplaceRules(shortLinkID, tt.rules)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShortLinkFindVariants(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	query := "SELECT * FROM `link_variants` WHERE short_link_id = ? ORDER BY position"
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(shortLinkID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "short_link_id", "position", "destination", "weight"}).
+			AddRow(uuid.New(), shortLinkID, 0, "https://example.com/a", 70).
+			AddRow(uuid.New(), shortLinkID, 1, "https://example.com/b", 30))
+
+	repo := &shortLinkRepository{db: db}
+	variants, err := repo.FindVariants(shortLinkID)
+	assert.NoError(t, err)
+	assert.Len(t, variants, 2)
+	assert.Equal(t, 70, variants[0].Weight)
+	assert.Equal(t, "https://example.com/b", variants[1].Destination)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(shortLinkID).
+		WillReturnError(errors.New("error"))
+
+	_, err = repo.FindVariants(shortLinkID)
+	assert.Error(t, err)
+}
+
+func TestShortLinkReplaceVariants(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	shortLinkID := uuid.New()
+	variants := []*models.LinkVariant{
+		{ID: uuid.New(), ShortLinkID: shortLinkID, Position: 0, Destination: "https://example.com/a", Weight: 70},
+		{ID: uuid.New(), ShortLinkID: shortLinkID, Position: 1, Destination: "https://example.com/b", Weight: 30},
+	}
+	deleteQuery := "DELETE FROM `link_variants` WHERE short_link_id = ?"
+
+	tests := []struct {
+		name        string
+		variants    []*models.LinkVariant
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr bool
+	}{
+		{
+			name:     "success",
+			variants: variants,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `link_variants`").
+					WillReturnResult(sqlmock.NewResult(1, 2))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "success clearing variants",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		}, {
+			name:     "error",
+			variants: variants,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).
+					WithArgs(shortLinkID).
+					WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &shortLinkRepository{db: db}
+			err := repo.ReplaceVariants(shortLinkID, tt.variants)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShortLinkIncrementVisitor(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mockData := struct {
+		id    uuid.UUID
+		query string
+		err   error
+	}{
+		id:    uuid.New(),
+		query: "UPDATE `short_links`",
+		err:   errors.New("error"),
 	}
 
 	tests := []struct {
@@ -238,7 +821,7 @@ func TestShortLinkIncrementVisitor(t *testing.T) {
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 				mock.ExpectExec(mockData.query).
-					WithArgs(1, mockData.slashCode).
+					WithArgs(1, mockData.id).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 			},
@@ -247,7 +830,7 @@ func TestShortLinkIncrementVisitor(t *testing.T) {
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 				mock.ExpectExec(mockData.query).
-					WithArgs(1, mockData.slashCode).
+					WithArgs(1, mockData.id).
 					WillReturnError(gorm.ErrRecordNotFound)
 				mock.ExpectRollback()
 			},
@@ -257,7 +840,7 @@ func TestShortLinkIncrementVisitor(t *testing.T) {
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 				mock.ExpectExec(mockData.query).
-					WithArgs(1, mockData.slashCode).
+					WithArgs(1, mockData.id).
 					WillReturnError(mockData.err)
 				mock.ExpectRollback()
 			},
@@ -269,7 +852,7 @@ func TestShortLinkIncrementVisitor(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setup(mock)
 			repo := &shortLinkRepository{db: db}
-			err := repo.IncrementVisitor(mockData.slashCode, 1)
+			err := repo.IncrementVisitor(mockData.id, 1)
 			if tt.expectedErr != nil {
 				assert.ErrorIs(t, err, tt.expectedErr)
 			} else {
@@ -279,23 +862,56 @@ func TestShortLinkIncrementVisitor(t *testing.T) {
 	}
 }
 
+func TestShortLinkClaimUnowned(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	ownerID := uuid.New()
+	repo := &shortLinkRepository{db: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `short_links` SET `owner_id`=\\? WHERE owner_id IS NULL").
+		WithArgs(ownerID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	claimed, err := repo.ClaimUnowned(ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), claimed)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `short_links`").
+		WithArgs(ownerID).
+		WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+	_, err = repo.ClaimUnowned(ownerID)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestShortLinkSetShortLinkCache(t *testing.T) {
 	tests := []struct {
 		name       string
+		host       string
 		slashCode  string
-		dest       string
+		cache      *domain.ShortLinkCache
 		expiration time.Duration
 		setupErr   func(mr *miniredis.Miniredis)
 	}{
 		{
 			name:       "success",
 			slashCode:  "foo",
-			dest:       "www.example.com",
+			cache:      &domain.ShortLinkCache{ID: uuid.New(), Destination: "www.example.com"},
+			expiration: 1 * time.Second,
+		}, {
+			name:       "custom domain",
+			host:       "go.example.com",
+			slashCode:  "foo",
+			cache:      &domain.ShortLinkCache{ID: uuid.New(), Destination: "www.example.com"},
 			expiration: 1 * time.Second,
 		}, {
 			name:       "invalid expiration",
 			slashCode:  "foo",
-			dest:       "www.example.com",
+			cache:      &domain.ShortLinkCache{ID: uuid.New(), Destination: "www.example.com"},
 			expiration: -1 * time.Second,
 			setupErr: func(mr *miniredis.Miniredis) {
 				mr.SetError("invalid expiration")
@@ -313,14 +929,14 @@ func TestShortLinkSetShortLinkCache(t *testing.T) {
 			}
 
 			repo := &shortLinkRepository{rdb: rdb}
-			err := repo.SetShortLinkCache(tt.slashCode, tt.dest, tt.expiration)
+			err := repo.SetShortLinkCache(tt.host, tt.slashCode, tt.cache, tt.expiration)
 
 			if tt.setupErr != nil {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				dest, err := rdb.Get(context.Background(), cacheDestPrefix+tt.slashCode).Result()
-				assert.NotEmpty(t, dest)
+				value, err := rdb.Get(context.Background(), cacheDestPrefix+linkKey(tt.host, tt.slashCode)).Result()
+				assert.Contains(t, value, tt.cache.Destination)
 				assert.NoError(t, err)
 			}
 		})
@@ -331,18 +947,28 @@ func TestShortLinkFindShortLinkCache(t *testing.T) {
 	tests := []struct {
 		name        string
 		slashCode   string
-		setup       func(rdb *redis.Client, key string, val string)
-		expected    string
+		setup       func(rdb *redis.Client, key string)
+		expected    *domain.ShortLinkCache
 		setupErr    func(mr *miniredis.Miniredis)
 		expectedErr error
 	}{
 		{
 			name:      "success",
 			slashCode: "foo",
-			setup: func(rdb *redis.Client, key string, val string) {
-				rdb.Set(context.Background(), cacheDestPrefix+key, val, 1*time.Second).Err()
+			setup: func(rdb *redis.Client, key string) {
+				rdb.Set(context.Background(), cacheDestPrefix+key, `{"id":"c6633797-5031-4326-9997-9a190a771399","destination":"bar"}`, 1*time.Second).Err()
+			},
+			expected: &domain.ShortLinkCache{
+				ID:          uuid.MustParse("c6633797-5031-4326-9997-9a190a771399"),
+				Destination: "bar",
+			},
+		}, {
+			name:      "invalid value",
+			slashCode: "foo",
+			setup: func(rdb *redis.Client, key string) {
+				rdb.Set(context.Background(), cacheDestPrefix+key, "bar", 1*time.Second).Err()
 			},
-			expected: "bar",
+			expectedErr: errors.New("invalid character 'b' looking for beginning of value"),
 		}, {
 			name:        "not found",
 			slashCode:   "foo",
@@ -363,7 +989,7 @@ func TestShortLinkFindShortLinkCache(t *testing.T) {
 			defer cleanup()
 
 			if tt.setup != nil {
-				tt.setup(rdb, tt.slashCode, tt.expected)
+				tt.setup(rdb, tt.slashCode)
 			}
 
 			if tt.setupErr != nil {
@@ -371,15 +997,365 @@ func TestShortLinkFindShortLinkCache(t *testing.T) {
 			}
 
 			repo := &shortLinkRepository{rdb: rdb}
-			dest, err := repo.FindShortLinkCache(tt.slashCode)
+			cache, err := repo.FindShortLinkCache("", tt.slashCode)
 
 			if tt.expectedErr != nil {
-				assert.Empty(t, dest)
+				assert.Nil(t, cache)
 				assert.EqualError(t, err, tt.expectedErr.Error())
 			} else {
-				assert.NotEmpty(t, dest)
+				assert.Equal(t, tt.expected, cache)
 				assert.NoError(t, err)
 			}
 		})
 	}
 }
+
+func TestShortLinkDeleteShortLinkCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		slashCode string
+		setup     func(rdb *redis.Client, key string)
+		setupErr  func(mr *miniredis.Miniredis)
+	}{
+		{
+			name:      "success",
+			slashCode: "foo",
+			setup: func(rdb *redis.Client, key string) {
+				rdb.Set(context.Background(), cacheDestPrefix+key, "www.example.com", 1*time.Second).Err()
+			},
+		}, {
+			name:      "not cached",
+			slashCode: "foo",
+		}, {
+			name:      "error",
+			slashCode: "foo",
+			setupErr: func(mr *miniredis.Miniredis) {
+				mr.SetError("error")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr, rdb, cleanup := SetupRedisMock(t)
+			defer cleanup()
+
+			if tt.setup != nil {
+				tt.setup(rdb, tt.slashCode)
+			}
+
+			if tt.setupErr != nil {
+				tt.setupErr(mr)
+			}
+
+			repo := &shortLinkRepository{rdb: rdb}
+			err := repo.DeleteShortLinkCache("", tt.slashCode)
+
+			if tt.setupErr != nil {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.False(t, mr.Exists(cacheDestPrefix+tt.slashCode))
+			}
+		})
+	}
+}
+
+func TestShortLinkRetryCacheInvalidations(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+	mr.Set(cacheDestPrefix+"foo", `{"destination":"https://example.com/old"}`)
+
+	mr.SetError("error")
+	assert.Error(t, repo.DeleteShortLinkCache("", "foo"))
+	assert.Error(t, repo.RetryCacheInvalidations())
+	mr.SetError("")
+
+	_, err := repo.FindShortLinkCache("", "foo")
+	assert.ErrorIs(t, err, redis.Nil)
+	assert.True(t, mr.Exists(cacheDestPrefix+"foo"))
+
+	assert.NoError(t, repo.RetryCacheInvalidations())
+	assert.False(t, mr.Exists(cacheDestPrefix+"foo"))
+	assert.Empty(t, repo.invalidations)
+	assert.NoError(t, repo.RetryCacheInvalidations())
+}
+
+func TestShortLinkLocalCache(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb, local: lru.New[string, domain.ShortLinkCache](10), localTTL: time.Minute}
+	cache := &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com"}
+
+	mr.Set(cacheDestPrefix+"foo", `{"destination":"https://example.com/foo"}`)
+	mr.SetTTL(cacheDestPrefix+"foo", time.Hour)
+	found, err := repo.FindShortLinkCache("", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/foo", found.Destination)
+
+	assert.NoError(t, repo.SetShortLinkCache("", "bar", cache, time.Hour))
+
+	mr.SetError("error")
+	found, err = repo.FindShortLinkCache("", "foo")
+	assert.NoError(t, err, "served from memory while redis fails")
+	assert.Equal(t, "https://example.com/foo", found.Destination)
+	found, err = repo.FindShortLinkCache("", "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, cache, found)
+
+	assert.Error(t, repo.DeleteShortLinkCache("", "bar"))
+	_, err = repo.FindShortLinkCache("", "bar")
+	assert.Error(t, err, "deleted from memory even if redis fails")
+}
+
+func TestShortLinkListenCacheInvalidation(t *testing.T) {
+	_, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	replica := &shortLinkRepository{rdb: rdb, local: lru.New[string, domain.ShortLinkCache](10), localTTL: time.Minute}
+	replica.local.Set("stale", domain.ShortLinkCache{}, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		replica.ListenCacheInvalidation(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return replica.local.Len() == 0
+	}, time.Second, 10*time.Millisecond, "cleared on subscribe")
+
+	cache := &domain.ShortLinkCache{Destination: "https://example.com"}
+	assert.NoError(t, replica.SetShortLinkCache("", "foo", cache, time.Hour))
+	assert.NoError(t, replica.SetShortLinkCache("", "bar", cache, time.Hour))
+
+	other := &shortLinkRepository{rdb: rdb}
+	assert.NoError(t, other.DeleteShortLinkCache("", "foo"))
+
+	assert.Eventually(t, func() bool {
+		_, ok := replica.local.Get("foo")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	_, ok := replica.local.Get("bar")
+	assert.True(t, ok)
+
+	replica.filter.Store(bloom.New(10, 0.01))
+	assert.False(t, replica.MayExist("", "baz"))
+	assert.NoError(t, other.AnnounceShortLinks([]*models.ShortLink{{SlashCode: "baz"}}))
+	assert.Eventually(t, func() bool {
+		return replica.MayExist("", "baz")
+	}, time.Second, 10*time.Millisecond, "links created elsewhere are added to the filter")
+
+	cancel()
+	<-done
+}
+
+func TestShortLinkListenCacheInvalidationDisconnected(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	replica := &shortLinkRepository{rdb: rdb}
+	other := &shortLinkRepository{rdb: rdb}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		replica.ListenCacheInvalidation(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return mr.PubSubNumSub(createdChannel)[createdChannel] == 1
+	}, time.Second, 10*time.Millisecond)
+
+	replica.filter.Store(bloom.New(10, 0.01))
+	assert.False(t, replica.MayExist("", "foo"))
+
+	// Links announced while the subscription is down would be missed, so
+	// the filter is bypassed until it is made again and reloaded.
+	mr.Close()
+	assert.Eventually(t, func() bool {
+		return replica.MayExist("", "foo")
+	}, time.Second, 10*time.Millisecond)
+
+	// An announcement that fails is retried as an invalidation.
+	assert.Error(t, other.AnnounceShortLinks([]*models.ShortLink{{SlashCode: "foo"}}))
+
+	assert.NoError(t, mr.Restart())
+	assert.Eventually(t, func() bool {
+		return !replica.unsubscribed.Load() && replica.filter.Load() == nil
+	}, 3*time.Second, 10*time.Millisecond)
+
+	replica.filter.Store(bloom.New(10, 0.01))
+	assert.False(t, replica.MayExist("", "foo"))
+	assert.NoError(t, other.RetryCacheInvalidations())
+	assert.Eventually(t, func() bool {
+		return replica.MayExist("", "foo")
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestShortLinkSlashCodeFilter(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mr, rdb, closeRedis := SetupRedisMock(t)
+	defer closeRedis()
+
+	repo := &shortLinkRepository{db: db, rdb: rdb}
+	assert.True(t, repo.MayExist("", "foo"), "everything may exist until the filter is loaded")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `short_links`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT `id`,`domain`,`slash_code` FROM `short_links` ORDER BY `short_links`.`id` LIMIT 500")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "slash_code"}).
+			AddRow(uuid.New(), "", "foo").
+			AddRow(uuid.New(), "go.example.com", "bar"))
+	assert.NoError(t, repo.LoadSlashCodeFilter(time.Hour))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.True(t, repo.MayExist("", "foo"))
+	assert.True(t, repo.MayExist("go.example.com", "bar"))
+	assert.False(t, repo.MayExist("", "bar"))
+
+	assert.NoError(t, repo.LoadSlashCodeFilter(time.Hour), "fresh filter isn't reloaded")
+
+	mr.Set(cacheDestPrefix+"baz", `{"not_found":true}`)
+	assert.NoError(t, repo.AnnounceShortLinks([]*models.ShortLink{{SlashCode: "baz"}}))
+	assert.True(t, repo.MayExist("", "baz"))
+	assert.False(t, mr.Exists(cacheDestPrefix+"baz"), "cached miss is dropped")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `short_links`")).WillReturnError(errors.New("error"))
+	assert.Error(t, repo.LoadSlashCodeFilter(0))
+	assert.False(t, repo.MayExist("", "bar"), "previous filter is kept")
+}
+
+func TestShortLinkIncrementPendingVisitors(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+	assert.NoError(t, repo.IncrementPendingVisitors(map[string]int{"foo": 2, "bar": 1}))
+	assert.NoError(t, repo.IncrementPendingVisitors(map[string]int{"foo": 3}))
+
+	assert.Equal(t, "5", mr.HGet(pendingVisitorsKey, "foo"))
+	assert.Equal(t, "1", mr.HGet(pendingVisitorsKey, "bar"))
+
+	mr.SetError("error")
+	assert.Error(t, repo.IncrementPendingVisitors(map[string]int{"foo": 1}))
+}
+
+func TestShortLinkFindPendingVisitors(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+
+	visitors, err := repo.FindPendingVisitors("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, visitors)
+
+	mr.HSet(pendingVisitorsKey, "foo", "2")
+	mr.HSet(flushingVisitorsKey, "foo", "3")
+	visitors, err = repo.FindPendingVisitors("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, visitors)
+
+	mr.SetError("error")
+	_, err = repo.FindPendingVisitors("foo")
+	assert.Error(t, err)
+}
+
+func TestShortLinkFindPendingVisitorsByIDs(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+
+	mr.HSet(pendingVisitorsKey, "foo", "2")
+	mr.HSet(flushingVisitorsKey, "foo", "3")
+	mr.HSet(flushingVisitorsKey, "bar", "1")
+	visitors, err := repo.FindPendingVisitorsByIDs([]string{"foo", "bar", "baz"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"foo": 5, "bar": 1}, visitors)
+
+	mr.SetError("error")
+	_, err = repo.FindPendingVisitorsByIDs([]string{"foo"})
+	assert.Error(t, err)
+}
+
+func TestShortLinkTakePendingVisitors(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+
+	counts, err := repo.TakePendingVisitors(time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+	assert.NoError(t, repo.ReleasePendingVisitors())
+
+	mr.HSet(pendingVisitorsKey, "foo", "2")
+	mr.HSet(pendingVisitorsKey, "bar", "1")
+	counts, err = repo.TakePendingVisitors(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"foo": 2, "bar": 1}, counts)
+	assert.False(t, mr.Exists(pendingVisitorsKey))
+
+	// another flusher is locked out while the lease is held
+	mr.HSet(pendingVisitorsKey, "baz", "1")
+	counts, err = repo.TakePendingVisitors(time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+
+	// unacknowledged counts are handed out again before new ones
+	assert.NoError(t, repo.AckPendingVisitors("foo"))
+	assert.NoError(t, repo.ReleasePendingVisitors())
+	counts, err = repo.TakePendingVisitors(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"bar": 1}, counts)
+
+	assert.NoError(t, repo.AckPendingVisitors("bar"))
+	assert.NoError(t, repo.ReleasePendingVisitors())
+	counts, err = repo.TakePendingVisitors(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"baz": 1}, counts)
+
+	mr.SetError("error")
+	_, err = repo.TakePendingVisitors(time.Minute)
+	assert.Error(t, err)
+}
+
+func TestShortLinkFailedUnlocks(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	repo := &shortLinkRepository{rdb: rdb}
+	id := uuid.New()
+
+	failed, err := repo.CountFailedUnlocks(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failed)
+
+	assert.NoError(t, repo.IncrementFailedUnlocks(id, time.Minute))
+	assert.NoError(t, repo.IncrementFailedUnlocks(id, time.Hour))
+	failed, err = repo.CountFailedUnlocks(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, time.Minute, mr.TTL(failedUnlocksPrefix+id.String()))
+
+	mr.FastForward(time.Minute)
+	failed, err = repo.CountFailedUnlocks(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failed)
+
+	mr.SetError("error")
+	_, err = repo.CountFailedUnlocks(id)
+	assert.Error(t, err)
+	assert.Error(t, repo.IncrementFailedUnlocks(id, time.Minute))
+}
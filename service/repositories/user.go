@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *userRepository {
+	return &userRepository{db}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *userRepository) FindByID(id uuid.UUID) (*models.User, error) {
+	user := &models.User{}
+	if err := r.db.Where("id = ?", id).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+	user := &models.User{}
+	if err := r.db.Where("email = ?", email).First(user).Error; err != nil {
+		return nil, err
+	}
+	return user, nil
+}
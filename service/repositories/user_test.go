@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNewUserRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewUserRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestUserCreate(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mockData := struct {
+		user *models.User
+		err  error
+	}{
+		user: &models.User{
+			ID:    uuid.New(),
+			Email: "foo@example.com",
+		},
+		err: errors.New("error"),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs(mockData.user.ID, mockData.user.Email, mockData.user.IsAdmin, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `users`").
+					WithArgs(mockData.user.ID, mockData.user.Email, mockData.user.IsAdmin, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnError(mockData.err)
+				mock.ExpectRollback()
+			},
+			expectedErr: mockData.err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &userRepository{db: db}
+			err := repo.Create(mockData.user)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserFindByID(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mockData := struct {
+		user  *models.User
+		query string
+		err   error
+	}{
+		user: &models.User{
+			ID:        uuid.New(),
+			Email:     "foo@example.com",
+			IsAdmin:   true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		query: "SELECT (.+) FROM `users` WHERE id = ?",
+		err:   errors.New("error"),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expected    *models.User
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.ID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "is_admin", "created_at", "updated_at"}).
+						AddRow(mockData.user.ID, mockData.user.Email, mockData.user.IsAdmin, mockData.user.CreatedAt, mockData.user.UpdatedAt))
+			},
+			expected: mockData.user,
+		}, {
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.ID).
+					WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.ID).
+					WillReturnError(mockData.err)
+			},
+			expectedErr: mockData.err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &userRepository{db: db}
+			res, err := repo.FindByID(mockData.user.ID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestUserFindByEmail(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	mockData := struct {
+		user  *models.User
+		query string
+		err   error
+	}{
+		user: &models.User{
+			ID:        uuid.New(),
+			Email:     "foo@example.com",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+		query: "SELECT (.+) FROM `users` WHERE email = ?",
+		err:   errors.New("error"),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expected    *models.User
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.Email).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "created_at", "updated_at"}).
+						AddRow(mockData.user.ID, mockData.user.Email, mockData.user.CreatedAt, mockData.user.UpdatedAt))
+			},
+			expected: mockData.user,
+		}, {
+			name: "not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.Email).
+					WillReturnRows(sqlmock.NewRows([]string{}))
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(mockData.query).
+					WithArgs(mockData.user.Email).
+					WillReturnError(mockData.err)
+			},
+			expectedErr: mockData.err,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &userRepository{db: db}
+			res, err := repo.FindByEmail(mockData.user.Email)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
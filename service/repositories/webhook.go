@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *webhookRepository {
+	return &webhookRepository{db}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+func (r *webhookRepository) FindByID(id uuid.UUID) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	if err := r.db.Where("id = ?", id).First(webhook).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (r *webhookRepository) FindByOwnerID(ownerID uuid.UUID) ([]*models.Webhook, error) {
+	webhooks := []*models.Webhook{}
+	if err := r.db.Where("owner_id = ?", ownerID).Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// HasSubscribers reports whether any webhook subscribes to one of events.
+func (r *webhookRepository) HasSubscribers(events []string) (bool, error) {
+	query := r.db.Model(&models.Webhook{})
+	for i, event := range events {
+		pattern := `%"` + event + `"%`
+		if i == 0 {
+			query = query.Where("events LIKE ?", pattern)
+		} else {
+			query = query.Or("events LIKE ?", pattern)
+		}
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Delete drops the webhook together with everything still queued for it.
+func (r *webhookRepository) Delete(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDeadLetter{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+// Enqueue queues the deliveries of one event. An event with a key is only
+// queued the first time, and false is returned for repeats.
+func (r *webhookRepository) Enqueue(key string, deliveries []*models.WebhookDelivery) (bool, error) {
+	queued := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if key != "" {
+			res := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&models.WebhookEventKey{Key: key})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return nil
+			}
+		}
+		if err := tx.Create(deliveries).Error; err != nil {
+			return err
+		}
+		queued = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return queued, nil
+}
+
+// ClaimDeliveries takes the deliveries that are due and holds them for the
+// lease, so other workers skip them while they are being sent.
+func (r *webhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	deliveries := []*models.WebhookDelivery{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).UpdateColumn("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	var webhookIDs []uuid.UUID
+	for _, delivery := range deliveries {
+		webhookIDs = append(webhookIDs, delivery.WebhookID)
+	}
+	webhooks := []*models.Webhook{}
+	if err := r.db.Where("id IN ?", webhookIDs).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*models.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		byID[webhook.ID] = webhook
+	}
+	for _, delivery := range deliveries {
+		delivery.Webhook = byID[delivery.WebhookID]
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) DeleteDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Delete(delivery).Error
+}
+
+func (r *webhookRepository) RetryDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).UpdateColumns(map[string]any{
+		"attempts":        delivery.Attempts,
+		"last_error":      delivery.LastError,
+		"next_attempt_at": delivery.NextAttemptAt,
+	}).Error
+}
+
+// DeadLetter moves a delivery that ran out of attempts aside.
+func (r *webhookRepository) DeadLetter(delivery *models.WebhookDelivery) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		deadLetter := &models.WebhookDeadLetter{
+			ID:        delivery.ID,
+			WebhookID: delivery.WebhookID,
+			Event:     delivery.Event,
+			Payload:   delivery.Payload,
+			Attempts:  delivery.Attempts,
+			LastError: delivery.LastError,
+			CreatedAt: delivery.CreatedAt,
+			FailedAt:  time.Now(),
+		}
+		if err := tx.Create(deadLetter).Error; err != nil {
+			return err
+		}
+		return tx.Delete(delivery).Error
+	})
+}
+
+func (r *webhookRepository) FindDeadLetters(webhookID uuid.UUID, limit int) ([]*models.WebhookDeadLetter, error) {
+	deadLetters := []*models.WebhookDeadLetter{}
+	if err := r.db.Where("webhook_id = ?", webhookID).Order("failed_at DESC").Limit(limit).Find(&deadLetters).Error; err != nil {
+		return nil, err
+	}
+	return deadLetters, nil
+}
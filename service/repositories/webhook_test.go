@@ -0,0 +1,329 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWebhookRepository(t *testing.T) {
+	db, _, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	repo := NewWebhookRepository(db)
+
+	assert.NotNil(t, repo.db)
+}
+
+func TestWebhookCreate(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	webhook := &models.Webhook{
+		ID:      uuid.New(),
+		OwnerID: uuid.New(),
+		URL:     "https://crm.example.com/hooks",
+		Secret:  "secret",
+		Events:  []string{"link.created"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `webhooks`").
+		WithArgs(webhook.ID, webhook.OwnerID, webhook.URL, webhook.Secret, `["link.created"]`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repo := &webhookRepository{db: db}
+	assert.NoError(t, repo.Create(webhook))
+}
+
+func TestWebhookFindByOwnerID(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	ownerID := uuid.New()
+	query := regexp.QuoteMeta("SELECT * FROM `webhooks` WHERE owner_id = ? ORDER BY created_at DESC")
+
+	mock.ExpectQuery(query).
+		WithArgs(ownerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "events"}).
+			AddRow(uuid.New(), ownerID, `["link.created","link.deleted"]`))
+
+	repo := &webhookRepository{db: db}
+	res, err := repo.FindByOwnerID(ownerID)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, []string{"link.created", "link.deleted"}, res[0].Events)
+
+	mock.ExpectQuery(query).WithArgs(ownerID).WillReturnError(errors.New("error"))
+
+	res, err = repo.FindByOwnerID(ownerID)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestWebhookHasSubscribers(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	query := regexp.QuoteMeta("SELECT count(*) FROM `webhooks` WHERE events LIKE ? OR events LIKE ?")
+	events := []string{"link.milestone", "link.expired"}
+	repo := &webhookRepository{db: db}
+
+	mock.ExpectQuery(query).
+		WithArgs(`%"link.milestone"%`, `%"link.expired"%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+	found, err := repo.HasSubscribers(events)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	mock.ExpectQuery(query).
+		WithArgs(`%"link.milestone"%`, `%"link.expired"%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	found, err = repo.HasSubscribers(events)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	mock.ExpectQuery(query).WillReturnError(errors.New("error"))
+	_, err = repo.HasSubscribers(events)
+	assert.Error(t, err)
+}
+
+func TestWebhookDelete(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	webhook := &models.Webhook{ID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `webhook_deliveries` WHERE webhook_id = ?")).
+		WithArgs(webhook.ID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `webhook_dead_letters` WHERE webhook_id = ?")).
+		WithArgs(webhook.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `webhooks` WHERE `webhooks`.`id` = ?")).
+		WithArgs(webhook.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := &webhookRepository{db: db}
+	assert.NoError(t, repo.Delete(webhook))
+}
+
+func TestWebhookEnqueue(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	deliveries := []*models.WebhookDelivery{
+		{ID: uuid.New(), WebhookID: uuid.New(), Event: "link.milestone", Payload: "{}", NextAttemptAt: time.Now()},
+	}
+	keyQuery := regexp.QuoteMeta("INSERT IGNORE INTO `webhook_event_keys`")
+
+	tests := []struct {
+		name           string
+		key            string
+		setup          func(mock sqlmock.Sqlmock)
+		expectedQueued bool
+		expectedErr    bool
+	}{
+		{
+			name: "success without key",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `webhook_deliveries`").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedQueued: true,
+		}, {
+			name: "success with new key",
+			key:  "milestone:foo:100",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(keyQuery).
+					WithArgs("milestone:foo:100", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `webhook_deliveries`").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedQueued: true,
+		}, {
+			name: "repeated key",
+			key:  "milestone:foo:100",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(keyQuery).
+					WithArgs("milestone:foo:100", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			key:  "milestone:foo:100",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(keyQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO `webhook_deliveries`").WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &webhookRepository{db: db}
+			queued, err := repo.Enqueue(tt.key, deliveries)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedQueued, queued)
+		})
+	}
+}
+
+func TestWebhookClaimDeliveries(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	webhook := &models.Webhook{ID: uuid.New(), URL: "https://crm.example.com/hooks", Secret: "secret"}
+	deliveryID := uuid.New()
+	claimQuery := regexp.QuoteMeta("SELECT * FROM `webhook_deliveries` WHERE next_attempt_at <= ? ORDER BY next_attempt_at LIMIT 100 FOR UPDATE SKIP LOCKED")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event"}).AddRow(deliveryID, webhook.ID, "link.created"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `webhook_deliveries` SET `next_attempt_at`=? WHERE id IN (?)")).
+		WithArgs(sqlmock.AnyArg(), deliveryID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `webhooks` WHERE id IN (?)")).
+		WithArgs(webhook.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret"}).AddRow(webhook.ID, webhook.URL, webhook.Secret))
+
+	repo := &webhookRepository{db: db}
+	res, err := repo.ClaimDeliveries(100, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, deliveryID, res[0].ID)
+	assert.Equal(t, webhook.URL, res[0].Webhook.URL)
+	assert.Equal(t, webhook.Secret, res[0].Webhook.Secret)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	res, err = repo.ClaimDeliveries(100, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(claimQuery).WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+
+	res, err = repo.ClaimDeliveries(100, time.Minute)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func TestWebhookRetryDelivery(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	delivery := &models.WebhookDelivery{
+		ID:            uuid.New(),
+		Attempts:      2,
+		LastError:     "unexpected status 500",
+		NextAttemptAt: time.Now().Add(time.Minute),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `webhook_deliveries` SET `attempts`=?,`last_error`=?,`next_attempt_at`=? WHERE `id` = ?")).
+		WithArgs(2, "unexpected status 500", delivery.NextAttemptAt, delivery.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := &webhookRepository{db: db}
+	assert.NoError(t, repo.RetryDelivery(delivery))
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	delivery := &models.WebhookDelivery{
+		ID:        uuid.New(),
+		WebhookID: uuid.New(),
+		Event:     "link.created",
+		Payload:   "{}",
+		Attempts:  10,
+		LastError: "unexpected status 500",
+		CreatedAt: time.Now(),
+	}
+
+	tests := []struct {
+		name        string
+		setup       func(mock sqlmock.Sqlmock)
+		expectedErr bool
+	}{
+		{
+			name: "success",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `webhook_dead_letters`").
+					WithArgs(delivery.ID, delivery.WebhookID, "link.created", "{}", 10, "unexpected status 500", delivery.CreatedAt, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `webhook_deliveries` WHERE `webhook_deliveries`.`id` = ?")).
+					WithArgs(delivery.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		}, {
+			name: "error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `webhook_dead_letters`").WillReturnError(errors.New("error"))
+				mock.ExpectRollback()
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(mock)
+			repo := &webhookRepository{db: db}
+			err := repo.DeadLetter(delivery)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookFindDeadLetters(t *testing.T) {
+	db, mock, closeDB := SetupDatabaseMock(t)
+	defer closeDB()
+
+	webhookID := uuid.New()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `webhook_dead_letters` WHERE webhook_id = ? ORDER BY failed_at DESC LIMIT 100")).
+		WithArgs(webhookID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id"}).AddRow(uuid.New(), webhookID))
+
+	repo := &webhookRepository{db: db}
+	res, err := repo.FindDeadLetters(webhookID, 100)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
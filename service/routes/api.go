@@ -3,11 +3,44 @@ package routes
 import (
 	"time"
 	"url-shortener/handlers"
-	"url-shortener/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func NewAPIRoutes(r fiber.Router, h *handlers.Factory) {
-	r.Post("/links", middleware.Limiter(150, 1*time.Hour), h.ShortLink.CreateShortLink)
+	// Counted per IP before the key is known, so guessing keys is limited too.
+	r.Use(h.Limiter(3000, 1*time.Hour), h.Authenticate)
+
+	r.Get("/links", h.Limiter(1000, 1*time.Hour), h.ShortLink.ListShortLinks)
+	r.Post("/links", h.Limiter(150, 1*time.Hour), h.ShortLink.CreateShortLink)
+	r.Post("/links/bulk", h.Limiter(20, 1*time.Hour), h.ShortLink.BulkCreateShortLinks)
+	r.Post("/links/import", h.Limiter(20, 1*time.Hour), h.ShortLink.ImportShortLinks)
+	r.Get("/links/export", h.Limiter(20, 1*time.Hour), h.ShortLink.ExportShortLinks)
+	r.Get("/links/:slash", h.Limiter(1000, 1*time.Hour), h.ShortLink.FindShortLink)
+	r.Patch("/links/:slash", h.Limiter(150, 1*time.Hour), h.ShortLink.UpdateShortLink)
+	r.Delete("/links/:slash", h.Limiter(150, 1*time.Hour), h.ShortLink.DeleteShortLink)
+	r.Get("/links/:slash/stats", h.Limiter(1000, 1*time.Hour), h.ShortLink.GetStats)
+	r.Get("/links/:slash/qr", h.Limiter(1000, 1*time.Hour), h.ShortLink.GetQRCode)
+	r.Get("/links/:slash/rules", h.Limiter(1000, 1*time.Hour), h.ShortLink.FindRules)
+	r.Put("/links/:slash/rules", h.Limiter(150, 1*time.Hour), h.ShortLink.ReplaceRules)
+	r.Get("/links/:slash/variants", h.Limiter(1000, 1*time.Hour), h.ShortLink.FindVariants)
+	r.Put("/links/:slash/variants", h.Limiter(150, 1*time.Hour), h.ShortLink.ReplaceVariants)
+
+	r.Get("/keys", h.Limiter(1000, 1*time.Hour), h.APIKey.ListAPIKeys)
+	r.Post("/keys", h.Limiter(150, 1*time.Hour), h.APIKey.CreateAPIKey)
+	r.Delete("/keys/:id", h.Limiter(150, 1*time.Hour), h.APIKey.RevokeAPIKey)
+	r.Patch("/keys/:id/rate-limit", h.RequireAdmin, h.Limiter(150, 1*time.Hour), h.APIKey.UpdateRateLimit)
+
+	r.Get("/webhooks", h.Limiter(1000, 1*time.Hour), h.Webhook.ListWebhooks)
+	r.Post("/webhooks", h.Limiter(150, 1*time.Hour), h.Webhook.CreateWebhook)
+	r.Delete("/webhooks/:id", h.Limiter(150, 1*time.Hour), h.Webhook.DeleteWebhook)
+	r.Get("/webhooks/:id/dead-letters", h.Limiter(1000, 1*time.Hour), h.Webhook.ListDeadLetters)
+
+	r.Get("/domains", h.Limiter(1000, 1*time.Hour), h.Domain.ListDomains)
+	r.Post("/domains", h.RequireAdmin, h.Limiter(150, 1*time.Hour), h.Domain.CreateDomain)
+	r.Delete("/domains/:id", h.RequireAdmin, h.Limiter(150, 1*time.Hour), h.Domain.DeleteDomain)
+
+	r.Get("/domain-rules", h.RequireAdmin, h.Limiter(1000, 1*time.Hour), h.DomainRule.ListDomainRules)
+	r.Post("/domain-rules", h.RequireAdmin, h.Limiter(150, 1*time.Hour), h.DomainRule.CreateDomainRule)
+	r.Delete("/domain-rules/:id", h.RequireAdmin, h.Limiter(150, 1*time.Hour), h.DomainRule.DeleteDomainRule)
 }
@@ -3,11 +3,21 @@ package routes
 import (
 	"time"
 	"url-shortener/handlers"
-	"url-shortener/middleware"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// NewMetricsRoutes serves metrics on their own listener, so they aren't
+// reachable through the public port.
+func NewMetricsRoutes(r fiber.Router) {
+	r.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+}
+
 func NewWebRoutes(r fiber.Router, h *handlers.Factory) {
-	r.Get("/:slash", middleware.Limiter(1000, 1*time.Hour), h.ShortLink.Redirect)
+	r.Get("/healthz", h.Health.Liveness)
+	r.Get("/readyz", h.Health.Readiness)
+	r.Get("/:slash", h.NotFoundBudget, h.Limiter(1000, 1*time.Hour), h.ShortLink.Redirect)
+	r.Post("/:slash", h.NotFoundBudget, h.Limiter(60, 1*time.Hour), h.ShortLink.Unlock)
 }
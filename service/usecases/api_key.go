@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"url-shortener/domain"
+	"url-shortener/helpers"
+	"url-shortener/logs"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix       = "sk_"
+	apiKeyLength       = 40
+	apiKeyPrefixLength = 8
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrCreateAPIKey  = errors.New("create api key failed")
+	ErrRevokeAPIKey  = errors.New("revoke api key failed")
+	ErrUpdateAPIKey  = errors.New("update api key failed")
+)
+
+type apiKeyUsecase struct {
+	apiKeyRepo domain.APIKeyRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo domain.APIKeyRepository) *apiKeyUsecase {
+	return &apiKeyUsecase{apiKeyRepo}
+}
+
+func (u *apiKeyUsecase) CreateAPIKey(userID uuid.UUID, req *domain.CreateAPIKeyRequest) (*domain.CreateAPIKeyResponse, error) {
+	random, err := helpers.StrSecureRandom(apiKeyLength)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateAPIKey
+	}
+	key := apiKeyPrefix + random
+
+	apiKey := &models.APIKey{
+		ID:      uuid.New(),
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: hashAPIKey(key),
+
+		RateLimitMultiplier: 1,
+	}
+	if err := u.apiKeyRepo.Create(apiKey); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateAPIKey
+	}
+
+	return &domain.CreateAPIKeyResponse{APIKey: apiKey, Key: key}, nil
+}
+
+func (u *apiKeyUsecase) ListAPIKeys(userID uuid.UUID) ([]*models.APIKey, error) {
+	apiKeys, err := u.apiKeyRepo.FindByUserID(userID)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return apiKeys, nil
+}
+
+func (u *apiKeyUsecase) RevokeAPIKey(userID uuid.UUID, id uuid.UUID) error {
+	apiKey, err := u.apiKeyRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return err
+		}
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+
+	if apiKey.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	if err := u.apiKeyRepo.Revoke(apiKey); err != nil {
+		logs.Error(err.Error())
+		return ErrRevokeAPIKey
+	}
+	return nil
+}
+
+// UpdateRateLimit is for admins, so it isn't limited to the caller's keys.
+func (u *apiKeyUsecase) UpdateRateLimit(id uuid.UUID, req *domain.UpdateRateLimitRequest) (*models.APIKey, error) {
+	apiKey, err := u.apiKeyRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	apiKey.RateLimitMultiplier = req.Multiplier
+	if err := u.apiKeyRepo.UpdateRateLimit(apiKey); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUpdateAPIKey
+	}
+	return apiKey, nil
+}
+
+func (u *apiKeyUsecase) Authenticate(key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := u.apiKeyRepo.FindByHash(hashAPIKey(key))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,320 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewAPIKeyUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+	usecase := NewAPIKeyUsecase(mock)
+
+	assert.NotNil(t, usecase.apiKeyRepo)
+}
+
+func TestAPIKeyCreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	userID := uuid.New()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockAPIKeyRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().Create(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrCreateAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+			usecase := NewAPIKeyUsecase(mock)
+			tt.setup(mock)
+
+			res, err := usecase.CreateAPIKey(userID, &domain.CreateAPIKeyRequest{Name: "default"})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, strings.HasPrefix(res.Key, apiKeyPrefix))
+				assert.Len(t, res.Key, len(apiKeyPrefix)+apiKeyLength)
+				assert.Equal(t, res.Key[:apiKeyPrefixLength], res.Prefix)
+				assert.Equal(t, hashAPIKey(res.Key), res.KeyHash)
+				assert.Equal(t, userID, res.UserID)
+				assert.Equal(t, 1.0, res.RateLimitMultiplier)
+			}
+		})
+	}
+}
+
+func TestAPIKeyListAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	userID := uuid.New()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockAPIKeyRepository)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByUserID(userID).Return([]*models.APIKey{{}, {}}, nil)
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByUserID(userID).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+			usecase := NewAPIKeyUsecase(mock)
+			tt.setup(mock)
+
+			res, err := usecase.ListAPIKeys(userID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, res, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestAPIKeyRevokeAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	userID := uuid.New()
+	keyID := uuid.New()
+	revokedAt := time.Now()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockAPIKeyRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID, UserID: userID}, nil)
+				mr.EXPECT().Revoke(gomock.Any()).Return(nil)
+			},
+		}, {
+			name: "revoked already",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID, UserID: userID, RevokedAt: &revokedAt}, nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "another user",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID, UserID: uuid.New()}, nil)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error FindByID()",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error Revoke()",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID, UserID: userID}, nil)
+				mr.EXPECT().Revoke(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrRevokeAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+			usecase := NewAPIKeyUsecase(mock)
+			tt.setup(mock)
+
+			err := usecase.RevokeAPIKey(userID, keyID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyUpdateRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	keyID := uuid.New()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockAPIKeyRepository)
+		expectedErr error
+	}{
+		{
+			name: "success for another user's key",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID, UserID: uuid.New(), RateLimitMultiplier: 1}, nil)
+				mr.EXPECT().UpdateRateLimit(gomock.Any()).Return(nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error FindByID()",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error UpdateRateLimit()",
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByID(keyID).Return(&models.APIKey{ID: keyID}, nil)
+				mr.EXPECT().UpdateRateLimit(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrUpdateAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+			usecase := NewAPIKeyUsecase(mock)
+			tt.setup(mock)
+
+			apiKey, err := usecase.UpdateRateLimit(keyID, &domain.UpdateRateLimitRequest{Multiplier: 2.5})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, apiKey)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 2.5, apiKey.RateLimitMultiplier)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	key := apiKeyPrefix + "foo"
+	revokedAt := time.Now()
+	tests := []struct {
+		name        string
+		key         string
+		setup       func(mr *mockDomain.MockAPIKeyRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			key:  key,
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByHash(hashAPIKey(key)).Return(&models.APIKey{}, nil)
+			},
+		}, {
+			name:        "invalid prefix",
+			key:         "foo",
+			setup:       func(mr *mockDomain.MockAPIKeyRepository) {},
+			expectedErr: ErrInvalidAPIKey,
+		}, {
+			name: "not found",
+			key:  key,
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByHash(hashAPIKey(key)).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: ErrInvalidAPIKey,
+		}, {
+			name: "revoked",
+			key:  key,
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByHash(hashAPIKey(key)).Return(&models.APIKey{RevokedAt: &revokedAt}, nil)
+			},
+			expectedErr: ErrInvalidAPIKey,
+		}, {
+			name: "error",
+			key:  key,
+			setup: func(mr *mockDomain.MockAPIKeyRepository) {
+				mr.EXPECT().FindByHash(hashAPIKey(key)).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockAPIKeyRepository(ctrl)
+			usecase := NewAPIKeyUsecase(mock)
+			tt.setup(mock)
+
+			apiKey, err := usecase.Authenticate(tt.key)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, apiKey)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, apiKey)
+			}
+		})
+	}
+}
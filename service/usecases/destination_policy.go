@@ -0,0 +1,237 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/helpers"
+	"url-shortener/logs"
+	"url-shortener/models"
+	"url-shortener/utils/refresh"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ruleRefreshInterval = 30 * time.Second
+	ruleRetryInterval   = 5 * time.Second
+)
+
+var (
+	ErrInvalidDestination = errors.New("destination invalid")
+	ErrSchemeNotAllowed   = errors.New("destination scheme is not allowed")
+	ErrPrivateDestination = errors.New("destination must be a public host")
+	ErrDomainBlocked      = errors.New("destination domain is blocked")
+	ErrDomainNotAllowed   = errors.New("destination domain is not on the allowlist")
+	ErrInvalidDomain      = errors.New("invalid domain")
+	ErrDomainRuleExists   = errors.New("domain rule exists already")
+	ErrCreateDomainRule   = errors.New("create domain rule failed")
+	ErrDeleteDomainRule   = errors.New("delete domain rule failed")
+)
+
+type destinationPolicyUsecase struct {
+	domainRuleRepo domain.DomainRuleRepository
+	allowedSchemes map[string]bool
+	allowlistOnly  bool
+	rules          *refresh.Value[map[string]string]
+}
+
+func NewDestinationPolicyUsecase(domainRuleRepo domain.DomainRuleRepository, allowedSchemes []string, allowlistOnly bool) *destinationPolicyUsecase {
+	schemes := make(map[string]bool, len(allowedSchemes))
+	for _, scheme := range allowedSchemes {
+		schemes[strings.ToLower(strings.TrimSpace(scheme))] = true
+	}
+
+	u := &destinationPolicyUsecase{
+		domainRuleRepo: domainRuleRepo,
+		allowedSchemes: schemes,
+		allowlistOnly:  allowlistOnly,
+	}
+	u.rules = refresh.New(u.loadRules, ruleRefreshInterval, ruleRetryInterval)
+	return u
+}
+
+// NewDestinationPolicyFromEnv reads ALLOWED_SCHEMES and DESTINATION_POLICY_MODE
+// and panics on an unknown mode, like the other startup settings.
+func NewDestinationPolicyFromEnv(domainRuleRepo domain.DomainRuleRepository) *destinationPolicyUsecase {
+	schemes := strings.Split(helpers.Getenv("ALLOWED_SCHEMES", "http,https"), ",")
+
+	mode := helpers.Getenv("DESTINATION_POLICY_MODE", "blocklist")
+	if mode != "blocklist" && mode != "allowlist" {
+		panic(fmt.Sprintf("invalid DESTINATION_POLICY_MODE: %v", mode))
+	}
+
+	return NewDestinationPolicyUsecase(domainRuleRepo, schemes, mode == "allowlist")
+}
+
+// CheckDestination is enforced when a destination is created or changed.
+func (u *destinationPolicyUsecase) CheckDestination(destination string) error {
+	dest, err := url.Parse(destination)
+	if err != nil {
+		return ErrInvalidDestination
+	}
+
+	if !u.allowedSchemes[strings.ToLower(dest.Scheme)] {
+		return ErrSchemeNotAllowed
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(dest.Hostname()), ".")
+	if host == "" {
+		return ErrInvalidDestination
+	}
+	if isPrivateHost(host) {
+		return ErrPrivateDestination
+	}
+
+	return u.checkDomain(host)
+}
+
+// IsBlocked also catches links whose domain was blocked after they were
+// created. The rules may be up to ruleRefreshInterval old.
+func (u *destinationPolicyUsecase) IsBlocked(destination string) bool {
+	dest, err := url.Parse(destination)
+	if err != nil {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(dest.Hostname()), ".")
+	return u.checkDomain(host) != nil
+}
+
+func (u *destinationPolicyUsecase) ListRules() ([]*models.DomainRule, error) {
+	rules, err := u.domainRuleRepo.FindAll()
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return rules, nil
+}
+
+func (u *destinationPolicyUsecase) CreateRule(userID uuid.UUID, req *domain.CreateDomainRuleRequest) (*models.DomainRule, error) {
+	ruleDomain := normalizeRuleDomain(req.Domain)
+	if ruleDomain == "" {
+		return nil, ErrInvalidDomain
+	}
+
+	_, err := u.domainRuleRepo.FindByDomain(ruleDomain)
+	if err == nil {
+		return nil, ErrDomainRuleExists
+	} else if err != gorm.ErrRecordNotFound {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	rule := &models.DomainRule{
+		ID:        uuid.New(),
+		Domain:    ruleDomain,
+		Type:      req.Type,
+		Reason:    req.Reason,
+		CreatedBy: userID,
+	}
+	if err := u.domainRuleRepo.Create(rule); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateDomainRule
+	}
+	u.rules.Reload()
+
+	return rule, nil
+}
+
+func (u *destinationPolicyUsecase) DeleteRule(id uuid.UUID) error {
+	rule, err := u.domainRuleRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return err
+		}
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+
+	if err := u.domainRuleRepo.Delete(rule); err != nil {
+		logs.Error(err.Error())
+		return ErrDeleteDomainRule
+	}
+	u.rules.Reload()
+
+	return nil
+}
+
+// checkDomain applies the rule for the most specific matching domain, so an
+// allow rule for a subdomain wins over a block rule for its parent.
+func (u *destinationPolicyUsecase) checkDomain(host string) error {
+	rules := u.rules.Get()
+
+	for name := host; name != ""; name = parentDomain(name) {
+		switch rules[name] {
+		case models.DomainRuleAllow:
+			return nil
+		case models.DomainRuleBlock:
+			return ErrDomainBlocked
+		}
+	}
+
+	if u.allowlistOnly {
+		return ErrDomainNotAllowed
+	}
+	return nil
+}
+
+// loadRules maps each domain to its rule type. Until rules have loaded once,
+// no domain is blocked, and in allowlist mode none is allowed.
+func (u *destinationPolicyUsecase) loadRules() (map[string]string, error) {
+	found, err := u.domainRuleRepo.FindAll()
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, err
+	}
+
+	rules := make(map[string]string, len(found))
+	for _, rule := range found {
+		rules[rule.Domain] = rule.Type
+	}
+	return rules, nil
+}
+
+func normalizeRuleDomain(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if dest, err := url.Parse(value); err == nil && dest.Host != "" {
+		value = dest.Hostname()
+	}
+	value = strings.Trim(strings.TrimPrefix(value, "*."), ".")
+
+	if value == "" || strings.ContainsAny(value, " /:@?#") {
+		return ""
+	}
+	return value
+}
+
+func parentDomain(name string) string {
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// isPrivateHost rejects loopback, private, link-local and unspecified
+// addresses as well as localhost names. Hosts made only of digits and dots
+// are rejected too since browsers read forms like 2130706433 or 0177.0.0.1
+// as IPv4 addresses.
+func isPrivateHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addr = addr.Unmap()
+		return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+			addr.IsLinkLocalMulticast() || addr.IsUnspecified() || addr.IsMulticast() ||
+			(addr.Is4() && addr.As4()[0] == 0)
+	}
+
+	return strings.Trim(host, "0123456789.") == ""
+}
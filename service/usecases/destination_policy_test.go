@@ -0,0 +1,327 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewDestinationPolicyUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+	usecase := NewDestinationPolicyUsecase(mock, []string{"HTTPS ", "http"}, true)
+
+	assert.NotNil(t, usecase.domainRuleRepo)
+	assert.Equal(t, map[string]bool{"https": true, "http": true}, usecase.allowedSchemes)
+	assert.True(t, usecase.allowlistOnly)
+}
+
+func TestDestinationPolicyCheckDestination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	rules := []*models.DomainRule{
+		{Domain: "evil.com", Type: models.DomainRuleBlock},
+		{Domain: "safe.evil.com", Type: models.DomainRuleAllow},
+		{Domain: "example.org", Type: models.DomainRuleAllow},
+	}
+
+	tests := []struct {
+		name          string
+		destination   string
+		allowlistOnly bool
+		expectedErr   error
+	}{
+		{name: "public host", destination: "https://example.com/path"},
+		{name: "http scheme", destination: "http://example.com"},
+		{name: "javascript scheme", destination: "javascript:alert(1)", expectedErr: ErrSchemeNotAllowed},
+		{name: "data scheme", destination: "data:text/html,hello", expectedErr: ErrSchemeNotAllowed},
+		{name: "ftp scheme", destination: "ftp://example.com", expectedErr: ErrSchemeNotAllowed},
+		{name: "missing host", destination: "https:///path", expectedErr: ErrInvalidDestination},
+		{name: "invalid url", destination: "https://exa mple.com:port", expectedErr: ErrInvalidDestination},
+		{name: "localhost", destination: "http://localhost:8080", expectedErr: ErrPrivateDestination},
+		{name: "localhost subdomain", destination: "http://app.localhost", expectedErr: ErrPrivateDestination},
+		{name: "loopback", destination: "http://127.0.0.1/admin", expectedErr: ErrPrivateDestination},
+		{name: "private ipv4", destination: "http://192.168.1.1", expectedErr: ErrPrivateDestination},
+		{name: "link-local metadata", destination: "http://169.254.169.254/latest", expectedErr: ErrPrivateDestination},
+		{name: "zero network", destination: "http://0.0.0.0", expectedErr: ErrPrivateDestination},
+		{name: "ipv6 loopback", destination: "http://[::1]/", expectedErr: ErrPrivateDestination},
+		{name: "ipv4-mapped ipv6", destination: "http://[::ffff:10.0.0.1]/", expectedErr: ErrPrivateDestination},
+		{name: "decimal ipv4", destination: "http://2130706433", expectedErr: ErrPrivateDestination},
+		{name: "public ip", destination: "http://8.8.8.8"},
+		{name: "blocked domain", destination: "https://evil.com", expectedErr: ErrDomainBlocked},
+		{name: "blocked subdomain", destination: "https://www.EVIL.com.", expectedErr: ErrDomainBlocked},
+		{name: "allowed subdomain of blocked domain", destination: "https://safe.evil.com"},
+		{name: "similar domain", destination: "https://notevil.com"},
+		{name: "allowlist match", destination: "https://docs.example.org", allowlistOnly: true},
+		{name: "allowlist miss", destination: "https://example.com", allowlistOnly: true, expectedErr: ErrDomainNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+			mock.EXPECT().FindAll().Return(rules, nil).MaxTimes(1)
+			usecase := NewDestinationPolicyUsecase(mock, []string{"http", "https"}, tt.allowlistOnly)
+
+			err := usecase.CheckDestination(tt.destination)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDestinationPolicyIsBlocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+	mock.EXPECT().FindAll().Return([]*models.DomainRule{{Domain: "evil.com", Type: models.DomainRuleBlock}}, nil).Times(1)
+	usecase := NewDestinationPolicyUsecase(mock, []string{"http", "https"}, false)
+
+	assert.True(t, usecase.IsBlocked("https://evil.com/a"))
+	assert.True(t, usecase.IsBlocked("https://a.evil.com/b"))
+	assert.False(t, usecase.IsBlocked("https://example.com"))
+}
+
+func TestDestinationPolicyReloadRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+	usecase := NewDestinationPolicyUsecase(mock, []string{"http", "https"}, false)
+
+	gomock.InOrder(
+		mock.EXPECT().FindAll().Return([]*models.DomainRule{{Domain: "evil.com", Type: models.DomainRuleBlock}}, nil),
+		mock.EXPECT().FindAll().Return(nil, errors.New("error")),
+	)
+
+	assert.True(t, usecase.IsBlocked("https://evil.com"))
+
+	// A failed reload keeps the rules that were loaded before.
+	assert.Equal(t, models.DomainRuleBlock, usecase.rules.Reload()["evil.com"])
+	assert.True(t, usecase.IsBlocked("https://evil.com"))
+}
+
+func TestDestinationPolicyLoadRulesBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+	mock.EXPECT().FindAll().Return(nil, errors.New("error")).Times(1)
+	usecase := NewDestinationPolicyUsecase(mock, []string{"http", "https"}, false)
+
+	// Redirects during an outage don't each query MySQL again.
+	for i := 0; i < 10; i++ {
+		assert.False(t, usecase.IsBlocked("https://evil.com"))
+	}
+}
+
+func TestDestinationPolicyListRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockDomainRuleRepository)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindAll().Return([]*models.DomainRule{{}, {}}, nil)
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindAll().Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+			usecase := NewDestinationPolicyUsecase(mock, []string{"https"}, false)
+			tt.setup(mock)
+
+			rules, err := usecase.ListRules()
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, rules)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, rules, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestDestinationPolicyCreateRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	userID := uuid.New()
+	tests := []struct {
+		name           string
+		request        *domain.CreateDomainRuleRequest
+		setup          func(mr *mockDomain.MockDomainRuleRepository)
+		expectedDomain string
+		expectedErr    error
+	}{
+		{
+			name:    "success",
+			request: &domain.CreateDomainRuleRequest{Domain: "https://Evil.com/path", Type: models.DomainRuleBlock, Reason: "phishing"},
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByDomain("evil.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(nil)
+				mr.EXPECT().FindAll().Return([]*models.DomainRule{}, nil)
+			},
+			expectedDomain: "evil.com",
+		}, {
+			name:    "wildcard",
+			request: &domain.CreateDomainRuleRequest{Domain: "*.example.com", Type: models.DomainRuleAllow},
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByDomain("example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(nil)
+				mr.EXPECT().FindAll().Return([]*models.DomainRule{}, nil)
+			},
+			expectedDomain: "example.com",
+		}, {
+			name:        "invalid domain",
+			request:     &domain.CreateDomainRuleRequest{Domain: "evil com", Type: models.DomainRuleBlock},
+			setup:       func(mr *mockDomain.MockDomainRuleRepository) {},
+			expectedErr: ErrInvalidDomain,
+		}, {
+			name:    "rule exists",
+			request: &domain.CreateDomainRuleRequest{Domain: "evil.com", Type: models.DomainRuleBlock},
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByDomain("evil.com").Return(&models.DomainRule{}, nil)
+			},
+			expectedErr: ErrDomainRuleExists,
+		}, {
+			name:    "error FindByDomain()",
+			request: &domain.CreateDomainRuleRequest{Domain: "evil.com", Type: models.DomainRuleBlock},
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByDomain("evil.com").Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name:    "error Create()",
+			request: &domain.CreateDomainRuleRequest{Domain: "evil.com", Type: models.DomainRuleBlock},
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByDomain("evil.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrCreateDomainRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+			usecase := NewDestinationPolicyUsecase(mock, []string{"https"}, false)
+			tt.setup(mock)
+
+			rule, err := usecase.CreateRule(userID, tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, rule)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedDomain, rule.Domain)
+				assert.Equal(t, userID, rule.CreatedBy)
+			}
+		})
+	}
+}
+
+func TestDestinationPolicyDeleteRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	rule := &models.DomainRule{ID: uuid.New()}
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockDomainRuleRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByID(rule.ID).Return(rule, nil)
+				mr.EXPECT().Delete(rule).Return(nil)
+				mr.EXPECT().FindAll().Return([]*models.DomainRule{}, nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByID(rule.ID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error FindByID()",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByID(rule.ID).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error Delete()",
+			setup: func(mr *mockDomain.MockDomainRuleRepository) {
+				mr.EXPECT().FindByID(rule.ID).Return(rule, nil)
+				mr.EXPECT().Delete(rule).Return(errors.New("error"))
+			},
+			expectedErr: ErrDeleteDomainRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+			usecase := NewDestinationPolicyUsecase(mock, []string{"https"}, false)
+			tt.setup(mock)
+
+			err := usecase.DeleteRule(rule.ID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
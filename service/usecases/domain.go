@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/logs"
+	"url-shortener/models"
+	"url-shortener/utils/refresh"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	domainRefreshInterval = 30 * time.Second
+	domainRetryInterval   = 5 * time.Second
+)
+
+var (
+	ErrDomainExists        = errors.New("domain exists already")
+	ErrDomainInUse         = errors.New("domain still has short links")
+	ErrDomainNotRegistered = errors.New("domain is not registered")
+	ErrCreateDomain        = errors.New("create domain failed")
+	ErrDeleteDomain        = errors.New("delete domain failed")
+)
+
+type domainUsecase struct {
+	domainRepo domain.DomainRepository
+	names      *refresh.Value[map[string]bool]
+}
+
+func NewDomainUsecase(domainRepo domain.DomainRepository) *domainUsecase {
+	u := &domainUsecase{domainRepo: domainRepo}
+	u.names = refresh.New(u.loadNames, domainRefreshInterval, domainRetryInterval)
+	return u
+}
+
+func (u *domainUsecase) ListDomains() ([]*models.Domain, error) {
+	domains, err := u.domainRepo.FindAll()
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return domains, nil
+}
+
+func (u *domainUsecase) CreateDomain(userID uuid.UUID, req *domain.CreateDomainRequest) (*models.Domain, error) {
+	name := normalizeDomainName(req.Name)
+
+	_, err := u.domainRepo.FindByName(name)
+	if err == nil {
+		return nil, ErrDomainExists
+	} else if err != gorm.ErrRecordNotFound {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	customDomain := &models.Domain{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedBy: userID,
+	}
+	if err := u.domainRepo.Create(customDomain); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateDomain
+	}
+	u.names.Reload()
+
+	return customDomain, nil
+}
+
+// DeleteDomain refuses domains that still have links, since those links
+// would otherwise start answering on the default domain.
+func (u *domainUsecase) DeleteDomain(id uuid.UUID) error {
+	customDomain, err := u.domainRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return err
+		}
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+
+	count, err := u.domainRepo.CountShortLinks(customDomain.Name)
+	if err != nil {
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+	if count > 0 {
+		return ErrDomainInUse
+	}
+
+	if err := u.domainRepo.Delete(customDomain); err != nil {
+		logs.Error(err.Error())
+		return ErrDeleteDomain
+	}
+	u.names.Reload()
+
+	return nil
+}
+
+// IsRegistered picks which domain's links a request's host serves. A domain
+// added on another replica is picked up within domainRefreshInterval.
+func (u *domainUsecase) IsRegistered(name string) bool {
+	if name == "" {
+		return false
+	}
+	return u.names.Get()[normalizeDomainName(name)]
+}
+
+// loadNames leaves every host on the default domain until the names have
+// loaded once.
+func (u *domainUsecase) loadNames() (map[string]bool, error) {
+	found, err := u.domainRepo.FindAll()
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(found))
+	for _, customDomain := range found {
+		names[customDomain.Name] = true
+	}
+	return names, nil
+}
+
+func normalizeDomainName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
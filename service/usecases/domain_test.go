@@ -0,0 +1,247 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewDomainUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockDomainRepository(ctrl)
+	usecase := NewDomainUsecase(mock)
+
+	assert.NotNil(t, usecase.domainRepo)
+}
+
+func TestDomainIsRegistered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockDomainRepository(ctrl)
+	usecase := NewDomainUsecase(mock)
+
+	gomock.InOrder(
+		mock.EXPECT().FindAll().Return([]*models.Domain{{Name: "go.example.com"}}, nil),
+		mock.EXPECT().FindAll().Return(nil, errors.New("error")),
+	)
+
+	assert.True(t, usecase.IsRegistered("go.example.com"))
+	assert.True(t, usecase.IsRegistered("GO.example.com."))
+	assert.False(t, usecase.IsRegistered("example.com"))
+	assert.False(t, usecase.IsRegistered(""))
+
+	// A failed reload keeps the names that were loaded before.
+	assert.True(t, usecase.names.Reload()["go.example.com"])
+	assert.True(t, usecase.IsRegistered("go.example.com"))
+}
+
+func TestDomainLoadNamesBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockDomainRepository(ctrl)
+	mock.EXPECT().FindAll().Return(nil, errors.New("error")).Times(1)
+	usecase := NewDomainUsecase(mock)
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, usecase.IsRegistered("go.example.com"))
+	}
+}
+
+func TestDomainListDomains(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockDomainRepository)
+		expectedLen int
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindAll().Return([]*models.Domain{{}, {}}, nil)
+			},
+			expectedLen: 2,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindAll().Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRepository(ctrl)
+			usecase := NewDomainUsecase(mock)
+			tt.setup(mock)
+
+			domains, err := usecase.ListDomains()
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, domains)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, domains, tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestDomainCreateDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	userID := uuid.New()
+	request := &domain.CreateDomainRequest{Name: "Go.Example.com"}
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockDomainRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByName("go.example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(nil)
+				mr.EXPECT().FindAll().Return([]*models.Domain{}, nil)
+			},
+		}, {
+			name: "domain exists",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByName("go.example.com").Return(&models.Domain{}, nil)
+			},
+			expectedErr: ErrDomainExists,
+		}, {
+			name: "error FindByName()",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByName("go.example.com").Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error Create()",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByName("go.example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrCreateDomain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRepository(ctrl)
+			usecase := NewDomainUsecase(mock)
+			tt.setup(mock)
+
+			customDomain, err := usecase.CreateDomain(userID, request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, customDomain)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "go.example.com", customDomain.Name)
+				assert.Equal(t, userID, customDomain.CreatedBy)
+			}
+		})
+	}
+}
+
+func TestDomainDeleteDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	customDomain := &models.Domain{ID: uuid.New(), Name: "go.example.com"}
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockDomainRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(customDomain, nil)
+				mr.EXPECT().CountShortLinks(customDomain.Name).Return(int64(0), nil)
+				mr.EXPECT().Delete(customDomain).Return(nil)
+				mr.EXPECT().FindAll().Return([]*models.Domain{}, nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "in use",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(customDomain, nil)
+				mr.EXPECT().CountShortLinks(customDomain.Name).Return(int64(2), nil)
+			},
+			expectedErr: ErrDomainInUse,
+		}, {
+			name: "error FindByID()",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error CountShortLinks()",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(customDomain, nil)
+				mr.EXPECT().CountShortLinks(customDomain.Name).Return(int64(0), errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error Delete()",
+			setup: func(mr *mockDomain.MockDomainRepository) {
+				mr.EXPECT().FindByID(customDomain.ID).Return(customDomain, nil)
+				mr.EXPECT().CountShortLinks(customDomain.Name).Return(int64(0), nil)
+				mr.EXPECT().Delete(customDomain).Return(errors.New("error"))
+			},
+			expectedErr: ErrDeleteDomain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockDomainRepository(ctrl)
+			usecase := NewDomainUsecase(mock)
+			tt.setup(mock)
+
+			err := usecase.DeleteDomain(customDomain.ID)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/domain"
+)
+
+const readinessTimeout = 2 * time.Second
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusDegraded     = "degraded"
+	HealthStatusUnavailable  = "unavailable"
+	HealthStatusShuttingDown = "shutting_down"
+	DependencyStatusUp       = "up"
+	DependencyStatusDown     = "down"
+)
+
+type healthUsecase struct {
+	healthRepo   domain.HealthRepository
+	shuttingDown atomic.Bool
+}
+
+func NewHealthUsecase(healthRepo domain.HealthRepository) *healthUsecase {
+	return &healthUsecase{healthRepo: healthRepo}
+}
+
+// Readiness pings every dependency at once, each bounded by
+// readinessTimeout. Redirects are served without Redis, so losing it only
+// degrades the service.
+func (u *healthUsecase) Readiness() *domain.Readiness {
+	if u.shuttingDown.Load() {
+		return &domain.Readiness{Status: HealthStatusShuttingDown}
+	}
+
+	pings := map[string]func(ctx context.Context) error{
+		"database": u.healthRepo.PingDatabase,
+		"redis":    u.healthRepo.PingRedis,
+	}
+	optional := map[string]bool{"redis": true}
+
+	readiness := &domain.Readiness{
+		Status: HealthStatusOK,
+		Checks: make(map[string]*domain.DependencyStatus, len(pings)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, ping := range pings {
+		wg.Add(1)
+		go func(name string, ping func(ctx context.Context) error) {
+			defer wg.Done()
+			status := checkDependency(name, ping)
+
+			mu.Lock()
+			defer mu.Unlock()
+			readiness.Checks[name] = status
+			if status.Status == DependencyStatusUp {
+				return
+			}
+			if !optional[name] {
+				readiness.Status = HealthStatusUnavailable
+			} else if readiness.Status == HealthStatusOK {
+				readiness.Status = HealthStatusDegraded
+			}
+		}(name, ping)
+	}
+	wg.Wait()
+
+	return readiness
+}
+
+// Shutdown makes readiness fail from now on, so traffic is routed elsewhere
+// while in-flight requests finish.
+func (u *healthUsecase) Shutdown() {
+	u.shuttingDown.Store(true)
+}
+
+// checkDependency only logs why a dependency is down, since readiness is
+// public and the error can name internal hosts.
+func checkDependency(name string, ping func(ctx context.Context) error) *domain.DependencyStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(ctx)
+	status := &domain.DependencyStatus{
+		Status:    DependencyStatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		status.Status = DependencyStatusDown
+		logCacheError(fmt.Errorf("%v is down: %w", name, err))
+	}
+	return status
+}
@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	mockDomain "url-shortener/domain/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewHealthUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockHealthRepository(ctrl)
+	usecase := NewHealthUsecase(mock)
+
+	assert.NotNil(t, usecase.healthRepo)
+}
+
+func TestHealthReadiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	tests := []struct {
+		name           string
+		setup          func(mr *mockDomain.MockHealthRepository)
+		shutdown       bool
+		expectedStatus string
+		expectedChecks map[string]string
+	}{
+		{
+			name: "ready",
+			setup: func(mr *mockDomain.MockHealthRepository) {
+				mr.EXPECT().PingDatabase(gomock.Any()).Return(nil)
+				mr.EXPECT().PingRedis(gomock.Any()).Return(nil)
+			},
+			expectedStatus: HealthStatusOK,
+			expectedChecks: map[string]string{"database": DependencyStatusUp, "redis": DependencyStatusUp},
+		}, {
+			name: "redis down",
+			setup: func(mr *mockDomain.MockHealthRepository) {
+				mr.EXPECT().PingDatabase(gomock.Any()).Return(nil)
+				mr.EXPECT().PingRedis(gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedStatus: HealthStatusDegraded,
+			expectedChecks: map[string]string{"database": DependencyStatusUp, "redis": DependencyStatusDown},
+		}, {
+			name: "database down",
+			setup: func(mr *mockDomain.MockHealthRepository) {
+				mr.EXPECT().PingDatabase(gomock.Any()).Return(errors.New("connection refused"))
+				mr.EXPECT().PingRedis(gomock.Any()).Return(nil)
+			},
+			expectedStatus: HealthStatusUnavailable,
+			expectedChecks: map[string]string{"database": DependencyStatusDown, "redis": DependencyStatusUp},
+		}, {
+			name: "both down",
+			setup: func(mr *mockDomain.MockHealthRepository) {
+				mr.EXPECT().PingDatabase(gomock.Any()).Return(errors.New("connection refused"))
+				mr.EXPECT().PingRedis(gomock.Any()).Return(errors.New("connection refused"))
+			},
+			expectedStatus: HealthStatusUnavailable,
+			expectedChecks: map[string]string{"database": DependencyStatusDown, "redis": DependencyStatusDown},
+		}, {
+			name:           "shutting down",
+			setup:          func(mr *mockDomain.MockHealthRepository) {},
+			shutdown:       true,
+			expectedStatus: HealthStatusShuttingDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockHealthRepository(ctrl)
+			usecase := NewHealthUsecase(mock)
+			tt.setup(mock)
+			if tt.shutdown {
+				usecase.Shutdown()
+			}
+
+			readiness := usecase.Readiness()
+			assert.Equal(t, tt.expectedStatus, readiness.Status)
+			assert.Len(t, readiness.Checks, len(tt.expectedChecks))
+			for name, status := range tt.expectedChecks {
+				assert.Equal(t, status, readiness.Checks[name].Status)
+			}
+		})
+	}
+}
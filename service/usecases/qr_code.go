@@ -0,0 +1,213 @@
+package usecases
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/logs"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	qrCodeExpiration    = 24 * time.Hour
+	defaultQRCodeSize   = 256
+	defaultQRCodeMargin = 4
+)
+
+var (
+	ErrInvalidQRCodeFormat = errors.New("format must be png or svg")
+	ErrInvalidColor        = errors.New("color must be 6 hex digits")
+	ErrRenderQRCode        = errors.New("render qr code failed")
+)
+
+var qrCodeLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+type qrCodeOptions struct {
+	format     string
+	size       int
+	level      string
+	margin     int
+	foreground string
+	background string
+}
+
+type qrCodeUsecase struct {
+	qrCodeRepo domain.QRCodeRepository
+}
+
+func NewQRCodeUsecase(qrCodeRepo domain.QRCodeRepository) *qrCodeUsecase {
+	return &qrCodeUsecase{qrCodeRepo}
+}
+
+// Render draws content as a QR code. Images are cached by content and
+// options, which never change for a link, so each look is drawn once.
+func (u *qrCodeUsecase) Render(content string, req *domain.QRCodeRequest) (*domain.QRCode, error) {
+	opts, err := newQRCodeOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "image/png"
+	if opts.format == "svg" {
+		contentType = "image/svg+xml"
+	}
+
+	key := opts.cacheKey(content)
+	image, err := u.qrCodeRepo.FindQRCode(key)
+	if err == nil {
+		return &domain.QRCode{ContentType: contentType, Image: image}, nil
+	}
+	if err != redis.Nil {
+		logCacheError(err)
+	}
+
+	image, err = renderQRCode(content, opts)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrRenderQRCode
+	}
+
+	if err := u.qrCodeRepo.SetQRCode(key, image, qrCodeExpiration); err != nil {
+		logCacheError(err)
+	}
+
+	return &domain.QRCode{ContentType: contentType, Image: image}, nil
+}
+
+func newQRCodeOptions(req *domain.QRCodeRequest) (*qrCodeOptions, error) {
+	opts := &qrCodeOptions{
+		format:     strings.ToLower(req.Format),
+		size:       req.Size,
+		level:      strings.ToUpper(req.Level),
+		margin:     defaultQRCodeMargin,
+		foreground: strings.ToLower(req.Foreground),
+		background: strings.ToLower(req.Background),
+	}
+
+	switch opts.format {
+	case "":
+		opts.format = "png"
+	case "png", "svg":
+	default:
+		return nil, ErrInvalidQRCodeFormat
+	}
+	if opts.size <= 0 {
+		opts.size = defaultQRCodeSize
+	}
+	if _, ok := qrCodeLevels[opts.level]; !ok {
+		opts.level = "M"
+	}
+	if req.Margin != nil && *req.Margin >= 0 {
+		opts.margin = *req.Margin
+	}
+	if opts.foreground == "" {
+		opts.foreground = "000000"
+	}
+	if opts.background == "" {
+		opts.background = "ffffff"
+	}
+	if !isHexColor(opts.foreground) || !isHexColor(opts.background) {
+		return nil, ErrInvalidColor
+	}
+
+	return opts, nil
+}
+
+func (o *qrCodeOptions) cacheKey(content string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v|%v|%v|%v|%v|%v|%v",
+		o.format, o.size, o.level, o.margin, o.foreground, o.background, content)))
+	return hex.EncodeToString(sum[:])
+}
+
+func renderQRCode(content string, opts *qrCodeOptions) ([]byte, error) {
+	q, err := qrcode.New(content, qrCodeLevels[opts.level])
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+	bitmap := q.Bitmap()
+
+	if opts.format == "svg" {
+		return qrCodeSVG(bitmap, opts), nil
+	}
+	return qrCodePNG(bitmap, opts)
+}
+
+// qrCodePNG scales modules by whole pixels so they stay sharp, and centers
+// the code when the size isn't a multiple of the module count.
+func qrCodePNG(bitmap [][]bool, opts *qrCodeOptions) ([]byte, error) {
+	modules := len(bitmap) + 2*opts.margin
+	size := max(opts.size, modules)
+	scale := size / modules
+	offset := (size-scale*modules)/2 + scale*opts.margin
+
+	palette := color.Palette{hexColor(opts.background), hexColor(opts.foreground)}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func qrCodeSVG(bitmap [][]bool, opts *qrCodeOptions) []byte {
+	modules := len(bitmap) + 2*opts.margin
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.size, opts.size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#%v"/>`, modules, modules, opts.background)
+	fmt.Fprintf(&buf, `<path fill="#%v" d="`, opts.foreground)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+opts.margin, y+opts.margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
+
+func isHexColor(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 16, 32)
+	return err == nil
+}
+
+func hexColor(s string) color.RGBA {
+	v, _ := strconv.ParseUint(s, 16, 32)
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
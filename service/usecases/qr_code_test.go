@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewQRCodeUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockQRCodeRepository(ctrl)
+	usecase := NewQRCodeUsecase(mock)
+
+	assert.NotNil(t, usecase.qrCodeRepo)
+}
+
+func TestQRCodeRender(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	content := "https://example.com/foo"
+	margin := 0
+
+	tests := []struct {
+		name         string
+		request      *domain.QRCodeRequest
+		setup        func(mr *mockDomain.MockQRCodeRepository)
+		expectedType string
+		expectedErr  error
+	}{
+		{
+			name:    "png",
+			request: &domain.QRCodeRequest{},
+			setup: func(mr *mockDomain.MockQRCodeRepository) {
+				mr.EXPECT().FindQRCode(gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().SetQRCode(gomock.Any(), gomock.Any(), qrCodeExpiration).Return(nil)
+			},
+			expectedType: "image/png",
+		}, {
+			name:    "svg",
+			request: &domain.QRCodeRequest{Format: "svg", Level: "H", Margin: &margin, Foreground: "112233"},
+			setup: func(mr *mockDomain.MockQRCodeRepository) {
+				mr.EXPECT().FindQRCode(gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().SetQRCode(gomock.Any(), gomock.Any(), qrCodeExpiration).Return(nil)
+			},
+			expectedType: "image/svg+xml",
+		}, {
+			name:    "cached",
+			request: &domain.QRCodeRequest{},
+			setup: func(mr *mockDomain.MockQRCodeRepository) {
+				mr.EXPECT().FindQRCode(gomock.Any()).Return([]byte("png"), nil)
+			},
+			expectedType: "image/png",
+		}, {
+			name:    "cache errors are ignored",
+			request: &domain.QRCodeRequest{},
+			setup: func(mr *mockDomain.MockQRCodeRepository) {
+				mr.EXPECT().FindQRCode(gomock.Any()).Return(nil, errors.New("error"))
+				mr.EXPECT().SetQRCode(gomock.Any(), gomock.Any(), qrCodeExpiration).Return(errors.New("error"))
+			},
+			expectedType: "image/png",
+		}, {
+			name:        "invalid format",
+			request:     &domain.QRCodeRequest{Format: "gif"},
+			setup:       func(mr *mockDomain.MockQRCodeRepository) {},
+			expectedErr: ErrInvalidQRCodeFormat,
+		}, {
+			name:        "invalid color",
+			request:     &domain.QRCodeRequest{Background: "0x1234"},
+			setup:       func(mr *mockDomain.MockQRCodeRepository) {},
+			expectedErr: ErrInvalidColor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockQRCodeRepository(ctrl)
+			usecase := NewQRCodeUsecase(mock)
+			tt.setup(mock)
+
+			qr, err := usecase.Render(content, tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, qr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedType, qr.ContentType)
+				assert.NotEmpty(t, qr.Image)
+			}
+		})
+	}
+}
+
+func TestQRCodeCacheKey(t *testing.T) {
+	opts, err := newQRCodeOptions(&domain.QRCodeRequest{})
+	assert.NoError(t, err)
+
+	other, err := newQRCodeOptions(&domain.QRCodeRequest{Format: "PNG", Size: defaultQRCodeSize, Level: "m", Foreground: "000000"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, opts.cacheKey("https://example.com/foo"), other.cacheKey("https://example.com/foo"))
+	assert.NotEqual(t, opts.cacheKey("https://example.com/foo"), opts.cacheKey("https://example.com/bar"))
+
+	other.background = "eeeeee"
+	assert.NotEqual(t, opts.cacheKey("https://example.com/foo"), other.cacheKey("https://example.com/foo"))
+}
+
+func TestRenderQRCode(t *testing.T) {
+	opts := &qrCodeOptions{format: "png", size: 300, level: "M", margin: 4, foreground: "112233", background: "ffeedd"}
+
+	image, err := renderQRCode("https://example.com/foo", opts)
+	assert.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(image))
+	assert.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, color.RGBA{0xff, 0xee, 0xdd, 0xff}, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xff})
+
+	opts.format = "svg"
+	image, err = renderQRCode("https://example.com/foo", opts)
+	assert.NoError(t, err)
+	svg := string(image)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `width="300"`)
+	assert.Contains(t, svg, `fill="#112233"`)
+	assert.Contains(t, svg, `fill="#ffeedd"`)
+
+	_, err = renderQRCode(strings.Repeat("a", 8000), opts)
+	assert.Error(t, err)
+}
@@ -1,63 +1,188 @@
 package usecases
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"url-shortener/domain"
-	"url-shortener/helpers"
 	"url-shortener/logs"
+	"url-shortener/metrics"
 	"url-shortener/models"
+	"url-shortener/utils/breaker"
+	"url-shortener/utils/lru"
+	"url-shortener/utils/slashcode"
+	"url-shortener/utils/useragent"
 
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 const (
-	maxAttempts = 3
-	slashLength = 6
+	maxAttempts      = 3
+	maxBatchAttempts = 20
+	cacheDuration    = 3 * time.Hour
+	defaultListLimit = 20
+	clickBatchSize   = 500
+	defaultStatsDays = 30
+	flushLease       = time.Minute
+	maxFailedUnlocks = 5
+	unlockWindow     = 15 * time.Minute
+	localUnlocksSize = 10000
+
+	notFoundCacheDuration = 30 * time.Second
+	filterMaxAge          = time.Hour
+	expiredLookback       = time.Hour
+)
+
+// Passthrough modes decide whether the query of the short URL is forwarded to
+// the destination, and which side wins when both set a parameter.
+const (
+	PassthroughOff     = "off"
+	PassthroughLink    = "link"
+	PassthroughRequest = "request"
 )
 
 var (
 	ErrUnexpected        = errors.New("unexpected error")
 	ErrCreateShortLink   = errors.New("create short link failed")
+	ErrUpdateShortLink   = errors.New("update short link failed")
+	ErrDeleteShortLink   = errors.New("delete short link failed")
 	ErrGenerateSlashCode = errors.New("generate slash code failed")
 	ErrSlashCodeExists   = errors.New("slash code exists already")
+	ErrShortLinkExpired  = errors.New("short link has expired")
+	ErrShortLinkBlocked  = errors.New("short link destination is blocked")
+	ErrPasswordRequired  = errors.New("short link is password protected")
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrTooManyAttempts   = errors.New("too many failed attempts, try again later")
+	ErrNotOwner          = errors.New("short link belongs to another user")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrInvalidFilter     = errors.New("invalid filter")
+	ErrInvalidTimeWindow = errors.New("ends_at must be after starts_at")
+
+	ErrDestinationRequired = errors.New("destination is required")
+	ErrDestinationInvalid  = errors.New("destination invalid")
 )
 
+// reservedSlashCodes are paths served at the root by the service itself, or
+// under /api/links where they would shadow a link. Routes aren't case
+// sensitive, so neither is the check.
+var reservedSlashCodes = map[string]bool{
+	"api":     true,
+	"healthz": true,
+	"readyz":  true,
+	"metrics": true,
+	"export":  true,
+}
+
+type listCursor struct {
+	Sort  string `json:"sort"`
+	Desc  bool   `json:"desc"`
+	Value domain.ShortLinkCursor
+}
+
 type visitorQueue struct {
 	isRunning bool
-	order     []string
 	counts    map[string]int
+	clicks    []*models.Click
 	mu        sync.Mutex
+	flushMu   sync.Mutex
+}
+
+type slashCodeGenerator struct {
+	generator  domain.SlashCodeGenerator
+	length     atomic.Int32
+	collisions atomic.Int32
 }
 
 type shortLinkUsecase struct {
 	shortLinkRepo domain.ShortLinkRepository
+	clickRepo     domain.ClickRepository
+	policy        domain.DestinationPolicyUsecase
+	domains       domain.DomainUsecase
+	geo           domain.GeoLocator
+	webhooks      domain.WebhookUsecase
 	visitorQueue  *visitorQueue
+	slashCodes    *slashCodeGenerator
+	localUnlocks  *lru.Cache[uuid.UUID, int]
+	filterMu      sync.Mutex
+	expiredMu     sync.Mutex
+	expiredSince  time.Time
+}
+
+// ShortLinkUsecaseOptions are the dependencies of the short link usecase.
+// Geo and Webhooks may be left nil to turn those features off, and slash
+// codes default to random ones of slashcode.DefaultLength.
+type ShortLinkUsecaseOptions struct {
+	ShortLinkRepo domain.ShortLinkRepository
+	ClickRepo     domain.ClickRepository
+	Policy        domain.DestinationPolicyUsecase
+	Domains       domain.DomainUsecase
+	Geo           domain.GeoLocator
+	Webhooks      domain.WebhookUsecase
+	Generator     domain.SlashCodeGenerator
+	SlashLength   int
 }
 
-func NewShortLinkUsecase(shortLinkRepo domain.ShortLinkRepository) *shortLinkUsecase {
+func NewShortLinkUsecase(opts ShortLinkUsecaseOptions) *shortLinkUsecase {
 	visitorQueue := &visitorQueue{
 		counts: make(map[string]int),
 	}
+	if opts.Generator == nil {
+		opts.Generator = slashcode.NewRandom(slashcode.Base62Alphabet)
+	}
+	if opts.SlashLength == 0 {
+		opts.SlashLength = slashcode.DefaultLength
+	}
+	slashCodes := &slashCodeGenerator{generator: opts.Generator}
+	slashCodes.length.Store(int32(opts.SlashLength))
 
-	return &shortLinkUsecase{shortLinkRepo, visitorQueue}
+	return &shortLinkUsecase{
+		shortLinkRepo: opts.ShortLinkRepo,
+		clickRepo:     opts.ClickRepo,
+		policy:        opts.Policy,
+		domains:       opts.Domains,
+		geo:           opts.Geo,
+		webhooks:      opts.Webhooks,
+		visitorQueue:  visitorQueue,
+		slashCodes:    slashCodes,
+		localUnlocks:  lru.New[uuid.UUID, int](localUnlocksSize),
+	}
 }
 
-func (u *shortLinkUsecase) CreateShortLink(req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
-	shortLink := &models.ShortLink{
-		ID:          uuid.New(),
-		Destination: req.Destination,
+func (u *shortLinkUsecase) CreateShortLink(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+	if err := u.policy.CheckDestination(req.Destination); err != nil {
+		return nil, err
+	}
+	if err := u.checkDomain(req.Domain); err != nil {
+		return nil, err
+	}
+
+	shortLink, err := newShortLink(ownerID, req)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateShortLink
 	}
 
 	if req.SlashCode == "" {
-		shortLink.SlashCode = u.generateSlashCode()
+		shortLink.SlashCode = u.generateSlashCode(shortLink.Domain)
 		if shortLink.SlashCode == "" {
 			return nil, ErrGenerateSlashCode
 		}
 	} else {
-		err := u.checkSlashCodeExist(req.SlashCode)
+		err := u.checkSlashCodeExist(shortLink.Domain, req.SlashCode)
 		if err != nil {
 			return nil, err
 		}
@@ -68,12 +193,136 @@ func (u *shortLinkUsecase) CreateShortLink(req *domain.CreateShortLinkRequest) (
 		logs.Error(err.Error())
 		return nil, ErrCreateShortLink
 	}
+	u.announceShortLinks([]*models.ShortLink{shortLink})
+	u.publish(EventLinkCreated, shortLink)
 
 	return shortLink, nil
 }
 
-func (u *shortLinkUsecase) FindBySlashCode(slashCode string) (*models.ShortLink, error) {
-	shortLink, err := u.shortLinkRepo.FindBySlashCode(slashCode)
+// CreateShortLinks creates many links at once. Slash codes for the whole batch
+// are checked with one query per domain and attempt, and the links are
+// inserted in a single transaction. Failures are reported per item.
+func (u *shortLinkUsecase) CreateShortLinks(ownerID uuid.UUID, reqs []*domain.CreateShortLinkRequest) ([]*domain.BulkCreateResult, error) {
+	results := make([]*domain.BulkCreateResult, len(reqs))
+	shortLinks := make([]*models.ShortLink, len(reqs))
+	taken := make(map[string]bool)
+
+	var check, generate []int
+	for i, req := range reqs {
+		results[i] = &domain.BulkCreateResult{Index: i}
+
+		if err := u.policy.CheckDestination(req.Destination); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if err := u.checkDomain(req.Domain); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		shortLink, err := newShortLink(ownerID, req)
+		if err != nil {
+			logs.Error(err.Error())
+			results[i].Error = ErrCreateShortLink.Error()
+			continue
+		}
+		shortLinks[i] = shortLink
+
+		if req.SlashCode == "" {
+			generate = append(generate, i)
+			continue
+		}
+		key := shortLink.Domain + "/" + req.SlashCode
+		if taken[key] || isReservedSlashCode(req.SlashCode) {
+			results[i].Error = ErrSlashCodeExists.Error()
+			continue
+		}
+		taken[key] = true
+		shortLinks[i].SlashCode = req.SlashCode
+		check = append(check, i)
+	}
+
+	for attempt := 0; attempt < maxAttempts && len(check)+len(generate) > 0; attempt++ {
+		for _, i := range generate {
+			slashCode, err := u.uniqueSlashCode(shortLinks[i].Domain, taken)
+			if err != nil {
+				logs.Error(err.Error())
+				results[i].Error = ErrGenerateSlashCode.Error()
+				continue
+			}
+			shortLinks[i].SlashCode = slashCode
+			check = append(check, i)
+		}
+
+		codes := make(map[string][]string)
+		for _, i := range check {
+			host := shortLinks[i].Domain
+			codes[host] = append(codes[host], shortLinks[i].SlashCode)
+		}
+
+		exists := make(map[string]bool)
+		for host, hostCodes := range codes {
+			existing, err := u.shortLinkRepo.FindExistingSlashCodes(host, hostCodes)
+			if err != nil {
+				logs.Error(err.Error())
+				return nil, ErrUnexpected
+			}
+			for _, code := range existing {
+				exists[host+"/"+code] = true
+			}
+		}
+
+		generate = nil
+		for _, i := range check {
+			if !exists[shortLinks[i].Domain+"/"+shortLinks[i].SlashCode] {
+				continue
+			}
+			if reqs[i].SlashCode != "" {
+				results[i].Error = ErrSlashCodeExists.Error()
+			} else {
+				u.slashCodes.collided()
+				generate = append(generate, i)
+			}
+		}
+		check = nil
+	}
+
+	for _, i := range generate {
+		results[i].Error = ErrGenerateSlashCode.Error()
+	}
+
+	var creates []*models.ShortLink
+	for i, result := range results {
+		if result.Error == "" {
+			creates = append(creates, shortLinks[i])
+		}
+	}
+	if len(creates) == 0 {
+		return results, nil
+	}
+
+	createErr := u.shortLinkRepo.CreateBatch(creates)
+	if createErr != nil {
+		logs.Error(createErr.Error())
+	} else {
+		u.announceShortLinks(creates)
+		u.publish(EventLinkCreated, creates...)
+	}
+	for i, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		if createErr != nil {
+			result.Error = ErrCreateShortLink.Error()
+		} else {
+			result.Data = shortLinks[i]
+		}
+	}
+
+	return results, nil
+}
+
+func (u *shortLinkUsecase) FindBySlashCode(ownerID uuid.UUID, host string, slashCode string) (*models.ShortLink, error) {
+	shortLink, err := u.shortLinkRepo.FindBySlashCode(normalizeDomainName(host), slashCode)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, err
@@ -82,96 +331,1226 @@ func (u *shortLinkUsecase) FindBySlashCode(slashCode string) (*models.ShortLink,
 		return nil, ErrUnexpected
 	}
 
+	if shortLink.OwnerID == nil || *shortLink.OwnerID != ownerID {
+		return nil, ErrNotOwner
+	}
+
 	return shortLink, nil
 }
 
-func (u *shortLinkUsecase) Redirect(slashCode string) (string, error) {
-	dest, err := u.shortLinkRepo.FindShortLinkCache(slashCode)
-	if err == nil {
-		go u.incrementVisitorEnqueue(slashCode)
-		return dest, nil
+func (u *shortLinkUsecase) ListShortLinks(ownerID uuid.UUID, req *domain.ListShortLinksRequest) (*domain.ShortLinkPage, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+
+	filter := &domain.ShortLinkFilter{
+		OwnerID: ownerID,
+		Host:    strings.ToLower(req.Host),
+		Sort:    req.Sort,
+		Desc:    req.Order != "asc",
+		Limit:   limit + 1,
+	}
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeFilter(req.CreatedFrom); err != nil {
+		return nil, ErrInvalidFilter
+	}
+	if filter.CreatedTo, err = parseTimeFilter(req.CreatedTo); err != nil {
+		return nil, ErrInvalidFilter
+	}
+
+	if req.Cursor != "" {
+		filter.After, err = decodeCursor(req.Cursor, filter)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
 	}
 
-	shortLink, err := u.shortLinkRepo.FindBySlashCode(slashCode)
+	shortLinks, err := u.shortLinkRepo.List(filter)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return "", err
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	page := &domain.ShortLinkPage{Data: shortLinks}
+	if len(shortLinks) > limit {
+		page.Data = shortLinks[:limit]
+		page.NextCursor = encodeCursor(filter, page.Data[limit-1])
+	}
+
+	return page, nil
+}
+
+func (u *shortLinkUsecase) UpdateShortLink(ownerID uuid.UUID, host string, slashCode string, req *domain.UpdateShortLinkRequest) (*models.ShortLink, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Destination != "" {
+		if err := u.policy.CheckDestination(req.Destination); err != nil {
+			return nil, err
 		}
+		shortLink.Destination = req.Destination
+		shortLink.DestinationHost = destinationHost(req.Destination)
+	}
+	if req.RedirectType != 0 {
+		shortLink.RedirectType = req.RedirectType
+	}
+	if req.UTM != nil {
+		shortLink.UTM = newUTM(req.UTM)
+	}
+	if req.OG != nil {
+		shortLink.OG = newOpenGraph(req.OG)
+	}
+	if req.Passthrough != "" {
+		shortLink.Passthrough = req.Passthrough
+	}
+	if err := u.shortLinkRepo.Update(shortLink); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUpdateShortLink
+	}
+	u.deleteShortLinkCache(shortLink.Domain, slashCode)
+	u.publish(EventLinkUpdated, shortLink)
+
+	return shortLink, nil
+}
+
+func (u *shortLinkUsecase) DeleteShortLink(ownerID uuid.UUID, host string, slashCode string) error {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return err
+	}
+
+	if err := u.shortLinkRepo.Delete(shortLink); err != nil {
 		logs.Error(err.Error())
-		return "", ErrUnexpected
+		return ErrDeleteShortLink
 	}
+	u.deleteShortLinkCache(shortLink.Domain, slashCode)
+	u.publish(EventLinkDeleted, shortLink)
 
-	go u.setShortLinkCache(slashCode, shortLink.Destination, 3*time.Hour)
-	go u.incrementVisitorEnqueue(slashCode)
+	return nil
+}
 
-	return shortLink.Destination, nil
+// ClaimUnownedShortLinks hands the links created before API keys existed to
+// a user, since links without an owner can't be managed by anyone.
+func (u *shortLinkUsecase) ClaimUnownedShortLinks(ownerID uuid.UUID) (int64, error) {
+	claimed, err := u.shortLinkRepo.ClaimUnowned(ownerID)
+	if err != nil {
+		logs.Error(err.Error())
+		return 0, ErrUnexpected
+	}
+	return claimed, nil
 }
 
-func (u *shortLinkUsecase) generateSlashCode() string {
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		slashCode := helpers.StrRandom(slashLength)
-		_, err := u.shortLinkRepo.FindBySlashCode(slashCode)
-		if err == gorm.ErrRecordNotFound {
-			return slashCode
+func (u *shortLinkUsecase) GetStats(ownerID uuid.UUID, host string, slashCode string, req *domain.StatsRequest) (*domain.ShortLinkStats, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.ShortLinkStats{
+		SlashCode: shortLink.SlashCode,
+		Interval:  req.Interval,
+		To:        time.Now(),
+	}
+	if stats.Interval == "" {
+		stats.Interval = "day"
+	}
+
+	from, err := parseTimeFilter(req.From)
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+	to, err := parseTimeFilter(req.To)
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+	if to != nil {
+		stats.To = *to
+	}
+	stats.From = stats.To.AddDate(0, 0, -defaultStatsDays)
+	if from != nil {
+		stats.From = *from
+	}
+	if !stats.From.Before(stats.To) {
+		return nil, ErrInvalidFilter
+	}
+
+	stats.Buckets, err = u.clickRepo.CountByInterval(shortLink.ID, stats.Interval, stats.From, stats.To)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	for _, bucket := range stats.Buckets {
+		stats.Total += bucket.Count
+	}
+
+	variants, err := u.shortLinkRepo.FindVariants(shortLink.ID)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	if len(variants) > 0 {
+		counts, err := u.clickRepo.CountByVariant(shortLink.ID, stats.From, stats.To)
+		if err != nil {
+			logs.Error(err.Error())
+			return nil, ErrUnexpected
+		}
+		for _, variant := range variants {
+			stats.Variants = append(stats.Variants, &domain.VariantStats{
+				ID:          variant.ID,
+				Destination: variant.Destination,
+				Weight:      variant.Weight,
+				Count:       counts[variant.ID],
+			})
 		}
 	}
-	return ""
+
+	return stats, nil
 }
 
-func (u *shortLinkUsecase) checkSlashCodeExist(slashCode string) error {
-	_, err := u.shortLinkRepo.FindBySlashCode(slashCode)
+func (u *shortLinkUsecase) FindRules(ownerID uuid.UUID, host string, slashCode string) ([]*models.LinkRule, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil
+		return nil, err
+	}
+
+	rules, err := u.shortLinkRepo.FindRules(shortLink.ID)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	return rules, nil
+}
+
+// ReplaceRules sets the rules of a link, in the order they're checked.
+func (u *shortLinkUsecase) ReplaceRules(ownerID uuid.UUID, host string, slashCode string, req *domain.ReplaceLinkRulesRequest) ([]*models.LinkRule, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*models.LinkRule, len(req.Rules))
+	for i, r := range req.Rules {
+		if r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+			return nil, ErrInvalidTimeWindow
+		}
+		if err := u.policy.CheckDestination(r.Destination); err != nil {
+			return nil, err
 		}
+
+		rules[i] = &models.LinkRule{
+			ID:          uuid.New(),
+			ShortLinkID: shortLink.ID,
+			Position:    i,
+			OS:          r.OS,
+			Device:      r.Device,
+			StartsAt:    r.StartsAt,
+			EndsAt:      r.EndsAt,
+			Destination: r.Destination,
+		}
+		for _, country := range r.Countries {
+			rules[i].Countries = append(rules[i].Countries, strings.ToUpper(country))
+		}
+		for _, language := range r.Languages {
+			rules[i].Languages = append(rules[i].Languages, strings.ToLower(language))
+		}
+	}
+
+	if err := u.shortLinkRepo.ReplaceRules(shortLink.ID, rules); err != nil {
 		logs.Error(err.Error())
-		return ErrUnexpected
+		return nil, ErrUpdateShortLink
 	}
-	return ErrSlashCodeExists
+	u.deleteShortLinkCache(shortLink.Domain, slashCode)
+	u.publish(EventLinkUpdated, shortLink)
+
+	return rules, nil
 }
 
-func (u *shortLinkUsecase) setShortLinkCache(slashCode string, destination string, duration time.Duration) {
-	err := u.shortLinkRepo.SetShortLinkCache(slashCode, destination, duration)
+func (u *shortLinkUsecase) FindVariants(ownerID uuid.UUID, host string, slashCode string) ([]*models.LinkVariant, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := u.shortLinkRepo.FindVariants(shortLink.ID)
 	if err != nil {
 		logs.Error(err.Error())
+		return nil, ErrUnexpected
 	}
+
+	return variants, nil
 }
 
-func (u *shortLinkUsecase) incrementVisitorEnqueue(slashCode string) {
-	u.visitorQueue.mu.Lock()
-	defer u.visitorQueue.mu.Unlock()
+// ReplaceVariants splits a link between several destinations. Variants get
+// new IDs, so their click counts start over.
+func (u *shortLinkUsecase) ReplaceVariants(ownerID uuid.UUID, host string, slashCode string, req *domain.ReplaceLinkVariantsRequest) ([]*models.LinkVariant, error) {
+	shortLink, err := u.FindBySlashCode(ownerID, host, slashCode)
+	if err != nil {
+		return nil, err
+	}
 
-	if _, exist := u.visitorQueue.counts[slashCode]; !exist {
-		u.visitorQueue.counts[slashCode] = 1
-		u.visitorQueue.order = append(u.visitorQueue.order, slashCode)
-		if !u.visitorQueue.isRunning {
-			u.visitorQueue.isRunning = true
-			go u.incrementVisitorQueueWorker()
+	variants := make([]*models.LinkVariant, len(req.Variants))
+	for i, v := range req.Variants {
+		if err := u.policy.CheckDestination(v.Destination); err != nil {
+			return nil, err
 		}
-	} else {
-		u.visitorQueue.counts[slashCode] += 1
+
+		variants[i] = &models.LinkVariant{
+			ID:          uuid.New(),
+			ShortLinkID: shortLink.ID,
+			Position:    i,
+			Destination: v.Destination,
+			Weight:      v.Weight,
+		}
+	}
+
+	if err := u.shortLinkRepo.ReplaceVariants(shortLink.ID, variants); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUpdateShortLink
 	}
+	u.deleteShortLinkCache(shortLink.Domain, slashCode)
+	u.publish(EventLinkUpdated, shortLink)
+
+	return variants, nil
 }
 
-func (u *shortLinkUsecase) incrementVisitorQueueWorker() {
-	for {
-		u.visitorQueue.mu.Lock()
-		if len(u.visitorQueue.counts) == 0 {
-			u.visitorQueue.isRunning = false
-			u.visitorQueue.mu.Unlock()
-			return
+// Redirect serves links of the domain named by host, or of the default domain
+// when host is not a registered custom domain.
+func (u *shortLinkUsecase) Redirect(host string, slashCode string, visit *domain.Visit) (*domain.Redirection, error) {
+	target, err := u.resolve(u.linkDomain(host), slashCode)
+	if err != nil {
+		return nil, err
+	}
+	if target.PasswordHash != "" {
+		return nil, ErrPasswordRequired
+	}
+
+	// Unfurling a pasted link isn't a visit, so crawlers are shown the
+	// link's own preview and aren't counted.
+	if target.OG != nil && useragent.IsCrawler(visit.UserAgent) {
+		return &domain.Redirection{
+			Destination: buildDestination(target.Destination, target, visit.Query),
+			StatusCode:  redirectType(target.RedirectType),
+			Preview:     target.OG,
+		}, nil
+	}
+
+	dest, variantID := u.chooseDestination(target, visit)
+	if dest != target.Destination && u.policy.IsBlocked(dest) {
+		return nil, ErrShortLinkBlocked
+	}
+
+	click := newClick(target.ID, visit)
+	click.VariantID = variantID
+	u.incrementVisitorEnqueue(click)
+
+	return &domain.Redirection{
+		Destination: buildDestination(dest, target, visit.Query),
+		StatusCode:  redirectType(target.RedirectType),
+		Private:     len(target.Rules) > 0 || len(target.Variants) > 0 || target.OG != nil,
+	}, nil
+}
+
+// Unlock redirects to a password protected link. Failed attempts are counted
+// per link, so guessing from many addresses is limited as well. Without Redis
+// each replica counts the attempts it sees, so links can still be unlocked.
+func (u *shortLinkUsecase) Unlock(host string, slashCode string, password string, visit *domain.Visit) (*domain.Redirection, error) {
+	target, err := u.resolve(u.linkDomain(host), slashCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.PasswordHash != "" {
+		failed, err := u.shortLinkRepo.CountFailedUnlocks(target.ID)
+		if err != nil {
+			logCacheError(err)
+			failed, _ = u.localUnlocks.Get(target.ID)
+		}
+		if failed >= maxFailedUnlocks {
+			return nil, ErrTooManyAttempts
 		}
 
-		code := u.visitorQueue.order[0]
-		u.visitorQueue.order = u.visitorQueue.order[1:]
+		if err := bcrypt.CompareHashAndPassword([]byte(target.PasswordHash), []byte(password)); err != nil {
+			if err := u.shortLinkRepo.IncrementFailedUnlocks(target.ID, unlockWindow); err != nil {
+				logCacheError(err)
+				failed, _ := u.localUnlocks.Get(target.ID)
+				u.localUnlocks.Set(target.ID, failed+1, unlockWindow)
+			}
+			return nil, ErrInvalidPassword
+		}
+	}
 
-		visitors := u.visitorQueue.counts[code]
-		delete(u.visitorQueue.counts, code)
-		u.visitorQueue.mu.Unlock()
+	dest, variantID := u.chooseDestination(target, visit)
+	if dest != target.Destination && u.policy.IsBlocked(dest) {
+		return nil, ErrShortLinkBlocked
+	}
+
+	click := newClick(target.ID, visit)
+	click.VariantID = variantID
+	u.incrementVisitorEnqueue(click)
+
+	return &domain.Redirection{
+		Destination: buildDestination(dest, target, visit.Query),
+		StatusCode:  redirectType(target.RedirectType),
+	}, nil
+}
+
+// resolve finds where a slash code leads, from the cache when possible, and
+// refuses links that have expired or whose destination got blocked. Codes
+// that don't exist are turned away by the filter, or else remembered for a
+// short while, so scanning for codes doesn't reach MySQL.
+func (u *shortLinkUsecase) resolve(host string, slashCode string) (*domain.ShortLinkCache, error) {
+	if !u.shortLinkRepo.MayExist(host, slashCode) {
+		metrics.RedirectFiltered.Inc()
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	cache, err := u.shortLinkRepo.FindShortLinkCache(host, slashCode)
+	if err == nil {
+		metrics.RedirectCache.WithLabelValues("hit").Inc()
+		if cache.NotFound {
+			return nil, gorm.ErrRecordNotFound
+		}
+		if u.policy.IsBlocked(cache.Destination) {
+			return nil, ErrShortLinkBlocked
+		}
+		return cache, nil
+	}
+	if err == redis.Nil {
+		metrics.RedirectCache.WithLabelValues("miss").Inc()
+	} else {
+		metrics.RedirectCache.WithLabelValues("error").Inc()
+		logCacheError(err)
+	}
+
+	shortLink, err := u.shortLinkRepo.FindBySlashCode(host, slashCode)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			go u.setShortLinkCache(host, slashCode, &domain.ShortLinkCache{NotFound: true}, notFoundCacheDuration)
+			return nil, err
+		}
+		metrics.RedirectErrors.Inc()
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	if u.isExpired(shortLink) {
+		return nil, ErrShortLinkExpired
+	}
+	if u.policy.IsBlocked(shortLink.Destination) {
+		return nil, ErrShortLinkBlocked
+	}
 
-		err := u.shortLinkRepo.IncrementVisitor(code, visitors)
+	rules, err := u.shortLinkRepo.FindRules(shortLink.ID)
+	if err != nil {
+		metrics.RedirectErrors.Inc()
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	variants, err := u.shortLinkRepo.FindVariants(shortLink.ID)
+	if err != nil {
+		metrics.RedirectErrors.Inc()
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	cache = &domain.ShortLinkCache{
+		ID:           shortLink.ID,
+		Destination:  shortLink.Destination,
+		RedirectType: shortLink.RedirectType,
+		PasswordHash: shortLink.PasswordHash,
+		Passthrough:  shortLink.Passthrough,
+	}
+	if len(rules) > 0 {
+		cache.Rules = rules
+	}
+	if len(variants) > 0 {
+		cache.Variants = variants
+	}
+	if shortLink.UTM != (models.UTM{}) {
+		cache.UTM = &shortLink.UTM
+	}
+	if shortLink.OG != (models.OpenGraph{}) {
+		cache.OG = &shortLink.OG
+	}
+	if exp := u.cacheExpiration(shortLink); exp > 0 {
+		go u.setShortLinkCache(host, slashCode, cache, exp)
+	}
+
+	return cache, nil
+}
+
+func (u *shortLinkUsecase) generateSlashCode(host string) string {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		slashCode, err := u.slashCodes.next()
 		if err != nil {
 			logs.Error(err.Error())
+			return ""
+		}
+
+		_, err = u.shortLinkRepo.FindBySlashCode(host, slashCode)
+		if err == gorm.ErrRecordNotFound {
+			u.slashCodes.collisions.Store(0)
+			return slashCode
+		}
+		if err == nil {
+			u.slashCodes.collided()
+		}
+	}
+	return ""
+}
+
+// uniqueSlashCode returns a code that is not used elsewhere in the batch on
+// the same domain. Clashes within the batch count as collisions too, so a
+// batch larger than the code space makes codes longer instead of spinning.
+func (u *shortLinkUsecase) uniqueSlashCode(host string, taken map[string]bool) (string, error) {
+	for attempt := 0; attempt < maxBatchAttempts; attempt++ {
+		slashCode, err := u.slashCodes.next()
+		if err != nil {
+			return "", err
+		}
+		if !taken[host+"/"+slashCode] {
+			taken[host+"/"+slashCode] = true
+			return slashCode, nil
 		}
+		u.slashCodes.collided()
+	}
+	return "", ErrGenerateSlashCode
+}
+
+// next skips codes that would be shadowed by the service's own routes.
+func (g *slashCodeGenerator) next() (string, error) {
+	for {
+		slashCode, err := g.generator.Generate(int(g.length.Load()))
+		if err != nil || !isReservedSlashCode(slashCode) {
+			return slashCode, err
+		}
+	}
+}
+
+// collided records a generated code that was already taken. Once collisions
+// keep happening the code space is getting crowded, so codes get one
+// character longer.
+func (g *slashCodeGenerator) collided() {
+	metrics.SlashCodeCollisions.Inc()
+	if g.collisions.Add(1) < maxAttempts {
+		return
+	}
+	g.collisions.Store(0)
+
+	length := g.length.Load()
+	if length < slashcode.MaxLength && g.length.CompareAndSwap(length, length+1) {
+		logs.Info(fmt.Sprintf("slash code length extended to %v", length+1))
+	}
+}
+
+func (u *shortLinkUsecase) checkSlashCodeExist(host string, slashCode string) error {
+	if isReservedSlashCode(slashCode) {
+		return ErrSlashCodeExists
+	}
+
+	_, err := u.shortLinkRepo.FindBySlashCode(host, slashCode)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+	return ErrSlashCodeExists
+}
+
+func (u *shortLinkUsecase) checkDomain(name string) error {
+	if name != "" && !u.domains.IsRegistered(name) {
+		return ErrDomainNotRegistered
+	}
+	return nil
+}
+
+func (u *shortLinkUsecase) linkDomain(host string) string {
+	if u.domains.IsRegistered(host) {
+		return normalizeDomainName(host)
+	}
+	return ""
+}
+
+func (u *shortLinkUsecase) isExpired(shortLink *models.ShortLink) bool {
+	if shortLink.ExpiresAt != nil && !time.Now().Before(*shortLink.ExpiresAt) {
+		return true
+	}
+
+	if shortLink.MaxVisits != nil {
+		u.visitorQueue.mu.Lock()
+		pending := u.visitorQueue.counts[shortLink.ID.String()]
+		u.visitorQueue.mu.Unlock()
+
+		buffered, err := u.shortLinkRepo.FindPendingVisitors(shortLink.ID.String())
+		if err != nil {
+			logCacheError(err)
+		}
+		pending += buffered
+
+		return shortLink.Visitors+pending >= *shortLink.MaxVisits
+	}
+
+	return false
+}
+
+// Links with a visit budget are not cached since the budget can only be
+// checked against the database.
+func (u *shortLinkUsecase) cacheExpiration(shortLink *models.ShortLink) time.Duration {
+	if shortLink.MaxVisits != nil {
+		return 0
+	}
+
+	exp := cacheDuration
+	if shortLink.ExpiresAt != nil {
+		if remaining := time.Until(*shortLink.ExpiresAt); remaining < exp {
+			exp = remaining
+		}
+	}
+
+	return exp
+}
+
+func (u *shortLinkUsecase) setShortLinkCache(host string, slashCode string, cache *domain.ShortLinkCache, duration time.Duration) {
+	err := u.shortLinkRepo.SetShortLinkCache(host, slashCode, cache, duration)
+	if err != nil {
+		logCacheError(err)
+	}
+}
+
+func (u *shortLinkUsecase) announceShortLinks(shortLinks []*models.ShortLink) {
+	err := u.shortLinkRepo.AnnounceShortLinks(shortLinks)
+	if err != nil {
+		logCacheError(err)
+	}
+}
+
+// publish reports link changes to webhooks. Failures are logged by the
+// webhooks and don't fail the change.
+func (u *shortLinkUsecase) publish(event string, shortLinks ...*models.ShortLink) {
+	if u.webhooks == nil {
+		return
+	}
+
+	events := make([]*domain.WebhookEvent, len(shortLinks))
+	for i, shortLink := range shortLinks {
+		events[i] = &domain.WebhookEvent{Type: event, Link: shortLink}
+	}
+	u.webhooks.Publish(events...)
+}
+
+func (u *shortLinkUsecase) deleteShortLinkCache(host string, slashCode string) {
+	err := u.shortLinkRepo.DeleteShortLinkCache(host, slashCode)
+	if err != nil {
+		logCacheError(err)
+	}
+}
+
+// RetryCacheInvalidations drops the cache entries of links that changed
+// while Redis was unavailable, before other replicas read them again.
+func (u *shortLinkUsecase) RetryCacheInvalidations() error {
+	if err := u.shortLinkRepo.RetryCacheInvalidations(); err != nil {
+		logCacheError(err)
+		return ErrUnexpected
+	}
+	return nil
+}
+
+// RefreshSlashCodeFilter loads the filter of existing slash codes if it is
+// missing or due to be rebuilt. Calls made while it is loading return at once.
+func (u *shortLinkUsecase) RefreshSlashCodeFilter() error {
+	if !u.filterMu.TryLock() {
+		return nil
+	}
+	defer u.filterMu.Unlock()
+
+	if err := u.shortLinkRepo.LoadSlashCodeFilter(filterMaxAge); err != nil {
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+	return nil
+}
+
+// FlushVisitors moves the visitor counts buffered in Redis to MySQL. Counts
+// are acknowledged one link at a time, so an interrupted flush is picked up
+// by the next one.
+func (u *shortLinkUsecase) FlushVisitors() error {
+	counts, err := u.shortLinkRepo.TakePendingVisitors(flushLease)
+	if err != nil {
+		logCacheError(err)
+		return ErrUnexpected
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	defer func() {
+		if err := u.shortLinkRepo.ReleasePendingVisitors(); err != nil {
+			logs.Error(err.Error())
+		}
+	}()
+
+	for key, visitors := range counts {
+		// Counts are keyed by link id; anything else can't be applied.
+		if id, err := uuid.Parse(key); err != nil {
+			logs.Error(fmt.Sprintf("dropped %v pending visitors of %v", visitors, key))
+		} else if err := u.shortLinkRepo.IncrementVisitor(id, visitors); err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+		if err := u.shortLinkRepo.AckPendingVisitors(key); err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+	}
+
+	return nil
+}
+
+// DrainVisitors writes everything still queued in memory and then flushes
+// Redis to MySQL. It is called on shutdown.
+func (u *shortLinkUsecase) DrainVisitors() error {
+	for u.flushVisitorQueue() {
+	}
+	return u.FlushVisitors()
+}
+
+func (u *shortLinkUsecase) incrementVisitorEnqueue(click *models.Click) {
+	u.visitorQueue.mu.Lock()
+	defer u.visitorQueue.mu.Unlock()
+
+	u.visitorQueue.clicks = append(u.visitorQueue.clicks, click)
+	u.visitorQueue.counts[click.ShortLinkID.String()] += 1
+	metrics.PendingVisitors.Inc()
+
+	if !u.visitorQueue.isRunning {
+		u.visitorQueue.isRunning = true
+		go u.incrementVisitorQueueWorker()
+	}
+}
+
+func (u *shortLinkUsecase) incrementVisitorQueueWorker() {
+	for u.flushVisitorQueue() {
+	}
+}
+
+// flushVisitorQueue writes one batch from the in-memory queue and reports
+// whether there was anything to write. Counts go to Redis so they survive a
+// restart; if Redis is unavailable they are written to MySQL directly.
+func (u *shortLinkUsecase) flushVisitorQueue() bool {
+	u.visitorQueue.flushMu.Lock()
+	defer u.visitorQueue.flushMu.Unlock()
+
+	u.visitorQueue.mu.Lock()
+	if len(u.visitorQueue.counts) == 0 && len(u.visitorQueue.clicks) == 0 {
+		u.visitorQueue.isRunning = false
+		u.visitorQueue.mu.Unlock()
+		return false
+	}
+
+	counts := u.visitorQueue.counts
+	u.visitorQueue.counts = make(map[string]int)
+
+	var clicks []*models.Click
+	if len(u.visitorQueue.clicks) > 0 {
+		n := min(len(u.visitorQueue.clicks), clickBatchSize)
+		clicks = u.visitorQueue.clicks[:n]
+		u.visitorQueue.clicks = u.visitorQueue.clicks[n:]
+	}
+	u.visitorQueue.mu.Unlock()
+
+	pending := 0
+	for _, visitors := range counts {
+		pending += visitors
+	}
+	metrics.PendingVisitors.Sub(float64(pending))
+
+	if len(counts) > 0 {
+		if err := u.shortLinkRepo.IncrementPendingVisitors(counts); err != nil {
+			logCacheError(err)
+			for key, visitors := range counts {
+				if err := u.shortLinkRepo.IncrementVisitor(uuid.MustParse(key), visitors); err != nil {
+					logs.Error(err.Error())
+				}
+			}
+		}
+	}
+
+	if len(clicks) > 0 {
+		if err := u.clickRepo.CreateBatch(clicks); err != nil {
+			logs.Error(err.Error())
+		}
+	}
+
+	if len(counts) > 0 {
+		u.publishVisitors(counts)
+	}
+
+	return true
+}
+
+// publishVisitors lets webhooks know how far the visitors of the links just
+// counted got. Redis is read before MySQL, so a flush in between counts some
+// visits twice rather than missing them; the event keys drop the repeats.
+func (u *shortLinkUsecase) publishVisitors(counts map[string]int) {
+	if u.webhooks == nil || !u.webhooks.SubscribesToVisitors() {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	ids := make([]uuid.UUID, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+		ids = append(ids, uuid.MustParse(key))
+	}
+
+	pending, err := u.shortLinkRepo.FindPendingVisitorsByIDs(keys)
+	if err != nil {
+		logCacheError(err)
+	}
+
+	shortLinks, err := u.shortLinkRepo.FindByIDs(ids)
+	if err != nil {
+		logs.Error(err.Error())
+		return
+	}
+	for _, shortLink := range shortLinks {
+		after := shortLink.Visitors + pending[shortLink.ID.String()]
+		u.webhooks.PublishVisitors(shortLink, after-counts[shortLink.ID.String()], after)
+	}
+}
+
+// PublishExpired publishes the links that expired since the last call. The
+// first call looks back an hour, so expiries during a restart aren't missed.
+func (u *shortLinkUsecase) PublishExpired() error {
+	if u.webhooks == nil {
+		return nil
+	}
+
+	u.expiredMu.Lock()
+	defer u.expiredMu.Unlock()
+
+	now := time.Now()
+	since := u.expiredSince
+	if since.IsZero() {
+		since = now.Add(-expiredLookback)
+	}
+
+	shortLinks, err := u.shortLinkRepo.FindExpired(since, now)
+	if err != nil {
+		logs.Error(err.Error())
+		return ErrUnexpected
+	}
+
+	events := make([]*domain.WebhookEvent, len(shortLinks))
+	for i, shortLink := range shortLinks {
+		events[i] = expiredEvent(shortLink)
+	}
+	if err := u.webhooks.Publish(events...); err != nil {
+		return err
+	}
+
+	u.expiredSince = now
+	return nil
+}
+
+func newShortLink(ownerID uuid.UUID, req *domain.CreateShortLinkRequest) (*models.ShortLink, error) {
+	shortLink := &models.ShortLink{
+		ID:              uuid.New(),
+		OwnerID:         &ownerID,
+		Destination:     req.Destination,
+		DestinationHost: destinationHost(req.Destination),
+		Domain:          normalizeDomainName(req.Domain),
+		RedirectType:    redirectType(req.RedirectType),
+		ExpiresAt:       req.ExpiresAt,
+		MaxVisits:       req.MaxVisits,
+		Passthrough:     req.Passthrough,
+	}
+	if req.UTM != nil {
+		shortLink.UTM = newUTM(req.UTM)
+	}
+	if req.OG != nil {
+		shortLink.OG = newOpenGraph(req.OG)
+	}
+	if shortLink.Passthrough == "" {
+		shortLink.Passthrough = PassthroughOff
+	}
+
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		shortLink.PasswordHash = string(hash)
+		shortLink.Protected = true
+	}
+
+	return shortLink, nil
+}
+
+// redirectType falls back to a permanent redirect for links and cache
+// entries created before the redirect type could be chosen.
+func redirectType(statusCode int) int {
+	if statusCode == 0 {
+		return http.StatusMovedPermanently
+	}
+	return statusCode
+}
+
+func newUTM(params *domain.UTMParameters) models.UTM {
+	return models.UTM{
+		Source:   params.Source,
+		Medium:   params.Medium,
+		Campaign: params.Campaign,
+		Term:     params.Term,
+		Content:  params.Content,
+	}
+}
+
+func newOpenGraph(params *domain.OGParameters) models.OpenGraph {
+	return models.OpenGraph{
+		Title:       params.Title,
+		Description: params.Description,
+		Image:       params.Image,
+	}
+}
+
+// chooseDestination sends the visitor to the first rule they match, else to
+// one of the link's variants, else to the link's own destination. The
+// variant is returned as well so the click can be counted for it.
+func (u *shortLinkUsecase) chooseDestination(target *domain.ShortLinkCache, visit *domain.Visit) (string, *uuid.UUID) {
+	if dest, ok := u.matchRule(target, visit); ok {
+		return dest, nil
+	}
+	if variant := chooseVariant(target, visit); variant != nil {
+		return variant.Destination, &variant.ID
+	}
+	return target.Destination, nil
+}
+
+// matchRule returns the destination of the first rule the visitor matches.
+// The country is only looked up if a rule asks for it.
+func (u *shortLinkUsecase) matchRule(target *domain.ShortLinkCache, visit *domain.Visit) (string, bool) {
+	if len(target.Rules) == 0 {
+		return "", false
+	}
+
+	os, device := useragent.Parse(visit.UserAgent)
+	languages := acceptedLanguages(visit.AcceptLanguage)
+	country, located := "", false
+	now := time.Now()
+
+	for _, rule := range target.Rules {
+		if rule.OS != "" && rule.OS != os {
+			continue
+		}
+		if rule.Device != "" && rule.Device != device {
+			continue
+		}
+		if rule.StartsAt != nil && now.Before(*rule.StartsAt) {
+			continue
+		}
+		if rule.EndsAt != nil && !now.Before(*rule.EndsAt) {
+			continue
+		}
+		if len(rule.Languages) > 0 && !matchLanguage(rule.Languages, languages) {
+			continue
+		}
+		if len(rule.Countries) > 0 {
+			if !located {
+				country, located = u.country(visit.IP), true
+			}
+			if !slices.Contains(rule.Countries, country) {
+				continue
+			}
+		}
+		return rule.Destination, true
+	}
+
+	return "", false
+}
+
+// chooseVariant picks a variant with a chance proportional to its weight.
+// The pick comes from a hash of the visitor's IP and user agent, so the same
+// visitor keeps seeing the same variant without a cookie. Visitors whose IP
+// changes, such as on mobile networks, may be moved to another variant.
+func chooseVariant(target *domain.ShortLinkCache, visit *domain.Visit) *models.LinkVariant {
+	total := 0
+	for _, variant := range target.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	h.Write(target.ID[:])
+	h.Write([]byte(visit.IP))
+	h.Write([]byte{0})
+	h.Write([]byte(visit.UserAgent))
+
+	n := int(h.Sum64() % uint64(total))
+	for _, variant := range target.Variants {
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+	return nil
+}
+
+func (u *shortLinkUsecase) country(ip string) string {
+	if u.geo == nil {
+		return ""
+	}
+	return u.geo.Country(ip)
+}
+
+// acceptedLanguages lists the language tags of an Accept-Language header in
+// lower case, leaving out the ones refused with q=0.
+func acceptedLanguages(header string) []string {
+	languages := []string{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		languages = append(languages, tag)
+	}
+	return languages
+}
+
+// matchLanguage reports whether any accepted language is one of wanted, or
+// a regional variant of one, so "en" matches "en-us".
+func matchLanguage(wanted []string, accepted []string) bool {
+	for _, language := range accepted {
+		for _, w := range wanted {
+			if language == w || strings.HasPrefix(language, w+"-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildDestination adds the link's UTM parameters to its destination, unless
+// the destination sets them already, and forwards the query of the short URL
+// if the link asks for it. The destination's own query is kept as is, so
+// signed URLs stay valid, and only parameters it doesn't contain are appended.
+// With PassthroughLink the UTM parameters win over the request's, with
+// PassthroughRequest the other way round.
+func buildDestination(destination string, target *domain.ShortLinkCache, query string) string {
+	forward := query != "" && (target.Passthrough == PassthroughLink || target.Passthrough == PassthroughRequest)
+	if target.UTM == nil && !forward {
+		return destination
+	}
+
+	base, fragment, hasFragment := strings.Cut(destination, "#")
+	_, rawQuery, _ := strings.Cut(base, "?")
+	// A malformed pair is ignored, the destination is still left untouched.
+	existing, _ := url.ParseQuery(rawQuery)
+
+	var utm [][2]string
+	if target.UTM != nil {
+		for _, param := range [][2]string{
+			{"utm_source", target.UTM.Source},
+			{"utm_medium", target.UTM.Medium},
+			{"utm_campaign", target.UTM.Campaign},
+			{"utm_term", target.UTM.Term},
+			{"utm_content", target.UTM.Content},
+		} {
+			if param[1] != "" && !existing.Has(param[0]) {
+				utm = append(utm, param)
+			}
+		}
+	}
+
+	var forwarded []string
+	if forward {
+		for _, pair := range strings.Split(query, "&") {
+			name, value, _ := strings.Cut(pair, "=")
+			// A malformed pair is dropped, the rest are still forwarded.
+			name, nameErr := url.QueryUnescape(name)
+			_, valueErr := url.QueryUnescape(value)
+			if name == "" || nameErr != nil || valueErr != nil || existing.Has(name) {
+				continue
+			}
+
+			overrides := false
+			for i, param := range utm {
+				if param[0] == name {
+					overrides = true
+					if target.Passthrough == PassthroughRequest {
+						utm = append(utm[:i], utm[i+1:]...)
+					}
+					break
+				}
+			}
+			if overrides && target.Passthrough == PassthroughLink {
+				continue
+			}
+			forwarded = append(forwarded, pair)
+		}
+	}
+
+	extra := make([]string, 0, len(utm)+len(forwarded))
+	for _, param := range utm {
+		extra = append(extra, url.QueryEscape(param[0])+"="+url.QueryEscape(param[1]))
+	}
+	extra = append(extra, forwarded...)
+	if len(extra) == 0 {
+		return destination
+	}
+
+	switch {
+	case rawQuery == "" && !strings.Contains(base, "?"):
+		base += "?"
+	case rawQuery != "" && !strings.HasSuffix(rawQuery, "&"):
+		base += "&"
+	}
+	base += strings.Join(extra, "&")
+	if hasFragment {
+		base += "#" + fragment
+	}
+	return base
+}
+
+func destinationHost(dest string) string {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func parseTimeFilter(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func encodeCursor(filter *domain.ShortLinkFilter, last *models.ShortLink) string {
+	cursor := &listCursor{
+		Sort: filter.Sort,
+		Desc: filter.Desc,
+		Value: domain.ShortLinkCursor{
+			ID:        last.ID,
+			CreatedAt: last.CreatedAt,
+			Visitors:  last.Visitors,
+		},
+	}
+
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(value string, filter *domain.ShortLinkFilter) (*domain.ShortLinkCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &listCursor{}
+	if err := json.Unmarshal(b, cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor.Value, nil
+}
+
+func newClick(shortLinkID uuid.UUID, visit *domain.Visit) *models.Click {
+	return &models.Click{
+		ShortLinkID:    shortLinkID,
+		ClickedAt:      time.Now(),
+		Referrer:       truncate(visit.Referrer, 512),
+		UserAgent:      truncate(visit.UserAgent, 512),
+		IPPrefix:       ipPrefix(visit.IP),
+		AcceptLanguage: truncate(visit.AcceptLanguage, 255),
+	}
+}
+
+// ipPrefix keeps only the network part of the address (/24 for IPv4, /48
+// for IPv6) so clicks can be grouped by origin without storing the full IP.
+func ipPrefix(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+
+	bits := 48
+	if addr.Is4() || addr.Is4In6() {
+		addr, bits = addr.Unmap(), 24
+	}
+
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	return strings.ToValidUTF8(value[:length], "")
+}
+
+// NormalizeDestination defaults destinations without a scheme to https, so
+// every way of creating a link stores the same URL.
+func NormalizeDestination(dest string) (string, error) {
+	if dest == "" {
+		return "", ErrDestinationRequired
+	} else if !strings.Contains(dest, ".") && !strings.Contains(dest, ":") {
+		return "", ErrDestinationInvalid
+	} else if !strings.Contains(dest, "://") && !hasScheme(dest) {
+		return "https://" + dest, nil
+	}
+	return dest, nil
+}
+
+// hasScheme reports whether dest starts with a scheme like `javascript:` or
+// `mailto:`, so the destination policy sees it instead of a prefixed host.
+// A colon followed by a digit is read as a host and port.
+func hasScheme(dest string) bool {
+	i := strings.IndexByte(dest, ':')
+	if i <= 0 || strings.ContainsAny(dest[:i], "./") {
+		return false
+	}
+	return i+1 == len(dest) || dest[i+1] < '0' || dest[i+1] > '9'
+}
+
+func isReservedSlashCode(slashCode string) bool {
+	return reservedSlashCodes[strings.ToLower(slashCode)]
+}
+
+// logCacheError leaves out calls skipped by the Redis circuit breaker, which
+// reports the outage itself.
+func logCacheError(err error) {
+	if !errors.Is(err, breaker.ErrOpen) {
+		logs.Error(err.Error())
 	}
 }
@@ -0,0 +1,433 @@
+package usecases
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/logs"
+	"url-shortener/models"
+	"url-shortener/utils/validation"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+
+	importBatchSize = 1000
+	exportPageSize  = 500
+	maxImportLine   = 64 * 1024
+)
+
+var (
+	ErrInvalidFormat = errors.New("format must be csv or ndjson")
+	ErrInvalidImport = errors.New("invalid import file")
+	// ErrProtectedImport rejects a protected link without its password, since
+	// exports leave the hash out and the link would come back open.
+	ErrProtectedImport = errors.New("protected link needs a password")
+)
+
+var exportHeader = []string{"slash_code", "destination", "redirect_type", "visitors", "max_visits", "expires_at", "created_at", "domain", "utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "query_passthrough", "og_title", "og_description", "og_image", "protected"}
+
+type exportRecord struct {
+	Domain       string                `json:"domain,omitempty"`
+	SlashCode    string                `json:"slash_code"`
+	Destination  string                `json:"destination"`
+	RedirectType int                   `json:"redirect_type"`
+	Visitors     int                   `json:"visitors"`
+	MaxVisits    *int                  `json:"max_visits"`
+	ExpiresAt    *time.Time            `json:"expires_at"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UTM          *domain.UTMParameters `json:"utm,omitempty"`
+	Passthrough  string                `json:"query_passthrough,omitempty"`
+	OG           *domain.OGParameters  `json:"og,omitempty"`
+	Protected    bool                  `json:"protected,omitempty"`
+}
+
+type importRow struct {
+	line      int
+	req       *domain.CreateShortLinkRequest
+	protected bool
+	err       error
+}
+
+// ImportShortLinks reads a CSV or NDJSON file and creates its links in
+// batches. Only rows that failed are listed in the result.
+func (u *shortLinkUsecase) ImportShortLinks(ownerID uuid.UUID, format string, r io.Reader) (*domain.ImportResult, error) {
+	next, err := importReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ImportResult{Errors: []*domain.ImportError{}}
+	var batch []*importRow
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		reqs := make([]*domain.CreateShortLinkRequest, len(batch))
+		for i, row := range batch {
+			reqs[i] = row.req
+		}
+
+		created, err := u.CreateShortLinks(ownerID, reqs)
+		if err != nil {
+			return err
+		}
+
+		for i, res := range created {
+			if res.Error != "" {
+				addImportError(result, batch[i], res.Error)
+			} else {
+				result.Created++
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Total++
+		if row.err == nil && row.protected && row.req.Password == "" {
+			row.err = ErrProtectedImport
+		}
+		if row.err == nil {
+			row.req.Destination, row.err = NormalizeDestination(row.req.Destination)
+		}
+		if row.err == nil {
+			row.err = validateImportRow(row.req)
+		}
+		if row.err != nil {
+			addImportError(result, row, row.err.Error())
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool {
+		return result.Errors[i].Line < result.Errors[j].Line
+	})
+
+	return result, nil
+}
+
+// ExportShortLinks writes every link of the owner, oldest first. Links are
+// read page by page so the export never holds the whole table in memory.
+func (u *shortLinkUsecase) ExportShortLinks(ownerID uuid.UUID, format string, w io.Writer) error {
+	write, done, err := exportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	filter := &domain.ShortLinkFilter{
+		OwnerID: ownerID,
+		Sort:    "created_at",
+		Limit:   exportPageSize,
+	}
+
+	for {
+		shortLinks, err := u.shortLinkRepo.List(filter)
+		if err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+
+		for _, shortLink := range shortLinks {
+			if err := write(shortLink); err != nil {
+				return err
+			}
+		}
+
+		if len(shortLinks) < exportPageSize {
+			break
+		}
+
+		last := shortLinks[len(shortLinks)-1]
+		filter.After = &domain.ShortLinkCursor{
+			ID:        last.ID,
+			CreatedAt: last.CreatedAt,
+		}
+	}
+
+	return done()
+}
+
+// validateImportRow validates a row like a created link, except that
+// expires_at may be in the past so expired links are restored as expired.
+func validateImportRow(req *domain.CreateShortLinkRequest) error {
+	expiresAt := req.ExpiresAt
+	req.ExpiresAt = nil
+	defer func() { req.ExpiresAt = expiresAt }()
+
+	if errs := validator.ValidateStruct(req); errs != nil {
+		return errors.New(errs[0].Message)
+	}
+	return nil
+}
+
+func addImportError(result *domain.ImportResult, row *importRow, message string) {
+	result.Failed++
+	result.Errors = append(result.Errors, &domain.ImportError{
+		Line:      row.line,
+		SlashCode: row.req.SlashCode,
+		Error:     message,
+	})
+}
+
+func importReader(format string, r io.Reader) (func() (*importRow, error), error) {
+	switch format {
+	case FormatCSV:
+		return csvImportReader(r)
+	case FormatNDJSON:
+		return ndjsonImportReader(r), nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+func csvImportReader(r io.Reader) (func() (*importRow, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["destination"]; !ok {
+		return nil, ErrInvalidImport
+	}
+
+	return func() (*importRow, error) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &importRow{
+					line: parseErr.StartLine,
+					req:  &domain.CreateShortLinkRequest{},
+					err:  ErrInvalidImport,
+				}, nil
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := &importRow{
+			line: line,
+			req: &domain.CreateShortLinkRequest{
+				Domain:      value("domain"),
+				SlashCode:   value("slash_code"),
+				Destination: value("destination"),
+				Password:    value("password"),
+				Passthrough: value("query_passthrough"),
+			},
+		}
+
+		utm := &domain.UTMParameters{
+			Source:   value("utm_source"),
+			Medium:   value("utm_medium"),
+			Campaign: value("utm_campaign"),
+			Term:     value("utm_term"),
+			Content:  value("utm_content"),
+		}
+		if *utm != (domain.UTMParameters{}) {
+			row.req.UTM = utm
+		}
+
+		og := &domain.OGParameters{
+			Title:       value("og_title"),
+			Description: value("og_description"),
+			Image:       value("og_image"),
+		}
+		if *og != (domain.OGParameters{}) {
+			row.req.OG = og
+		}
+
+		if v := value("redirect_type"); v != "" {
+			if row.req.RedirectType, err = strconv.Atoi(v); err != nil {
+				row.err = errors.New("invalid redirect_type")
+			}
+		}
+		if v := value("max_visits"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				row.err = errors.New("invalid max_visits")
+			}
+			row.req.MaxVisits = &n
+		}
+		if v := value("expires_at"); v != "" {
+			if row.req.ExpiresAt, err = parseTimeFilter(v); err != nil {
+				row.err = errors.New("invalid expires_at")
+			}
+		}
+		if v := value("protected"); v != "" {
+			if row.protected, err = strconv.ParseBool(v); err != nil {
+				row.err = errors.New("invalid protected")
+			}
+		}
+
+		return row, nil
+	}, nil
+}
+
+func ndjsonImportReader(r io.Reader) func() (*importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLine)
+	line := 0
+
+	return func() (*importRow, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			row := &importRow{line: line, req: &domain.CreateShortLinkRequest{}}
+			record := struct {
+				*domain.CreateShortLinkRequest
+				Protected bool `json:"protected"`
+			}{CreateShortLinkRequest: row.req}
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				row.err = ErrInvalidImport
+			}
+			row.protected = record.Protected
+			return row, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, ErrInvalidImport
+		}
+		return nil, io.EOF
+	}
+}
+
+func exportWriter(format string, w io.Writer) (func(*models.ShortLink) error, func() error, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportHeader); err != nil {
+			return nil, nil, err
+		}
+
+		write := func(s *models.ShortLink) error {
+			record := []string{
+				s.SlashCode,
+				s.Destination,
+				strconv.Itoa(redirectType(s.RedirectType)),
+				strconv.Itoa(s.Visitors),
+				"",
+				"",
+				s.CreatedAt.Format(time.RFC3339),
+				s.Domain,
+				s.UTM.Source,
+				s.UTM.Medium,
+				s.UTM.Campaign,
+				s.UTM.Term,
+				s.UTM.Content,
+				s.Passthrough,
+				s.OG.Title,
+				s.OG.Description,
+				s.OG.Image,
+				"",
+			}
+			if s.MaxVisits != nil {
+				record[4] = strconv.Itoa(*s.MaxVisits)
+			}
+			if s.ExpiresAt != nil {
+				record[5] = s.ExpiresAt.Format(time.RFC3339)
+			}
+			if s.PasswordHash != "" {
+				record[17] = "true"
+			}
+			return writer.Write(record)
+		}
+		done := func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, done, nil
+
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write := func(s *models.ShortLink) error {
+			record := &exportRecord{
+				Domain:       s.Domain,
+				SlashCode:    s.SlashCode,
+				Destination:  s.Destination,
+				RedirectType: redirectType(s.RedirectType),
+				Visitors:     s.Visitors,
+				MaxVisits:    s.MaxVisits,
+				ExpiresAt:    s.ExpiresAt,
+				CreatedAt:    s.CreatedAt,
+				Passthrough:  s.Passthrough,
+				Protected:    s.PasswordHash != "",
+			}
+			if s.UTM != (models.UTM{}) {
+				record.UTM = &domain.UTMParameters{
+					Source:   s.UTM.Source,
+					Medium:   s.UTM.Medium,
+					Campaign: s.UTM.Campaign,
+					Term:     s.UTM.Term,
+					Content:  s.UTM.Content,
+				}
+			}
+			if s.OG != (models.OpenGraph{}) {
+				record.OG = &domain.OGParameters{
+					Title:       s.OG.Title,
+					Description: s.OG.Description,
+					Image:       s.OG.Image,
+				}
+			}
+			return encoder.Encode(record)
+		}
+		done := func() error { return nil }
+		return write, done, nil
+	}
+
+	return nil, nil, ErrInvalidFormat
+}
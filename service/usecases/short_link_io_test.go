@@ -0,0 +1,267 @@
+package usecases
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShortLinkImportShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+
+	tests := []struct {
+		name           string
+		format         string
+		input          string
+		setup          func(mr *mockDomain.MockShortLinkRepository)
+		expected       *domain.ImportResult
+		expectedErrors []int
+		expectedErr    error
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input: "slash_code,destination,redirect_type,max_visits,utm_source,query_passthrough,og_title\n" +
+				"foo,https://example.com,302,,newsletter,request,Spring sale\n" +
+				"bar,https://example.org,,10\n" +
+				"baz,not a url,,\n" +
+				"qux,https://example.net,abc,\n",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", []string{"foo", "bar"}).Return([]string{"bar"}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(1)).DoAndReturn(func(shortLinks []*models.ShortLink) error {
+					assert.Equal(t, "foo", shortLinks[0].SlashCode)
+					assert.Equal(t, 302, shortLinks[0].RedirectType)
+					assert.Equal(t, models.UTM{Source: "newsletter"}, shortLinks[0].UTM)
+					assert.Equal(t, PassthroughRequest, shortLinks[0].Passthrough)
+					assert.Equal(t, models.OpenGraph{Title: "Spring sale"}, shortLinks[0].OG)
+					return nil
+				})
+			},
+			expected:       &domain.ImportResult{Total: 4, Created: 1, Failed: 3},
+			expectedErrors: []int{3, 4, 5},
+		}, {
+			name:   "ndjson",
+			format: FormatNDJSON,
+			input: `{"slash_code": "foo", "destination": "https://example.com"}` + "\n" +
+				"\n" +
+				`{"destination": "https://example.org", "max_visits": 5}` + "\n" +
+				`{"destination": ` + "\n",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", gomock.Len(2)).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(2)).Return(nil)
+			},
+			expected:       &domain.ImportResult{Total: 3, Created: 2, Failed: 1},
+			expectedErrors: []int{4},
+		}, {
+			name:   "destinations are normalized",
+			format: FormatNDJSON,
+			input: `{"slash_code": "foo", "destination": "example.com/path"}` + "\n" +
+				`{"slash_code": "bar", "destination": ""}` + "\n" +
+				`{"slash_code": "baz", "destination": "localhost"}` + "\n",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", []string{"foo"}).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(1)).DoAndReturn(func(shortLinks []*models.ShortLink) error {
+					assert.Equal(t, "https://example.com/path", shortLinks[0].Destination)
+					return nil
+				})
+			},
+			expected:       &domain.ImportResult{Total: 3, Created: 1, Failed: 2},
+			expectedErrors: []int{2, 3},
+		}, {
+			name:   "protected and expired links",
+			format: FormatCSV,
+			input: "slash_code,destination,expires_at,password,protected\n" +
+				"foo,https://example.com,2020-01-01T00:00:00Z,,\n" +
+				"bar,https://example.org,,,true\n" +
+				"baz,https://example.net,,secret,true\n",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", []string{"foo", "baz"}).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(2)).DoAndReturn(func(shortLinks []*models.ShortLink) error {
+					assert.NotNil(t, shortLinks[0].ExpiresAt)
+					assert.NotEmpty(t, shortLinks[1].PasswordHash)
+					return nil
+				})
+			},
+			expected:       &domain.ImportResult{Total: 3, Created: 2, Failed: 1},
+			expectedErrors: []int{3},
+		}, {
+			name:           "ndjson protected link without password",
+			format:         FormatNDJSON,
+			input:          `{"slash_code": "foo", "destination": "https://example.com", "protected": true}`,
+			expected:       &domain.ImportResult{Total: 1, Failed: 1},
+			expectedErrors: []int{1},
+		}, {
+			name:        "error csv without destination column",
+			format:      FormatCSV,
+			input:       "slash_code\nfoo\n",
+			expectedErr: ErrInvalidImport,
+		}, {
+			name:        "error unknown format",
+			format:      "xml",
+			expectedErr: ErrInvalidFormat,
+		}, {
+			name:   "error CreateShortLinks()",
+			format: FormatNDJSON,
+			input:  `{"destination": "https://example.com"}`,
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			if tt.setup != nil {
+				tt.setup(mock)
+			}
+
+			result, err := usecase.ImportShortLinks(ownerID, tt.format, strings.NewReader(tt.input))
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, result)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected.Total, result.Total)
+			assert.Equal(t, tt.expected.Created, result.Created)
+			assert.Equal(t, tt.expected.Failed, result.Failed)
+
+			lines := make([]int, len(result.Errors))
+			for i, e := range result.Errors {
+				lines[i] = e.Line
+				assert.NotEmpty(t, e.Error)
+			}
+			assert.Equal(t, tt.expectedErrors, lines)
+		})
+	}
+}
+
+func TestShortLinkExportShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	createdAt := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	maxVisits := 10
+
+	firstPage := make([]*models.ShortLink, exportPageSize)
+	for i := range firstPage {
+		firstPage[i] = &models.ShortLink{ID: uuid.New(), SlashCode: "foo", Destination: "https://example.com", CreatedAt: createdAt}
+	}
+	secondPage := []*models.ShortLink{
+		{ID: uuid.New(), SlashCode: "bar", Destination: "https://example.org", RedirectType: 302, Visitors: 3, MaxVisits: &maxVisits, CreatedAt: createdAt, UTM: models.UTM{Source: "newsletter"}, Passthrough: PassthroughLink, OG: models.OpenGraph{Title: "Spring sale"}},
+		{ID: uuid.New(), SlashCode: "baz", Destination: "https://example.net", CreatedAt: createdAt, PasswordHash: "$2a$10$hash"},
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:   "csv",
+			format: FormatCSV,
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().List(gomock.Any()).Return(secondPage, nil)
+			},
+			expected: []string{
+				"slash_code,destination,redirect_type,visitors,max_visits,expires_at,created_at,domain,utm_source,utm_medium,utm_campaign,utm_term,utm_content,query_passthrough,og_title,og_description,og_image,protected",
+				"bar,https://example.org,302,3,10,,2023-10-01T00:00:00Z,,newsletter,,,,,link,Spring sale,,,",
+				"baz,https://example.net,301,0,,,2023-10-01T00:00:00Z,,,,,,,,,,,true",
+			},
+		}, {
+			name:   "ndjson over several pages",
+			format: FormatNDJSON,
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				gomock.InOrder(
+					mr.EXPECT().List(gomock.Any()).DoAndReturn(func(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+						assert.Equal(t, ownerID, filter.OwnerID)
+						assert.Nil(t, filter.After)
+						return firstPage, nil
+					}),
+					mr.EXPECT().List(gomock.Any()).DoAndReturn(func(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+						assert.Equal(t, firstPage[exportPageSize-1].ID, filter.After.ID)
+						return secondPage, nil
+					}),
+				)
+			},
+		}, {
+			name:        "error unknown format",
+			format:      "xml",
+			expectedErr: ErrInvalidFormat,
+		}, {
+			name:   "error List()",
+			format: FormatCSV,
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().List(gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			if tt.setup != nil {
+				tt.setup(mock)
+			}
+
+			var buf bytes.Buffer
+			err := usecase.ExportShortLinks(ownerID, tt.format, &buf)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, lines)
+			} else {
+				assert.Len(t, lines, exportPageSize+2)
+				assert.Contains(t, lines[exportPageSize], `"visitors":3`)
+				assert.Contains(t, lines[exportPageSize], `"utm":{"source":"newsletter"}`)
+				assert.Contains(t, lines[exportPageSize], `"og":{"title":"Spring sale"}`)
+				assert.NotContains(t, lines[0], `"og"`)
+				assert.NotContains(t, lines[0], `"protected"`)
+				assert.Contains(t, lines[exportPageSize+1], `"protected":true`)
+				assert.NotContains(t, lines[exportPageSize+1], "hash")
+			}
+		})
+	}
+}
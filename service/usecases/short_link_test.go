@@ -2,16 +2,25 @@ package usecases
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 	"url-shortener/domain"
 	mockDomain "url-shortener/domain/mocks"
 	"url-shortener/logs"
+	"url-shortener/metrics"
 	"url-shortener/models"
+	"url-shortener/utils/breaker"
+	"url-shortener/utils/slashcode"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -21,15 +30,51 @@ func SetupLogger(t *testing.T) func() {
 	return func() { logs.Close() }
 }
 
+func SetupClickRepositoryMock(ctrl *gomock.Controller) *mockDomain.MockClickRepository {
+	mock := mockDomain.NewMockClickRepository(ctrl)
+	mock.EXPECT().CreateBatch(gomock.Any()).Return(nil).AnyTimes()
+	return mock
+}
+
+func SetupDestinationPolicy(ctrl *gomock.Controller) domain.DestinationPolicyUsecase {
+	mock := mockDomain.NewMockDomainRuleRepository(ctrl)
+	mock.EXPECT().FindAll().Return([]*models.DomainRule{{Domain: "evil.com", Type: models.DomainRuleBlock}}, nil).AnyTimes()
+	return NewDestinationPolicyUsecase(mock, []string{"http", "https"}, false)
+}
+
+// SetupShortLinkRepositoryMock lets every slash code past the filter,
+// accepts announcements of new links and finds no rules or variants.
+func SetupShortLinkRepositoryMock(ctrl *gomock.Controller) *mockDomain.MockShortLinkRepository {
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	mock.EXPECT().MayExist(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	mock.EXPECT().AnnounceShortLinks(gomock.Any()).Return(nil).AnyTimes()
+	mock.EXPECT().FindRules(gomock.Any()).Return([]*models.LinkRule{}, nil).AnyTimes()
+	mock.EXPECT().FindVariants(gomock.Any()).Return([]*models.LinkVariant{}, nil).AnyTimes()
+	return mock
+}
+
+func SetupDomains(ctrl *gomock.Controller) domain.DomainUsecase {
+	mock := mockDomain.NewMockDomainRepository(ctrl)
+	mock.EXPECT().FindAll().Return([]*models.Domain{{Name: "go.example.com"}}, nil).AnyTimes()
+	return NewDomainUsecase(mock)
+}
+
 func TestNewShortLinkUsecase(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mock := mockDomain.NewMockShortLinkRepository(ctrl)
-	usecase := NewShortLinkUsecase(mock)
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
 
 	assert.NotNil(t, usecase.shortLinkRepo)
+	assert.NotNil(t, usecase.clickRepo)
 	assert.NotNil(t, usecase.visitorQueue)
+	assert.Equal(t, int32(slashcode.DefaultLength), usecase.slashCodes.length.Load())
 }
 
 func TestShortLinkCreateShortLink(t *testing.T) {
@@ -39,14 +84,19 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 	closeLog := SetupLogger(t)
 	defer closeLog()
 
+	ownerID := uuid.New()
 	mockData := struct {
 		shortLink *models.ShortLink
 		err       error
 	}{
 		shortLink: &models.ShortLink{
-			ID:          uuid.New(),
-			SlashCode:   "foo",
-			Destination: "https://example.com",
+			ID:              uuid.New(),
+			OwnerID:         &ownerID,
+			SlashCode:       "foo",
+			Destination:     "https://example.com",
+			DestinationHost: "example.com",
+			RedirectType:    301,
+			Passthrough:     PassthroughOff,
 		},
 		err: errors.New("error"),
 	}
@@ -64,7 +114,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).MaxTimes(maxAttempts).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).MaxTimes(maxAttempts).Return(nil, gorm.ErrRecordNotFound)
 				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
 					shortLink.ID = mockData.shortLink.ID
 					shortLink.SlashCode = mockData.shortLink.SlashCode
@@ -72,6 +122,64 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				})
 			},
 			expected: mockData.shortLink,
+		}, {
+			name: "success with password",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:   mockData.shortLink.SlashCode,
+				Destination: mockData.shortLink.Destination,
+				Password:    "secret",
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
+					assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(shortLink.PasswordHash), []byte("secret")))
+					assert.True(t, shortLink.Protected)
+					shortLink.ID = mockData.shortLink.ID
+					shortLink.PasswordHash = ""
+					shortLink.Protected = false
+					return nil
+				})
+			},
+			expected: mockData.shortLink,
+		}, {
+			name: "success on custom domain",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:   mockData.shortLink.SlashCode,
+				Destination: mockData.shortLink.Destination,
+				Domain:      "Go.Example.com",
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("go.example.com", mockData.shortLink.SlashCode).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
+					assert.Equal(t, "go.example.com", shortLink.Domain)
+					shortLink.ID = mockData.shortLink.ID
+					shortLink.Domain = ""
+					return nil
+				})
+			},
+			expected: mockData.shortLink,
+		}, {
+			name: "unregistered domain",
+			request: &domain.CreateShortLinkRequest{
+				Destination: mockData.shortLink.Destination,
+				Domain:      "go.example.org",
+			},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrDomainNotRegistered,
+		}, {
+			name: "blocked destination",
+			request: &domain.CreateShortLinkRequest{
+				Destination: "https://www.evil.com",
+			},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrDomainBlocked,
+		}, {
+			name: "private destination",
+			request: &domain.CreateShortLinkRequest{
+				Destination: "http://127.0.0.1",
+			},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrPrivateDestination,
 		}, {
 			name: "success with custom slash code",
 			request: &domain.CreateShortLinkRequest{
@@ -79,20 +187,70 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
 				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
 					shortLink.ID = mockData.shortLink.ID
 					return nil
 				})
 			},
 			expected: mockData.shortLink,
+		}, {
+			name: "success with redirect type",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:    mockData.shortLink.SlashCode,
+				Destination:  mockData.shortLink.Destination,
+				RedirectType: 302,
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
+					shortLink.ID = mockData.shortLink.ID
+					return nil
+				})
+			},
+			expected: &models.ShortLink{
+				ID:              mockData.shortLink.ID,
+				OwnerID:         &ownerID,
+				SlashCode:       mockData.shortLink.SlashCode,
+				Destination:     mockData.shortLink.Destination,
+				DestinationHost: mockData.shortLink.DestinationHost,
+				RedirectType:    302,
+				Passthrough:     PassthroughOff,
+			},
+		}, {
+			name: "success with utm, passthrough and og",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:   mockData.shortLink.SlashCode,
+				Destination: mockData.shortLink.Destination,
+				UTM:         &domain.UTMParameters{Source: "newsletter", Campaign: "spring"},
+				Passthrough: PassthroughRequest,
+				OG:          &domain.OGParameters{Title: "Spring sale", Image: "https://cdn.example.com/sale.png"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(shortLink *models.ShortLink) error {
+					shortLink.ID = mockData.shortLink.ID
+					return nil
+				})
+			},
+			expected: &models.ShortLink{
+				ID:              mockData.shortLink.ID,
+				OwnerID:         &ownerID,
+				SlashCode:       mockData.shortLink.SlashCode,
+				Destination:     mockData.shortLink.Destination,
+				DestinationHost: mockData.shortLink.DestinationHost,
+				RedirectType:    301,
+				UTM:             models.UTM{Source: "newsletter", Campaign: "spring"},
+				Passthrough:     PassthroughRequest,
+				OG:              models.OpenGraph{Title: "Spring sale", Image: "https://cdn.example.com/sale.png"},
+			},
 		}, {
 			name: "error",
 			request: &domain.CreateShortLinkRequest{
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, gorm.ErrRecordNotFound).MaxTimes(maxAttempts)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound).MaxTimes(maxAttempts)
 				mr.EXPECT().Create(gomock.Any()).Return(mockData.err)
 			},
 			expectedErr: ErrCreateShortLink,
@@ -102,7 +260,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, mockData.err).MinTimes(maxAttempts)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, mockData.err).MinTimes(maxAttempts)
 			},
 			expectedErr: ErrGenerateSlashCode,
 		}, {
@@ -112,7 +270,7 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, mockData.err)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, mockData.err)
 			},
 			expectedErr: ErrUnexpected,
 		}, {
@@ -122,19 +280,40 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 				Destination: mockData.shortLink.Destination,
 			},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(&models.ShortLink{}, nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{}, nil)
+			},
+			expectedErr: ErrSlashCodeExists,
+		}, {
+			name: "error custom slash code is reserved",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:   "Metrics",
+				Destination: mockData.shortLink.Destination,
+			},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrSlashCodeExists,
+		}, {
+			name: "error custom slash code shadowed by export",
+			request: &domain.CreateShortLinkRequest{
+				SlashCode:   "export",
+				Destination: mockData.shortLink.Destination,
 			},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
 			expectedErr: ErrSlashCodeExists,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := mockDomain.NewMockShortLinkRepository(ctrl)
-			usecase := NewShortLinkUsecase(mock)
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
 			tt.setup(mock)
 
-			res, err := usecase.CreateShortLink(tt.request)
+			res, err := usecase.CreateShortLink(ownerID, tt.request)
 			if tt.expectedErr != nil {
 				assert.ErrorIs(t, err, tt.expectedErr)
 				assert.Nil(t, res)
@@ -146,6 +325,264 @@ func TestShortLinkCreateShortLink(t *testing.T) {
 	}
 }
 
+func TestShortLinkGenerateSlashCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	tests := []struct {
+		name           string
+		length         int
+		setup          func(mr *mockDomain.MockShortLinkRepository, mg *mockDomain.MockSlashCodeGenerator)
+		expected       string
+		expectedLength int32
+	}{
+		{
+			name:   "success",
+			length: 6,
+			setup: func(mr *mockDomain.MockShortLinkRepository, mg *mockDomain.MockSlashCodeGenerator) {
+				mg.EXPECT().Generate(6).Return("foobar", nil)
+				mr.EXPECT().FindBySlashCode("", "foobar").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expected:       "foobar",
+			expectedLength: 6,
+		}, {
+			name:   "extend length after repeated collisions",
+			length: 6,
+			setup: func(mr *mockDomain.MockShortLinkRepository, mg *mockDomain.MockSlashCodeGenerator) {
+				mg.EXPECT().Generate(6).Return("foobar", nil).Times(maxAttempts)
+				mr.EXPECT().FindBySlashCode("", "foobar").Return(&models.ShortLink{}, nil).Times(maxAttempts)
+			},
+			expectedLength: 7,
+		}, {
+			name:   "do not extend past the column size",
+			length: slashcode.MaxLength,
+			setup: func(mr *mockDomain.MockShortLinkRepository, mg *mockDomain.MockSlashCodeGenerator) {
+				mg.EXPECT().Generate(slashcode.MaxLength).Return("foobarfoobar", nil).Times(maxAttempts)
+				mr.EXPECT().FindBySlashCode("", "foobarfoobar").Return(&models.ShortLink{}, nil).Times(maxAttempts)
+			},
+			expectedLength: slashcode.MaxLength,
+		}, {
+			name:   "error Generate()",
+			length: 6,
+			setup: func(mr *mockDomain.MockShortLinkRepository, mg *mockDomain.MockSlashCodeGenerator) {
+				mg.EXPECT().Generate(6).Return("", errors.New("error"))
+			},
+			expectedLength: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			generator := mockDomain.NewMockSlashCodeGenerator(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+				Generator:     generator,
+				SlashLength:   tt.length,
+			})
+			tt.setup(mock, generator)
+
+			assert.Equal(t, tt.expected, usecase.generateSlashCode(""))
+			assert.Equal(t, tt.expectedLength, usecase.slashCodes.length.Load())
+		})
+	}
+}
+
+func TestShortLinkUniqueSlashCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+		SlashLength:   1,
+	})
+
+	// A batch larger than the code space makes codes longer.
+	taken := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		_, err := usecase.uniqueSlashCode("", taken)
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, usecase.slashCodes.length.Load(), int32(1))
+
+	// A generator that can't come up with anything new gives up.
+	generator := mockDomain.NewMockSlashCodeGenerator(ctrl)
+	generator.EXPECT().Generate(gomock.Any()).Return("foo", nil).AnyTimes()
+	usecase = NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+		Generator:     generator,
+		SlashLength:   3,
+	})
+	taken = map[string]bool{"/foo": true}
+	_, err := usecase.uniqueSlashCode("", taken)
+	assert.ErrorIs(t, err, ErrGenerateSlashCode)
+}
+
+func TestShortLinkCreateShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	err := errors.New("error")
+
+	tests := []struct {
+		name           string
+		requests       []*domain.CreateShortLinkRequest
+		setup          func(mr *mockDomain.MockShortLinkRepository)
+		expectedErrors []string
+		expectedErr    error
+	}{
+		{
+			name: "success",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://example.com"},
+				{SlashCode: "foo", Destination: "https://example.org", RedirectType: 302},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", gomock.Len(2)).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(2)).Return(nil)
+			},
+			expectedErrors: []string{"", ""},
+		}, {
+			name: "blocked destination",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://evil.com"},
+				{Destination: "https://example.com"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", gomock.Len(1)).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(1)).Return(nil)
+			},
+			expectedErrors: []string{ErrDomainBlocked.Error(), ""},
+		}, {
+			name: "custom slash code taken or duplicated",
+			requests: []*domain.CreateShortLinkRequest{
+				{SlashCode: "foo", Destination: "https://example.com"},
+				{SlashCode: "bar", Destination: "https://example.com"},
+				{SlashCode: "bar", Destination: "https://example.org"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", []string{"foo", "bar"}).Return([]string{"foo"}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(1)).Return(nil)
+			},
+			expectedErrors: []string{ErrSlashCodeExists.Error(), "", ErrSlashCodeExists.Error()},
+		}, {
+			name: "same slash code on different domains",
+			requests: []*domain.CreateShortLinkRequest{
+				{SlashCode: "foo", Destination: "https://example.com"},
+				{SlashCode: "foo", Destination: "https://example.org", Domain: "go.example.com"},
+				{SlashCode: "foo", Destination: "https://example.net", Domain: "go.example.org"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", []string{"foo"}).Return([]string{}, nil)
+				mr.EXPECT().FindExistingSlashCodes("go.example.com", []string{"foo"}).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(2)).Return(nil)
+			},
+			expectedErrors: []string{"", "", ErrDomainNotRegistered.Error()},
+		}, {
+			name: "regenerate colliding slash codes",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://example.com"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				gomock.InOrder(
+					mr.EXPECT().FindExistingSlashCodes("", gomock.Len(1)).DoAndReturn(func(host string, codes []string) ([]string, error) {
+						return codes, nil
+					}),
+					mr.EXPECT().FindExistingSlashCodes("", gomock.Len(1)).Return([]string{}, nil),
+				)
+				mr.EXPECT().CreateBatch(gomock.Len(1)).Return(nil)
+			},
+			expectedErrors: []string{""},
+		}, {
+			name: "error generate slash code",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://example.com"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", gomock.Len(1)).Times(maxAttempts).DoAndReturn(func(host string, codes []string) ([]string, error) {
+					return codes, nil
+				})
+			},
+			expectedErrors: []string{ErrGenerateSlashCode.Error()},
+		}, {
+			name: "error CreateBatch()",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://example.com"},
+				{Destination: "https://example.org"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes("", gomock.Len(2)).Return([]string{}, nil)
+				mr.EXPECT().CreateBatch(gomock.Len(2)).Return(err)
+			},
+			expectedErrors: []string{ErrCreateShortLink.Error(), ErrCreateShortLink.Error()},
+		}, {
+			name: "error FindExistingSlashCodes()",
+			requests: []*domain.CreateShortLinkRequest{
+				{Destination: "https://example.com"},
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindExistingSlashCodes(gomock.Any(), gomock.Any()).Return(nil, err)
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			results, err := usecase.CreateShortLinks(ownerID, tt.requests)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, results)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, results, len(tt.requests))
+			for i, result := range results {
+				assert.Equal(t, i, result.Index)
+				assert.Equal(t, tt.expectedErrors[i], result.Error)
+				if result.Error != "" {
+					assert.Nil(t, result.Data)
+					continue
+				}
+				assert.Equal(t, &ownerID, result.Data.OwnerID)
+				assert.Equal(t, tt.requests[i].Destination, result.Data.Destination)
+				assert.Equal(t, redirectType(tt.requests[i].RedirectType), result.Data.RedirectType)
+				assert.NotEmpty(t, result.Data.SlashCode)
+				if tt.requests[i].SlashCode != "" {
+					assert.Equal(t, tt.requests[i].SlashCode, result.Data.SlashCode)
+				}
+			}
+		})
+	}
+}
+
 func TestShortLinkFindBySlashCode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -154,6 +591,7 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 	defer closeLog()
 
 	slashCode := "foo"
+	ownerID := uuid.New()
 	tests := []struct {
 		name        string
 		setup       func(mr *mockDomain.MockShortLinkRepository)
@@ -163,19 +601,32 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 		{
 			name: "success",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(&models.ShortLink{SlashCode: slashCode}, nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID}, nil)
+			},
+			expected: &models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID},
+		}, {
+			name: "another owner",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				otherID := uuid.New()
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &otherID}, nil)
+			},
+			expectedErr: ErrNotOwner,
+		}, {
+			name: "no owner",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{SlashCode: slashCode}, nil)
 			},
-			expected: &models.ShortLink{SlashCode: slashCode},
+			expectedErr: ErrNotOwner,
 		}, {
 			name: "not found",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
 			},
 			expectedErr: gorm.ErrRecordNotFound,
 		}, {
 			name: "error",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, errors.New("error"))
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 			},
 			expectedErr: ErrUnexpected,
 		},
@@ -183,11 +634,16 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := mockDomain.NewMockShortLinkRepository(ctrl)
-			usecase := NewShortLinkUsecase(mock)
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
 			tt.setup(mock)
 
-			shortLink, err := usecase.FindBySlashCode(slashCode)
+			shortLink, err := usecase.FindBySlashCode(ownerID, "", slashCode)
 			if tt.expectedErr != nil {
 				assert.ErrorIs(t, err, tt.expectedErr)
 				assert.Nil(t, shortLink)
@@ -199,108 +655,1946 @@ func TestShortLinkFindBySlashCode(t *testing.T) {
 	}
 }
 
-func TestShortLinkRedirect(t *testing.T) {
+func TestShortLinkListShortLinks(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	closeLog := SetupLogger(t)
 	defer closeLog()
 
-	mockData := struct {
-		shortLink *models.ShortLink
-		err       error
-	}{
-		shortLink: &models.ShortLink{
-			SlashCode:   "foo",
-			Destination: "https://example.com",
-		},
-		err: errors.New("error"),
+	shortLinks := []*models.ShortLink{
+		{ID: uuid.New(), SlashCode: "foo", CreatedAt: time.Now()},
+		{ID: uuid.New(), SlashCode: "bar", CreatedAt: time.Now()},
+		{ID: uuid.New(), SlashCode: "baz", CreatedAt: time.Now()},
 	}
+	cursor := encodeCursor(&domain.ShortLinkFilter{Sort: "created_at", Desc: true}, shortLinks[1])
+	ownerID := uuid.New()
 
 	tests := []struct {
-		name        string
-		setup       func(mr *mockDomain.MockShortLinkRepository)
-		modUcase    func(u *shortLinkUsecase)
-		expected    string
-		expectedErr error
+		name           string
+		request        *domain.ListShortLinksRequest
+		setup          func(mr *mockDomain.MockShortLinkRepository)
+		expectedLen    int
+		expectedCursor string
+		expectedErr    error
 	}{
 		{
-			name: "redirect with cache hit",
+			name:    "first page",
+			request: &domain.ListShortLinksRequest{Limit: 2},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return(mockData.shortLink.Destination, nil)
-				mr.EXPECT().IncrementVisitor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mr.EXPECT().List(gomock.Any()).DoAndReturn(func(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+					assert.Equal(t, ownerID, filter.OwnerID)
+					assert.Equal(t, "created_at", filter.Sort)
+					assert.True(t, filter.Desc)
+					assert.Equal(t, 3, filter.Limit)
+					assert.Nil(t, filter.After)
+					return shortLinks, nil
+				})
 			},
-			expected: mockData.shortLink.Destination,
+			expectedLen:    2,
+			expectedCursor: cursor,
 		}, {
-			name: "redirect with cache miss",
+			name:    "last page",
+			request: &domain.ListShortLinksRequest{Cursor: cursor, Host: "Example.com"},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return("", redis.Nil)
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(mockData.shortLink, nil)
-				mr.EXPECT().SetShortLinkCache(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-				mr.EXPECT().IncrementVisitor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mr.EXPECT().List(gomock.Any()).DoAndReturn(func(filter *domain.ShortLinkFilter) ([]*models.ShortLink, error) {
+					assert.Equal(t, "example.com", filter.Host)
+					assert.Equal(t, defaultListLimit+1, filter.Limit)
+					assert.Equal(t, shortLinks[1].ID, filter.After.ID)
+					return shortLinks[2:], nil
+				})
 			},
-			expected: mockData.shortLink.Destination,
+			expectedLen: 1,
 		}, {
-			name: "redirect no slash code",
+			name:        "error invalid cursor",
+			request:     &domain.ListShortLinksRequest{Cursor: "invalid"},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrInvalidCursor,
+		}, {
+			name:        "error cursor from another sort",
+			request:     &domain.ListShortLinksRequest{Cursor: cursor, Sort: "visitors"},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrInvalidCursor,
+		}, {
+			name:        "error invalid filter",
+			request:     &domain.ListShortLinksRequest{CreatedFrom: "yesterday"},
+			setup:       func(mr *mockDomain.MockShortLinkRepository) {},
+			expectedErr: ErrInvalidFilter,
+		}, {
+			name:    "error",
+			request: &domain.ListShortLinksRequest{},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return("", redis.Nil)
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().List(gomock.Any()).Return(nil, errors.New("error"))
 			},
-			expectedErr: gorm.ErrRecordNotFound,
-		}, {
-			name: "error increment vistor",
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			page, err := usecase.ListShortLinks(ownerID, tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, page)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, page.Data, tt.expectedLen)
+				assert.Equal(t, tt.expectedCursor, page.NextCursor)
+			}
+		})
+	}
+}
+
+func TestShortLinkUpdateShortLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	slashCode := "foo"
+	ownerID := uuid.New()
+	request := &domain.UpdateShortLinkRequest{Destination: "https://example.org"}
+	tests := []struct {
+		name        string
+		request     *domain.UpdateShortLinkRequest
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expected    *models.ShortLink
+		expectedErr error
+	}{
+		{
+			name: "success",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return(mockData.shortLink.Destination, nil)
-				mr.EXPECT().IncrementVisitor(gomock.Any(), gomock.Any()).Return(mockData.err).AnyTimes()
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: "https://example.com"}, nil)
+				mr.EXPECT().Update(gomock.Any()).Return(nil)
+				mr.EXPECT().DeleteShortLinkCache("", slashCode).Return(nil)
 			},
-			expected: mockData.shortLink.Destination,
+			expected: &models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: request.Destination, DestinationHost: "example.org"},
 		}, {
-			name: "error setShortLinkCache()",
+			name:    "success keeps unset fields and clears empty ones",
+			request: &domain.UpdateShortLinkRequest{RedirectType: 302, UTM: &domain.UTMParameters{}},
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return("", redis.Nil)
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(mockData.shortLink, nil)
-				mr.EXPECT().SetShortLinkCache(gomock.Any(), gomock.Any(), gomock.Any()).Return(mockData.err).AnyTimes()
-				mr.EXPECT().IncrementVisitor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: "https://example.com", DestinationHost: "example.com", UTM: models.UTM{Source: "newsletter"}, OG: models.OpenGraph{Title: "Spring sale"}}, nil)
+				mr.EXPECT().Update(gomock.Any()).Return(nil)
+				mr.EXPECT().DeleteShortLinkCache("", slashCode).Return(nil)
 			},
-			expected: mockData.shortLink.Destination,
+			expected: &models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: "https://example.com", DestinationHost: "example.com", RedirectType: 302, OG: models.OpenGraph{Title: "Spring sale"}},
 		}, {
-			name: "error FindBySlashCode()",
+			name: "success with error DeleteShortLinkCache()",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(gomock.Any()).Return("", redis.Nil)
-				mr.EXPECT().FindBySlashCode(gomock.Any()).Return(nil, mockData.err)
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: "https://example.com"}, nil)
+				mr.EXPECT().Update(gomock.Any()).Return(nil)
+				mr.EXPECT().DeleteShortLinkCache("", slashCode).Return(errors.New("error"))
 			},
-			expectedErr: ErrUnexpected,
+			expected: &models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID, Destination: request.Destination, DestinationHost: "example.org"},
 		}, {
-			name: "test incrementVisitorEnqueue()",
+			name: "not found",
 			setup: func(mr *mockDomain.MockShortLinkRepository) {
-				mr.EXPECT().FindShortLinkCache(mockData.shortLink.SlashCode).Return(mockData.shortLink.Destination, nil)
-				mr.EXPECT().IncrementVisitor(mockData.shortLink.SlashCode, gomock.Any()).Return(nil).AnyTimes()
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(nil, gorm.ErrRecordNotFound)
 			},
-			modUcase: func(u *shortLinkUsecase) {
-				u.visitorQueue.order = append(u.visitorQueue.order, mockData.shortLink.SlashCode)
-				u.visitorQueue.counts[mockData.shortLink.SlashCode] = 1
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID}, nil)
+				mr.EXPECT().Update(gomock.Any()).Return(errors.New("error"))
 			},
-			expected: mockData.shortLink.Destination,
+			expectedErr: ErrUpdateShortLink,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mock := mockDomain.NewMockShortLinkRepository(ctrl)
-			usecase := NewShortLinkUsecase(mock)
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			req := request
+			if tt.request != nil {
+				req = tt.request
+			}
+			shortLink, err := usecase.UpdateShortLink(ownerID, "", slashCode, req)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, shortLink)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, shortLink)
+			}
+		})
+	}
+}
+
+func TestShortLinkDeleteShortLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	slashCode := "foo"
+	ownerID := uuid.New()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID}, nil)
+				mr.EXPECT().Delete(gomock.Any()).Return(nil)
+				mr.EXPECT().DeleteShortLinkCache("", slashCode).Return(nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(&models.ShortLink{SlashCode: slashCode, OwnerID: &ownerID}, nil)
+				mr.EXPECT().Delete(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrDeleteShortLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			err := usecase.DeleteShortLink(ownerID, "", slashCode)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShortLinkRedirect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mockData := struct {
+		shortLink *models.ShortLink
+		cache     *domain.ShortLinkCache
+		visit     *domain.Visit
+		err       error
+	}{
+		shortLink: &models.ShortLink{
+			ID:          uuid.New(),
+			SlashCode:   "foo",
+			Destination: "https://example.com",
+		},
+		cache: &domain.ShortLinkCache{
+			Destination: "https://example.com",
+		},
+		visit: &domain.Visit{
+			Referrer:  "https://example.org",
+			UserAgent: "Mozilla/5.0",
+			IP:        "203.0.113.10",
+		},
+		err: errors.New("error"),
+	}
+
+	tests := []struct {
+		name           string
+		host           string
+		setup          func(mr *mockDomain.MockShortLinkRepository)
+		modUcase       func(u *shortLinkUsecase)
+		expected       string
+		expectedStatus int
+		expectedErr    error
+	}{
+		{
+			name: "redirect with cache hit",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(mockData.cache, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "redirect with cached redirect type",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(&domain.ShortLinkCache{
+					Destination:  mockData.shortLink.Destination,
+					RedirectType: http.StatusFound,
+				}, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected:       mockData.shortLink.Destination,
+			expectedStatus: http.StatusFound,
+		}, {
+			name: "redirect with cache miss",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(mockData.shortLink, nil)
+				mr.EXPECT().SetShortLinkCache("", gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "redirect with cache miss and redirect type",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					ID:           mockData.shortLink.ID,
+					SlashCode:    mockData.shortLink.SlashCode,
+					Destination:  mockData.shortLink.Destination,
+					RedirectType: http.StatusTemporaryRedirect,
+				}, nil)
+				mr.EXPECT().SetShortLinkCache("", mockData.shortLink.SlashCode, gomock.Any(), gomock.Any()).DoAndReturn(func(host string, slashCode string, cache *domain.ShortLinkCache, exp time.Duration) error {
+					assert.Equal(t, http.StatusTemporaryRedirect, cache.RedirectType)
+					return nil
+				}).AnyTimes()
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected:       mockData.shortLink.Destination,
+			expectedStatus: http.StatusTemporaryRedirect,
+		}, {
+			name: "blocked with cache hit",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(&domain.ShortLinkCache{Destination: "https://evil.com"}, nil)
+			},
+			expectedErr: ErrShortLinkBlocked,
+		}, {
+			name: "blocked with cache miss",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					SlashCode:   mockData.shortLink.SlashCode,
+					Destination: "https://evil.com/login",
+				}, nil)
+			},
+			expectedErr: ErrShortLinkBlocked,
+		}, {
+			name: "password protected",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(&domain.ShortLinkCache{
+					Destination:  mockData.shortLink.Destination,
+					PasswordHash: "hash",
+				}, nil)
+			},
+			expectedErr: ErrPasswordRequired,
+		}, {
+			name: "redirect no slash code",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().SetShortLinkCache("", mockData.shortLink.SlashCode, gomock.Any(), notFoundCacheDuration).DoAndReturn(func(host string, slashCode string, cache *domain.ShortLinkCache, exp time.Duration) error {
+					assert.True(t, cache.NotFound)
+					return nil
+				}).AnyTimes()
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "redirect cached not found",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(&domain.ShortLinkCache{NotFound: true}, nil)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error increment vistor",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(mockData.cache, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(mockData.err).AnyTimes()
+				mr.EXPECT().IncrementVisitor(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "error setShortLinkCache()",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(mockData.shortLink, nil)
+				mr.EXPECT().SetShortLinkCache("", gomock.Any(), gomock.Any(), gomock.Any()).Return(mockData.err).AnyTimes()
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "expired by time",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				expiresAt := time.Now().Add(-1 * time.Minute)
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					SlashCode:   mockData.shortLink.SlashCode,
+					Destination: mockData.shortLink.Destination,
+					ExpiresAt:   &expiresAt,
+				}, nil)
+			},
+			expectedErr: ErrShortLinkExpired,
+		}, {
+			name: "expired by max visits",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				maxVisits := 2
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					ID:          mockData.shortLink.ID,
+					SlashCode:   mockData.shortLink.SlashCode,
+					Destination: mockData.shortLink.Destination,
+					Visitors:    1,
+					MaxVisits:   &maxVisits,
+				}, nil)
+				mr.EXPECT().FindPendingVisitors(mockData.shortLink.ID.String()).Return(0, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			modUcase: func(u *shortLinkUsecase) {
+				u.visitorQueue.counts[mockData.shortLink.ID.String()] = 1
+			},
+			expectedErr: ErrShortLinkExpired,
+		}, {
+			name: "redirect with max visits left",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				maxVisits := 2
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					ID:          mockData.shortLink.ID,
+					SlashCode:   mockData.shortLink.SlashCode,
+					Destination: mockData.shortLink.Destination,
+					Visitors:    1,
+					MaxVisits:   &maxVisits,
+				}, nil)
+				mr.EXPECT().FindPendingVisitors(mockData.shortLink.ID.String()).Return(0, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "expired by visits buffered in redis",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				maxVisits := 2
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(&models.ShortLink{
+					ID:          mockData.shortLink.ID,
+					SlashCode:   mockData.shortLink.SlashCode,
+					Destination: mockData.shortLink.Destination,
+					Visitors:    1,
+					MaxVisits:   &maxVisits,
+				}, nil)
+				mr.EXPECT().FindPendingVisitors(mockData.shortLink.ID.String()).Return(1, nil)
+			},
+			expectedErr: ErrShortLinkExpired,
+		}, {
+			name: "error FindBySlashCode()",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, mockData.err)
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "test incrementVisitorEnqueue()",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", mockData.shortLink.SlashCode).Return(mockData.cache, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			modUcase: func(u *shortLinkUsecase) {
+				u.visitorQueue.counts[mockData.shortLink.ID.String()] = 1
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "custom domain",
+			host: "go.example.com",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("go.example.com", mockData.shortLink.SlashCode).Return(mockData.cache, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		}, {
+			name: "unregistered host uses default domain",
+			host: "short.example.net",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", mockData.shortLink.SlashCode).Return(mockData.cache, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+			expected: mockData.shortLink.Destination,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
 			if tt.modUcase != nil {
 				tt.modUcase(usecase)
 			}
 			tt.setup(mock)
 
-			dest, err := usecase.Redirect(mockData.shortLink.SlashCode)
+			redirection, err := usecase.Redirect(tt.host, mockData.shortLink.SlashCode, mockData.visit)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, redirection)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, redirection.Destination)
+
+				expectedStatus := tt.expectedStatus
+				if expectedStatus == 0 {
+					expectedStatus = http.StatusMovedPermanently
+				}
+				assert.Equal(t, expectedStatus, redirection.StatusCode)
+			}
+		})
+	}
+}
+
+func TestShortLinkRedirectMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", Destination: "https://example.com"}
+	visit := &domain.Visit{UserAgent: "Mozilla/5.0", IP: "203.0.113.10"}
+
+	tests := []struct {
+		name     string
+		setup    func(mr *mockDomain.MockShortLinkRepository)
+		result   string
+		dbErrors float64
+	}{
+		{
+			name: "hit",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(&domain.ShortLinkCache{Destination: shortLink.Destination}, nil)
+			},
+			result: "hit",
+		}, {
+			name: "miss",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(shortLink, nil)
+				mr.EXPECT().SetShortLinkCache(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			result: "miss",
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				mr.EXPECT().FindBySlashCode(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			result:   "error",
+			dbErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			cache := metrics.RedirectCache.WithLabelValues(tt.result)
+			before, beforeErrors := testutil.ToFloat64(cache), testutil.ToFloat64(metrics.RedirectErrors)
+
+			usecase.Redirect("", shortLink.SlashCode, visit)
+
+			assert.Equal(t, before+1, testutil.ToFloat64(cache))
+			assert.Equal(t, beforeErrors+tt.dbErrors, testutil.ToFloat64(metrics.RedirectErrors))
+		})
+	}
+}
+
+func TestShortLinkRedirectFiltered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	mock.EXPECT().MayExist("", "foo").Return(false)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	before := testutil.ToFloat64(metrics.RedirectFiltered)
+	redirection, err := usecase.Redirect("", "foo", &domain.Visit{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Nil(t, redirection)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.RedirectFiltered))
+}
+
+func TestShortLinkRedirectRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	past := time.Now().Add(-time.Hour)
+	target := &domain.ShortLinkCache{
+		ID:           uuid.New(),
+		Destination:  "https://example.com",
+		RedirectType: http.StatusFound,
+		Rules: []*models.LinkRule{
+			{EndsAt: &past, Destination: "https://example.com/ended"},
+			{OS: "ios", Destination: "https://apps.apple.com/app/foo"},
+			{OS: "android", Device: "mobile", Destination: "https://play.google.com/store/apps/details?id=foo"},
+			{Countries: []string{"TH", "LA"}, Destination: "https://example.co.th"},
+			{Languages: []string{"ja"}, Destination: "https://example.jp"},
+			{Destination: "https://evil.com"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		visit       *domain.Visit
+		setup       func(mg *mockDomain.MockGeoLocator)
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "ios",
+			visit:    &domain.Visit{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"},
+			expected: "https://apps.apple.com/app/foo",
+		}, {
+			name:     "android phone",
+			visit:    &domain.Visit{UserAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) Mobile Safari/537.36"},
+			expected: "https://play.google.com/store/apps/details?id=foo",
+		}, {
+			name:  "country",
+			visit: &domain.Visit{UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", IP: "203.0.113.10"},
+			setup: func(mg *mockDomain.MockGeoLocator) {
+				mg.EXPECT().Country("203.0.113.10").Return("TH")
+			},
+			expected: "https://example.co.th",
+		}, {
+			name:  "language",
+			visit: &domain.Visit{IP: "203.0.113.10", AcceptLanguage: "ja-JP,ja;q=0.9,en;q=0.8"},
+			setup: func(mg *mockDomain.MockGeoLocator) {
+				mg.EXPECT().Country("203.0.113.10").Return("JP")
+			},
+			expected: "https://example.jp",
+		}, {
+			name:  "blocked destination",
+			visit: &domain.Visit{IP: "203.0.113.10", AcceptLanguage: "en-US,ja;q=0"},
+			setup: func(mg *mockDomain.MockGeoLocator) {
+				mg.EXPECT().Country("203.0.113.10").Return("")
+			},
+			expectedErr: ErrShortLinkBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			mock.EXPECT().FindShortLinkCache("", "foo").Return(target, nil)
+			mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			geo := mockDomain.NewMockGeoLocator(ctrl)
+			if tt.setup != nil {
+				tt.setup(geo)
+			}
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+				Geo:           geo,
+			})
+
+			redirection, err := usecase.Redirect("", "foo", tt.visit)
 			if tt.expectedErr != nil {
 				assert.ErrorIs(t, err, tt.expectedErr)
-				assert.Empty(t, dest)
+				assert.Nil(t, redirection)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, dest)
+				assert.Equal(t, tt.expected, redirection.Destination)
+				assert.Equal(t, http.StatusFound, redirection.StatusCode)
+				assert.True(t, redirection.Private)
 			}
 		})
 	}
 }
+
+func TestShortLinkRedirectPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	target := &domain.ShortLinkCache{
+		ID:           uuid.New(),
+		Destination:  "https://example.com",
+		RedirectType: http.StatusFound,
+		UTM:          &models.UTM{Source: "chat"},
+		OG:           &models.OpenGraph{Title: "Spring sale", Image: "https://cdn.example.com/sale.png"},
+		Rules:        []*models.LinkRule{{Destination: "https://example.com/everyone"}},
+	}
+	crawler := &domain.Visit{UserAgent: "Twitterbot/1.0"}
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	mock.EXPECT().MayExist("", "foo").Return(true).AnyTimes()
+	mock.EXPECT().FindShortLinkCache("", "foo").Return(target, nil).AnyTimes()
+	mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	redirection, err := usecase.Redirect("", "foo", crawler)
+	assert.NoError(t, err)
+	assert.Equal(t, target.OG, redirection.Preview)
+	assert.Equal(t, "https://example.com?utm_source=chat", redirection.Destination)
+	usecase.visitorQueue.mu.Lock()
+	assert.Empty(t, usecase.visitorQueue.clicks)
+	usecase.visitorQueue.mu.Unlock()
+
+	redirection, err = usecase.Redirect("", "foo", &domain.Visit{UserAgent: "Mozilla/5.0"})
+	assert.NoError(t, err)
+	assert.Nil(t, redirection.Preview)
+	assert.Equal(t, "https://example.com/everyone?utm_source=chat", redirection.Destination)
+
+	target.OG = nil
+	redirection, err = usecase.Redirect("", "foo", crawler)
+	assert.NoError(t, err)
+	assert.Nil(t, redirection.Preview)
+}
+
+func TestShortLinkRedirectPrivate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name     string
+		target   *domain.ShortLinkCache
+		expected bool
+	}{
+		{
+			name:   "plain",
+			target: &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com"},
+		}, {
+			name:     "rules",
+			target:   &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com", Rules: []*models.LinkRule{{OS: "ios", Destination: "https://example.com/ios"}}},
+			expected: true,
+		}, {
+			name:     "variants",
+			target:   &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com", Variants: []*models.LinkVariant{{ID: uuid.New(), Destination: "https://example.com/a", Weight: 1}}},
+			expected: true,
+		}, {
+			name:     "preview",
+			target:   &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com", OG: &models.OpenGraph{Title: "Spring sale"}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			mock.EXPECT().FindShortLinkCache("", "foo").Return(tt.target, nil)
+			mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+
+			redirection, err := usecase.Redirect("", "foo", &domain.Visit{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, redirection.Private)
+		})
+	}
+}
+
+func TestShortLinkRedirectCachesRulesAndVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", Destination: "https://example.com"}
+	rules := []*models.LinkRule{{OS: "ios", Destination: "https://apps.apple.com/app/foo"}}
+	variants := []*models.LinkVariant{{ID: uuid.New(), Destination: "https://example.com/a", Weight: 1}}
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	mock.EXPECT().MayExist("", "foo").Return(true).Times(2)
+	mock.EXPECT().FindShortLinkCache("", "foo").Return(nil, redis.Nil).Times(2)
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil).Times(2)
+	gomock.InOrder(
+		mock.EXPECT().FindRules(shortLink.ID).Return(rules, nil),
+		mock.EXPECT().FindRules(shortLink.ID).Return(nil, errors.New("error")),
+	)
+	mock.EXPECT().FindVariants(shortLink.ID).Return(variants, nil)
+	mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+	cached := make(chan *domain.ShortLinkCache, 1)
+	mock.EXPECT().SetShortLinkCache("", "foo", gomock.Any(), cacheDuration).DoAndReturn(func(host string, slashCode string, cache *domain.ShortLinkCache, exp time.Duration) error {
+		cached <- cache
+		return nil
+	})
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	redirection, err := usecase.Redirect("", "foo", &domain.Visit{UserAgent: "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X)"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://apps.apple.com/app/foo", redirection.Destination)
+	cache := <-cached
+	assert.Equal(t, rules, cache.Rules)
+	assert.Equal(t, variants, cache.Variants)
+
+	_, err = usecase.Redirect("", "foo", &domain.Visit{})
+	assert.ErrorIs(t, err, ErrUnexpected)
+}
+
+func TestShortLinkFindRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", OwnerID: &ownerID}
+	rules := []*models.LinkRule{{OS: "ios", Destination: "https://apps.apple.com/app/foo"}}
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().FindRules(shortLink.ID).Return(rules, nil)
+	found, err := usecase.FindRules(ownerID, "", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, rules, found)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	_, err = usecase.FindRules(uuid.New(), "", "foo")
+	assert.ErrorIs(t, err, ErrNotOwner)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().FindRules(shortLink.ID).Return(nil, errors.New("error"))
+	_, err = usecase.FindRules(ownerID, "", "foo")
+	assert.ErrorIs(t, err, ErrUnexpected)
+}
+
+func TestShortLinkReplaceRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", OwnerID: &ownerID}
+	startsAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		request     *domain.ReplaceLinkRulesRequest
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			request: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{OS: "ios", Destination: "https://apps.apple.com/app/foo"},
+				{Countries: []string{"th"}, Languages: []string{"TH"}, StartsAt: &startsAt, EndsAt: &endsAt, Destination: "https://example.co.th"},
+			}},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mr.EXPECT().ReplaceRules(shortLink.ID, gomock.Any()).DoAndReturn(func(shortLinkID uuid.UUID, rules []*models.LinkRule) error {
+					assert.Len(t, rules, 2)
+					assert.Equal(t, 0, rules[0].Position)
+					assert.Equal(t, "ios", rules[0].OS)
+					assert.Equal(t, 1, rules[1].Position)
+					assert.Equal(t, shortLinkID, rules[1].ShortLinkID)
+					assert.Equal(t, []string{"TH"}, rules[1].Countries)
+					assert.Equal(t, []string{"th"}, rules[1].Languages)
+					return nil
+				})
+				mr.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+			},
+		}, {
+			name:    "success clearing rules",
+			request: &domain.ReplaceLinkRulesRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mr.EXPECT().ReplaceRules(shortLink.ID, gomock.Len(0)).Return(nil)
+				mr.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+			},
+		}, {
+			name: "error invalid time window",
+			request: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{StartsAt: &endsAt, EndsAt: &startsAt, Destination: "https://example.com"},
+			}},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+			},
+			expectedErr: ErrInvalidTimeWindow,
+		}, {
+			name: "error blocked destination",
+			request: &domain.ReplaceLinkRulesRequest{Rules: []*domain.LinkRuleRequest{
+				{Destination: "https://evil.com"},
+			}},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+			},
+			expectedErr: ErrDomainBlocked,
+		}, {
+			name:    "error ReplaceRules()",
+			request: &domain.ReplaceLinkRulesRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mr.EXPECT().ReplaceRules(shortLink.ID, gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrUpdateShortLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockShortLinkRepository(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			rules, err := usecase.ReplaceRules(ownerID, "", "foo", tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, rules)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, rules, len(tt.request.Rules))
+			}
+		})
+	}
+}
+
+func TestShortLinkChooseDestination(t *testing.T) {
+	target := &domain.ShortLinkCache{
+		ID:          uuid.New(),
+		Destination: "https://example.com",
+		Rules:       []*models.LinkRule{{OS: "ios", Destination: "https://apps.apple.com/app/foo"}},
+		Variants: []*models.LinkVariant{
+			{ID: uuid.New(), Destination: "https://example.com/a", Weight: 70},
+			{ID: uuid.New(), Destination: "https://example.com/b", Weight: 30},
+		},
+	}
+	usecase := &shortLinkUsecase{}
+
+	dest, variantID := usecase.chooseDestination(target, &domain.Visit{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"})
+	assert.Equal(t, "https://apps.apple.com/app/foo", dest)
+	assert.Nil(t, variantID)
+
+	counts := map[uuid.UUID]int{}
+	for i := 0; i < 10000; i++ {
+		visit := &domain.Visit{IP: fmt.Sprintf("203.0.%d.%d", i/256, i%256), UserAgent: "Mozilla/5.0"}
+		dest, variantID := usecase.chooseDestination(target, visit)
+		counts[*variantID]++
+
+		again, _ := usecase.chooseDestination(target, visit)
+		assert.Equal(t, dest, again)
+	}
+	assert.InDelta(t, 7000, counts[target.Variants[0].ID], 300)
+	assert.InDelta(t, 3000, counts[target.Variants[1].ID], 300)
+
+	dest, variantID = usecase.chooseDestination(&domain.ShortLinkCache{Destination: "https://example.com"}, &domain.Visit{})
+	assert.Equal(t, "https://example.com", dest)
+	assert.Nil(t, variantID)
+}
+
+func TestShortLinkFindVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", OwnerID: &ownerID}
+	variants := []*models.LinkVariant{{ID: uuid.New(), Destination: "https://example.com/a", Weight: 1}}
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().FindVariants(shortLink.ID).Return(variants, nil)
+	found, err := usecase.FindVariants(ownerID, "", "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, variants, found)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	_, err = usecase.FindVariants(uuid.New(), "", "foo")
+	assert.ErrorIs(t, err, ErrNotOwner)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().FindVariants(shortLink.ID).Return(nil, errors.New("error"))
+	_, err = usecase.FindVariants(ownerID, "", "foo")
+	assert.ErrorIs(t, err, ErrUnexpected)
+}
+
+func TestShortLinkReplaceVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), SlashCode: "foo", OwnerID: &ownerID}
+
+	tests := []struct {
+		name        string
+		request     *domain.ReplaceLinkVariantsRequest
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			request: &domain.ReplaceLinkVariantsRequest{Variants: []*domain.LinkVariantRequest{
+				{Destination: "https://example.com/a", Weight: 70},
+				{Destination: "https://example.com/b", Weight: 30},
+			}},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mr.EXPECT().ReplaceVariants(shortLink.ID, gomock.Any()).DoAndReturn(func(shortLinkID uuid.UUID, variants []*models.LinkVariant) error {
+					assert.Len(t, variants, 2)
+					assert.Equal(t, 0, variants[0].Position)
+					assert.Equal(t, 70, variants[0].Weight)
+					assert.Equal(t, 1, variants[1].Position)
+					assert.Equal(t, shortLinkID, variants[1].ShortLinkID)
+					assert.NotEqual(t, uuid.Nil, variants[1].ID)
+					return nil
+				})
+				mr.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+			},
+		}, {
+			name: "error blocked destination",
+			request: &domain.ReplaceLinkVariantsRequest{Variants: []*domain.LinkVariantRequest{
+				{Destination: "https://evil.com", Weight: 1},
+			}},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+			},
+			expectedErr: ErrDomainBlocked,
+		}, {
+			name:    "error ReplaceVariants()",
+			request: &domain.ReplaceLinkVariantsRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mr.EXPECT().ReplaceVariants(shortLink.ID, gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrUpdateShortLink,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockShortLinkRepository(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			variants, err := usecase.ReplaceVariants(ownerID, "", "foo", tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, variants)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, variants, len(tt.request.Variants))
+			}
+		})
+	}
+}
+
+func TestAcceptedLanguages(t *testing.T) {
+	assert.Equal(t, []string{"th-th", "th", "en"}, acceptedLanguages("th-TH, th;q=0.9, en;q=0.8, fr;q=0, *;q=0.1"))
+	assert.Empty(t, acceptedLanguages(""))
+}
+
+func TestShortLinkRefreshSlashCodeFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().LoadSlashCodeFilter(filterMaxAge).Return(nil)
+	assert.NoError(t, usecase.RefreshSlashCodeFilter())
+
+	mock.EXPECT().LoadSlashCodeFilter(filterMaxAge).Return(errors.New("error"))
+	assert.ErrorIs(t, usecase.RefreshSlashCodeFilter(), ErrUnexpected)
+}
+
+func TestShortLinkClaimUnownedShortLinks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().ClaimUnowned(ownerID).Return(int64(2), nil)
+	claimed, err := usecase.ClaimUnownedShortLinks(ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), claimed)
+
+	mock.EXPECT().ClaimUnowned(ownerID).Return(int64(0), errors.New("error"))
+	_, err = usecase.ClaimUnownedShortLinks(ownerID)
+	assert.ErrorIs(t, err, ErrUnexpected)
+}
+
+func TestShortLinkRetryCacheInvalidations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().RetryCacheInvalidations().Return(nil)
+	assert.NoError(t, usecase.RetryCacheInvalidations())
+
+	mock.EXPECT().RetryCacheInvalidations().Return(breaker.ErrOpen)
+	assert.ErrorIs(t, usecase.RetryCacheInvalidations(), ErrUnexpected)
+}
+
+func TestShortLinkUnlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	slashCode := "foo"
+	cache := &domain.ShortLinkCache{
+		ID:           uuid.New(),
+		Destination:  "https://example.com",
+		PasswordHash: string(hash),
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expectedErr error
+	}{
+		{
+			name:     "success",
+			password: "secret",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(maxFailedUnlocks-1, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+		}, {
+			name:     "not protected",
+			password: "",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(&domain.ShortLinkCache{Destination: cache.Destination}, nil)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+		}, {
+			name:     "invalid password",
+			password: "guess",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(0, nil)
+				mr.EXPECT().IncrementFailedUnlocks(cache.ID, unlockWindow).Return(nil)
+			},
+			expectedErr: ErrInvalidPassword,
+		}, {
+			name:     "invalid password with error IncrementFailedUnlocks()",
+			password: "guess",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(0, nil)
+				mr.EXPECT().IncrementFailedUnlocks(cache.ID, unlockWindow).Return(errors.New("error"))
+			},
+			expectedErr: ErrInvalidPassword,
+		}, {
+			name:     "too many attempts",
+			password: "secret",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(maxFailedUnlocks, nil)
+			},
+			expectedErr: ErrTooManyAttempts,
+		}, {
+			name:     "success with error CountFailedUnlocks()",
+			password: "secret",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(0, breaker.ErrOpen)
+				mr.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+			},
+		}, {
+			name:     "invalid password with error CountFailedUnlocks()",
+			password: "guess",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(cache, nil)
+				mr.EXPECT().CountFailedUnlocks(cache.ID).Return(0, errors.New("error"))
+				mr.EXPECT().IncrementFailedUnlocks(cache.ID, unlockWindow).Return(breaker.ErrOpen)
+			},
+			expectedErr: ErrInvalidPassword,
+		}, {
+			name:     "not found",
+			password: "secret",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().FindShortLinkCache("", slashCode).Return(nil, redis.Nil)
+				mr.EXPECT().FindBySlashCode("", slashCode).Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().SetShortLinkCache("", slashCode, gomock.Any(), notFoundCacheDuration).Return(nil).AnyTimes()
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			redirection, err := usecase.Unlock("", slashCode, tt.password, &domain.Visit{})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, redirection)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, cache.Destination, redirection.Destination)
+			}
+		})
+	}
+}
+
+func TestShortLinkUnlockWithoutRedis(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	cache := &domain.ShortLinkCache{ID: uuid.New(), Destination: "https://example.com", PasswordHash: string(hash)}
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	mock.EXPECT().FindShortLinkCache("", "foo").Return(cache, nil).AnyTimes()
+	mock.EXPECT().CountFailedUnlocks(cache.ID).Return(0, breaker.ErrOpen).AnyTimes()
+	mock.EXPECT().IncrementFailedUnlocks(cache.ID, unlockWindow).Return(breaker.ErrOpen).Times(maxFailedUnlocks)
+	mock.EXPECT().IncrementPendingVisitors(gomock.Any()).Return(nil).AnyTimes()
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	redirection, err := usecase.Unlock("", "foo", "secret", &domain.Visit{})
+	assert.NoError(t, err)
+	assert.Equal(t, cache.Destination, redirection.Destination)
+
+	// Attempts are still limited, by this replica alone.
+	for i := 0; i < maxFailedUnlocks; i++ {
+		_, err := usecase.Unlock("", "foo", "guess", &domain.Visit{})
+		assert.ErrorIs(t, err, ErrInvalidPassword)
+	}
+	_, err = usecase.Unlock("", "foo", "secret", &domain.Visit{})
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+}
+
+func TestShortLinkCacheExpiration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(10 * time.Minute)
+	maxVisits := 10
+
+	tests := []struct {
+		name      string
+		shortLink *models.ShortLink
+		max       time.Duration
+		min       time.Duration
+	}{
+		{
+			name:      "no expiration",
+			shortLink: &models.ShortLink{},
+			max:       cacheDuration,
+			min:       cacheDuration,
+		}, {
+			name:      "expires before cache duration",
+			shortLink: &models.ShortLink{ExpiresAt: &expiresAt},
+			max:       10 * time.Minute,
+			min:       9 * time.Minute,
+		}, {
+			name:      "max visits",
+			shortLink: &models.ShortLink{MaxVisits: &maxVisits},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+
+			exp := usecase.cacheExpiration(tt.shortLink)
+			assert.LessOrEqual(t, exp, tt.max)
+			assert.GreaterOrEqual(t, exp, tt.min)
+		})
+	}
+}
+
+func TestShortLinkGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), OwnerID: &ownerID, SlashCode: "foo"}
+	buckets := []*domain.StatsBucket{{Count: 2}, {Count: 3}}
+
+	tests := []struct {
+		name          string
+		request       *domain.StatsRequest
+		setup         func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository)
+		expectedTotal int
+		expectedErr   error
+	}{
+		{
+			name:    "default range",
+			request: &domain.StatsRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mc.EXPECT().CountByInterval(shortLink.ID, "day", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(id uuid.UUID, interval string, from time.Time, to time.Time) ([]*domain.StatsBucket, error) {
+						assert.Equal(t, to.AddDate(0, 0, -defaultStatsDays), from)
+						return buckets, nil
+					})
+			},
+			expectedTotal: 5,
+		}, {
+			name: "custom range",
+			request: &domain.StatsRequest{
+				Interval: "hour",
+				From:     "2023-10-01T00:00:00Z",
+				To:       "2023-10-02T00:00:00Z",
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mc.EXPECT().CountByInterval(shortLink.ID, "hour",
+					time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC),
+				).Return(buckets[:1], nil)
+			},
+			expectedTotal: 2,
+		}, {
+			name: "error invalid range",
+			request: &domain.StatsRequest{
+				From: "2023-10-02T00:00:00Z",
+				To:   "2023-10-01T00:00:00Z",
+			},
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+			},
+			expectedErr: ErrInvalidFilter,
+		}, {
+			name:    "not found",
+			request: &domain.StatsRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name:    "error",
+			request: &domain.StatsRequest{},
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+				mc.EXPECT().CountByInterval(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			mockClick := mockDomain.NewMockClickRepository(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     mockClick,
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock, mockClick)
+
+			stats, err := usecase.GetStats(ownerID, "", "foo", tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, stats)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedTotal, stats.Total)
+			}
+		})
+	}
+}
+
+func TestShortLinkGetStatsVariants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), OwnerID: &ownerID, SlashCode: "foo"}
+	variants := []*models.LinkVariant{
+		{ID: uuid.New(), Destination: "https://example.com/a", Weight: 70},
+		{ID: uuid.New(), Destination: "https://example.com/b", Weight: 30},
+	}
+
+	mock := mockDomain.NewMockShortLinkRepository(ctrl)
+	mockClick := mockDomain.NewMockClickRepository(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     mockClick,
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil).Times(2)
+	mock.EXPECT().FindVariants(shortLink.ID).Return(variants, nil).Times(2)
+	mockClick.EXPECT().CountByInterval(shortLink.ID, "day", gomock.Any(), gomock.Any()).Return([]*domain.StatsBucket{{Count: 9}}, nil).Times(2)
+	gomock.InOrder(
+		mockClick.EXPECT().CountByVariant(shortLink.ID, gomock.Any(), gomock.Any()).Return(map[uuid.UUID]int{variants[0].ID: 6}, nil),
+		mockClick.EXPECT().CountByVariant(shortLink.ID, gomock.Any(), gomock.Any()).Return(nil, errors.New("error")),
+	)
+
+	stats, err := usecase.GetStats(ownerID, "", "foo", &domain.StatsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.VariantStats{
+		{ID: variants[0].ID, Destination: "https://example.com/a", Weight: 70, Count: 6},
+		{ID: variants[1].ID, Destination: "https://example.com/b", Weight: 30, Count: 0},
+	}, stats.Variants)
+
+	_, err = usecase.GetStats(ownerID, "", "foo", &domain.StatsRequest{})
+	assert.ErrorIs(t, err, ErrUnexpected)
+}
+
+func TestShortLinkIncrementVisitorQueueWorker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	shortLinkID := uuid.New()
+
+	tests := []struct {
+		name  string
+		setup func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository)
+	}{
+		{
+			name: "buffer in redis",
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().IncrementPendingVisitors(map[string]int{shortLinkID.String(): 2}).Return(nil)
+				mc.EXPECT().CreateBatch(gomock.Len(2)).Return(errors.New("error"))
+			},
+		}, {
+			name: "fall back to database",
+			setup: func(mr *mockDomain.MockShortLinkRepository, mc *mockDomain.MockClickRepository) {
+				mr.EXPECT().IncrementPendingVisitors(map[string]int{shortLinkID.String(): 2}).Return(errors.New("error"))
+				mr.EXPECT().IncrementVisitor(shortLinkID, 2).Return(nil)
+				mc.EXPECT().CreateBatch(gomock.Len(2)).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			mockClick := mockDomain.NewMockClickRepository(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     mockClick,
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock, mockClick)
+
+			usecase.visitorQueue.counts[shortLinkID.String()] = 2
+			usecase.visitorQueue.clicks = []*models.Click{
+				newClick(shortLinkID, &domain.Visit{}),
+				newClick(shortLinkID, &domain.Visit{}),
+			}
+
+			usecase.incrementVisitorQueueWorker()
+
+			assert.False(t, usecase.visitorQueue.isRunning)
+			assert.Empty(t, usecase.visitorQueue.counts)
+			assert.Empty(t, usecase.visitorQueue.clicks)
+		})
+	}
+}
+
+func TestShortLinkFlushVisitors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	id := uuid.New()
+	err := errors.New("error")
+
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockShortLinkRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().TakePendingVisitors(flushLease).Return(map[string]int{id.String(): 3}, nil)
+				mr.EXPECT().IncrementVisitor(id, 3).Return(nil)
+				mr.EXPECT().AckPendingVisitors(id.String()).Return(nil)
+				mr.EXPECT().ReleasePendingVisitors().Return(nil)
+			},
+		}, {
+			name: "nothing pending",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().TakePendingVisitors(flushLease).Return(map[string]int{}, nil)
+			},
+		}, {
+			name: "error TakePendingVisitors()",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().TakePendingVisitors(flushLease).Return(nil, err)
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error IncrementVisitor() keeps counts pending",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().TakePendingVisitors(flushLease).Return(map[string]int{id.String(): 3}, nil)
+				mr.EXPECT().IncrementVisitor(id, 3).Return(err)
+				mr.EXPECT().ReleasePendingVisitors().Return(nil)
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "invalid key is dropped",
+			setup: func(mr *mockDomain.MockShortLinkRepository) {
+				mr.EXPECT().TakePendingVisitors(flushLease).Return(map[string]int{"foo": 3}, nil)
+				mr.EXPECT().AckPendingVisitors("foo").Return(nil)
+				mr.EXPECT().ReleasePendingVisitors().Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := SetupShortLinkRepositoryMock(ctrl)
+			usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+				ShortLinkRepo: mock,
+				ClickRepo:     SetupClickRepositoryMock(ctrl),
+				Policy:        SetupDestinationPolicy(ctrl),
+				Domains:       SetupDomains(ctrl),
+			})
+			tt.setup(mock)
+
+			err := usecase.FlushVisitors()
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShortLinkDrainVisitors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+	})
+	id := uuid.New()
+	usecase.visitorQueue.counts[id.String()] = 1
+
+	gomock.InOrder(
+		mock.EXPECT().IncrementPendingVisitors(map[string]int{id.String(): 1}).Return(nil),
+		mock.EXPECT().TakePendingVisitors(flushLease).Return(map[string]int{id.String(): 1}, nil),
+		mock.EXPECT().IncrementVisitor(id, 1).Return(nil),
+		mock.EXPECT().AckPendingVisitors(id.String()).Return(nil),
+		mock.EXPECT().ReleasePendingVisitors().Return(nil),
+	)
+
+	assert.NoError(t, usecase.DrainVisitors())
+	assert.Empty(t, usecase.visitorQueue.counts)
+}
+
+func TestShortLinkPublishesChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{SlashCode: "foo", OwnerID: &ownerID, Destination: "https://example.com"}
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	webhooks := mockDomain.NewMockWebhookUsecase(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+		Webhooks:      webhooks,
+	})
+
+	published := func(event string) func(events ...*domain.WebhookEvent) error {
+		return func(events ...*domain.WebhookEvent) error {
+			assert.Len(t, events, 1)
+			assert.Equal(t, event, events[0].Type)
+			assert.Equal(t, "foo", events[0].Link.SlashCode)
+			return nil
+		}
+	}
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(nil, gorm.ErrRecordNotFound)
+	mock.EXPECT().Create(gomock.Any()).Return(nil)
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(published(EventLinkCreated))
+	_, err := usecase.CreateShortLink(ownerID, &domain.CreateShortLinkRequest{SlashCode: "foo", Destination: "https://example.com"})
+	assert.NoError(t, err)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().Update(shortLink).Return(nil)
+	mock.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(published(EventLinkUpdated))
+	_, err = usecase.UpdateShortLink(ownerID, "", "foo", &domain.UpdateShortLinkRequest{Destination: "https://example.com/new"})
+	assert.NoError(t, err)
+
+	// Rules and variants change where visitors are sent as well.
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().ReplaceRules(shortLink.ID, gomock.Any()).Return(nil)
+	mock.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(published(EventLinkUpdated))
+	_, err = usecase.ReplaceRules(ownerID, "", "foo", &domain.ReplaceLinkRulesRequest{})
+	assert.NoError(t, err)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().ReplaceVariants(shortLink.ID, gomock.Any()).Return(nil)
+	mock.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(published(EventLinkUpdated))
+	_, err = usecase.ReplaceVariants(ownerID, "", "foo", &domain.ReplaceLinkVariantsRequest{})
+	assert.NoError(t, err)
+
+	mock.EXPECT().FindBySlashCode("", "foo").Return(shortLink, nil)
+	mock.EXPECT().Delete(shortLink).Return(nil)
+	mock.EXPECT().DeleteShortLinkCache("", "foo").Return(nil)
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(published(EventLinkDeleted))
+	assert.NoError(t, usecase.DeleteShortLink(ownerID, "", "foo"))
+}
+
+func TestShortLinkPublishVisitors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	webhooks := mockDomain.NewMockWebhookUsecase(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+		Webhooks:      webhooks,
+	})
+	shortLink := &models.ShortLink{ID: uuid.New(), Visitors: 95}
+	usecase.visitorQueue.counts[shortLink.ID.String()] = 5
+
+	// 7 visits are waiting in Redis, the 5 just counted among them.
+	gomock.InOrder(
+		mock.EXPECT().IncrementPendingVisitors(map[string]int{shortLink.ID.String(): 5}).Return(nil),
+		webhooks.EXPECT().SubscribesToVisitors().Return(true),
+		mock.EXPECT().FindPendingVisitorsByIDs([]string{shortLink.ID.String()}).Return(map[string]int{shortLink.ID.String(): 7}, nil),
+		mock.EXPECT().FindByIDs([]uuid.UUID{shortLink.ID}).Return([]*models.ShortLink{shortLink}, nil),
+		webhooks.EXPECT().PublishVisitors(shortLink, 97, 102).Return(nil),
+	)
+
+	assert.True(t, usecase.flushVisitorQueue())
+
+	// Without subscribers neither Redis nor MySQL is asked.
+	usecase.visitorQueue.counts[shortLink.ID.String()] = 1
+	gomock.InOrder(
+		mock.EXPECT().IncrementPendingVisitors(map[string]int{shortLink.ID.String(): 1}).Return(nil),
+		webhooks.EXPECT().SubscribesToVisitors().Return(false),
+	)
+
+	assert.True(t, usecase.flushVisitorQueue())
+}
+
+func TestShortLinkPublishExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), OwnerID: &ownerID}
+
+	mock := SetupShortLinkRepositoryMock(ctrl)
+	webhooks := mockDomain.NewMockWebhookUsecase(ctrl)
+	usecase := NewShortLinkUsecase(ShortLinkUsecaseOptions{
+		ShortLinkRepo: mock,
+		ClickRepo:     SetupClickRepositoryMock(ctrl),
+		Policy:        SetupDestinationPolicy(ctrl),
+		Domains:       SetupDomains(ctrl),
+		Webhooks:      webhooks,
+	})
+
+	var until time.Time
+	mock.EXPECT().FindExpired(gomock.Any(), gomock.Any()).DoAndReturn(func(from time.Time, to time.Time) ([]*models.ShortLink, error) {
+		assert.WithinDuration(t, time.Now().Add(-expiredLookback), from, time.Second)
+		until = to
+		return []*models.ShortLink{shortLink}, nil
+	})
+	webhooks.EXPECT().Publish(gomock.Any()).DoAndReturn(func(events ...*domain.WebhookEvent) error {
+		assert.Equal(t, EventLinkExpired, events[0].Type)
+		assert.Equal(t, "link.expired:"+shortLink.ID.String(), events[0].Key)
+		return nil
+	})
+	assert.NoError(t, usecase.PublishExpired())
+
+	// A failed scan is retried from the same point.
+	mock.EXPECT().FindExpired(gomock.Any(), gomock.Any()).DoAndReturn(func(from time.Time, to time.Time) ([]*models.ShortLink, error) {
+		assert.Equal(t, until, from)
+		return nil, errors.New("error")
+	})
+	assert.ErrorIs(t, usecase.PublishExpired(), ErrUnexpected)
+
+	mock.EXPECT().FindExpired(until, gomock.Any()).Return([]*models.ShortLink{}, nil)
+	webhooks.EXPECT().Publish().Return(nil)
+	assert.NoError(t, usecase.PublishExpired())
+}
+
+func TestBuildDestination(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   *domain.ShortLinkCache
+		query    string
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			target:   &domain.ShortLinkCache{Destination: "https://example.com/a?b=1&a=2"},
+			query:    "ref=ad",
+			expected: "https://example.com/a?b=1&a=2",
+		}, {
+			name: "utm",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com/a?utm_medium=email#top",
+				UTM:         &models.UTM{Source: "newsletter", Medium: "social", Campaign: "spring"},
+			},
+			expected: "https://example.com/a?utm_medium=email&utm_source=newsletter&utm_campaign=spring#top",
+		}, {
+			name: "passthrough off",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com",
+				Passthrough: PassthroughOff,
+			},
+			query:    "ref=ad",
+			expected: "https://example.com",
+		}, {
+			name: "passthrough link wins",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com?ref=link",
+				UTM:         &models.UTM{Source: "newsletter"},
+				Passthrough: PassthroughLink,
+			},
+			query:    "ref=ad&utm_source=twitter&page=2",
+			expected: "https://example.com?ref=link&utm_source=newsletter&page=2",
+		}, {
+			name: "passthrough request wins",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com?ref=link",
+				UTM:         &models.UTM{Source: "newsletter"},
+				Passthrough: PassthroughRequest,
+			},
+			query:    "ref=ad&utm_source=twitter&page=2",
+			expected: "https://example.com?ref=link&utm_source=twitter&page=2",
+		}, {
+			name: "malformed query",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com",
+				Passthrough: PassthroughRequest,
+			},
+			query:    "a=%zz&b=2",
+			expected: "https://example.com?b=2",
+		}, {
+			name: "signed destination",
+			target: &domain.ShortLinkCache{
+				Destination: "https://bucket.s3.amazonaws.com/key?X-Amz-Signature=ab%2Fcd&X-Amz-Date=20231001T000000Z&b=2",
+				UTM:         &models.UTM{Source: "news letter"},
+				Passthrough: PassthroughRequest,
+			},
+			query:    "b=3&page=2",
+			expected: "https://bucket.s3.amazonaws.com/key?X-Amz-Signature=ab%2Fcd&X-Amz-Date=20231001T000000Z&b=2&utm_source=news+letter&page=2",
+		}, {
+			name: "nothing to add",
+			target: &domain.ShortLinkCache{
+				Destination: "https://example.com/a?z=1&a=2",
+				UTM:         &models.UTM{},
+				Passthrough: PassthroughLink,
+			},
+			query:    "z=3",
+			expected: "https://example.com/a?z=1&a=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildDestination(tt.target.Destination, tt.target, tt.query))
+		})
+	}
+}
+
+func TestNewClick(t *testing.T) {
+	tests := []struct {
+		name     string
+		visit    *domain.Visit
+		expected *models.Click
+	}{
+		{
+			name: "ipv4",
+			visit: &domain.Visit{
+				Referrer:       "https://example.org",
+				UserAgent:      "Mozilla/5.0",
+				IP:             "203.0.113.10",
+				AcceptLanguage: "th-TH,th;q=0.9",
+			},
+			expected: &models.Click{
+				Referrer:       "https://example.org",
+				UserAgent:      "Mozilla/5.0",
+				IPPrefix:       "203.0.113.0/24",
+				AcceptLanguage: "th-TH,th;q=0.9",
+			},
+		}, {
+			name:     "ipv6",
+			visit:    &domain.Visit{IP: "2001:db8:1234:5678::1"},
+			expected: &models.Click{IPPrefix: "2001:db8:1234::/48"},
+		}, {
+			name:     "invalid ip",
+			visit:    &domain.Visit{IP: "foo"},
+			expected: &models.Click{},
+		}, {
+			name:     "long user agent",
+			visit:    &domain.Visit{UserAgent: strings.Repeat("a", 600)},
+			expected: &models.Click{UserAgent: strings.Repeat("a", 512)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortLinkID := uuid.New()
+			click := newClick(shortLinkID, tt.visit)
+
+			assert.Equal(t, shortLinkID, click.ShortLinkID)
+			assert.False(t, click.ClickedAt.IsZero())
+			assert.Equal(t, tt.expected.Referrer, click.Referrer)
+			assert.Equal(t, tt.expected.UserAgent, click.UserAgent)
+			assert.Equal(t, tt.expected.IPPrefix, click.IPPrefix)
+			assert.Equal(t, tt.expected.AcceptLanguage, click.AcceptLanguage)
+		})
+	}
+}
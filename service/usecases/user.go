@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"errors"
+	"strings"
+	"url-shortener/domain"
+	"url-shortener/logs"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreateUser  = errors.New("create user failed")
+	ErrEmailExists = errors.New("email exists already")
+)
+
+type userUsecase struct {
+	userRepo domain.UserRepository
+}
+
+func NewUserUsecase(userRepo domain.UserRepository) *userUsecase {
+	return &userUsecase{userRepo}
+}
+
+func (u *userUsecase) FindByID(id uuid.UUID) (*models.User, error) {
+	user, err := u.userRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return user, nil
+}
+
+func (u *userUsecase) FindByEmail(email string) (*models.User, error) {
+	user, err := u.userRepo.FindByEmail(strings.ToLower(email))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return user, nil
+}
+
+func (u *userUsecase) CreateUser(req *domain.CreateUserRequest) (*models.User, error) {
+	email := strings.ToLower(req.Email)
+
+	_, err := u.userRepo.FindByEmail(email)
+	if err == nil {
+		return nil, ErrEmailExists
+	} else if err != gorm.ErrRecordNotFound {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	user := &models.User{
+		ID:      uuid.New(),
+		Email:   email,
+		IsAdmin: req.IsAdmin,
+	}
+	if err := u.userRepo.Create(user); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateUser
+	}
+
+	return user, nil
+}
@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewUserUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mock := mockDomain.NewMockUserRepository(ctrl)
+	usecase := NewUserUsecase(mock)
+
+	assert.NotNil(t, usecase.userRepo)
+}
+
+func TestUserCreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	request := &domain.CreateUserRequest{Email: "Foo@Example.com"}
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+		}, {
+			name: "email exists",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(&models.User{}, nil)
+			},
+			expectedErr: ErrEmailExists,
+		}, {
+			name: "error FindByEmail()",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		}, {
+			name: "error Create()",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(nil, gorm.ErrRecordNotFound)
+				mr.EXPECT().Create(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrCreateUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockUserRepository(ctrl)
+			usecase := NewUserUsecase(mock)
+			tt.setup(mock)
+
+			user, err := usecase.CreateUser(request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "foo@example.com", user.Email)
+			}
+		})
+	}
+}
+
+func TestUserFindByEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(&models.User{Email: "foo@example.com"}, nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByEmail("foo@example.com").Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockUserRepository(ctrl)
+			usecase := NewUserUsecase(mock)
+			tt.setup(mock)
+
+			user, err := usecase.FindByEmail("Foo@Example.com")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "foo@example.com", user.Email)
+			}
+		})
+	}
+}
+
+func TestUserFindByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	id := uuid.New()
+	tests := []struct {
+		name        string
+		setup       func(mr *mockDomain.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name: "success",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByID(id).Return(&models.User{ID: id}, nil)
+			},
+		}, {
+			name: "not found",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByID(id).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedErr: gorm.ErrRecordNotFound,
+		}, {
+			name: "error",
+			setup: func(mr *mockDomain.MockUserRepository) {
+				mr.EXPECT().FindByID(id).Return(nil, errors.New("error"))
+			},
+			expectedErr: ErrUnexpected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockUserRepository(ctrl)
+			usecase := NewUserUsecase(mock)
+			tt.setup(mock)
+
+			user, err := usecase.FindByID(id)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, id, user.ID)
+			}
+		})
+	}
+}
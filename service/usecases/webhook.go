@@ -0,0 +1,470 @@
+package usecases
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"url-shortener/domain"
+	"url-shortener/helpers"
+	"url-shortener/logs"
+	"url-shortener/metrics"
+	"url-shortener/models"
+	"url-shortener/utils/refresh"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Events published to webhooks.
+const (
+	EventLinkCreated   = "link.created"
+	EventLinkUpdated   = "link.updated"
+	EventLinkDeleted   = "link.deleted"
+	EventLinkExpired   = "link.expired"
+	EventLinkMilestone = "link.milestone"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretLength = 32
+	maxWebhooks         = 10
+	webhookTimeout      = 10 * time.Second
+	webhookBatchSize    = 50
+	webhookConcurrency  = 10
+	webhookLease        = 2 * time.Minute
+	webhookMaxAttempts  = 10
+	webhookRetryBase    = 30 * time.Second
+	webhookRetryMax     = 4 * time.Hour
+	deadLetterLimit     = 100
+
+	subscribersInterval = 30 * time.Second
+	subscribersRetry    = 5 * time.Second
+)
+
+var (
+	ErrCreateWebhook   = errors.New("create webhook failed")
+	ErrDeleteWebhook   = errors.New("delete webhook failed")
+	ErrTooManyWebhooks = errors.New("too many webhooks")
+)
+
+type webhookUsecase struct {
+	webhookRepo domain.WebhookRepository
+	policy      domain.DestinationPolicyUsecase
+	milestones  []int
+	client      *http.Client
+	deliverMu   sync.Mutex
+
+	// visitorSubscribers tells whether anyone listens to the events counted
+	// visits can trigger, so flushes can skip the lookups otherwise.
+	visitorSubscribers *refresh.Value[bool]
+}
+
+func NewWebhookUsecase(webhookRepo domain.WebhookRepository, policy domain.DestinationPolicyUsecase, milestones []int) *webhookUsecase {
+	u := &webhookUsecase{
+		webhookRepo: webhookRepo,
+		policy:      policy,
+		milestones:  milestones,
+		client:      newWebhookClient(),
+	}
+	u.visitorSubscribers = refresh.New(u.loadVisitorSubscribers, subscribersInterval, subscribersRetry)
+	return u
+}
+
+// NewWebhookUsecaseFromEnv reads the visitor milestones from
+// WEBHOOK_MILESTONES, a comma separated list of counts.
+func NewWebhookUsecaseFromEnv(webhookRepo domain.WebhookRepository, policy domain.DestinationPolicyUsecase) *webhookUsecase {
+	var milestones []int
+	for _, value := range strings.Split(helpers.Getenv("WEBHOOK_MILESTONES", "100,1000,10000,100000"), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		milestone, err := strconv.Atoi(value)
+		if err != nil || milestone <= 0 {
+			panic(fmt.Sprintf("invalid WEBHOOK_MILESTONES: %v", value))
+		}
+		milestones = append(milestones, milestone)
+	}
+	sort.Ints(milestones)
+
+	return NewWebhookUsecase(webhookRepo, policy, milestones)
+}
+
+// newWebhookClient refuses to connect to private addresses, whatever the
+// webhook's host resolves to at the time, and doesn't follow redirects.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if isPrivateHost(host) {
+				return ErrPrivateDestination
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (u *webhookUsecase) CreateWebhook(ownerID uuid.UUID, req *domain.CreateWebhookRequest) (*domain.CreateWebhookResponse, error) {
+	if err := u.policy.CheckDestination(req.URL); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := u.webhookRepo.FindByOwnerID(ownerID)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	if len(webhooks) >= maxWebhooks {
+		return nil, ErrTooManyWebhooks
+	}
+
+	random, err := helpers.StrSecureRandom(webhookSecretLength)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateWebhook
+	}
+	secret := webhookSecretPrefix + random
+
+	events := slices.Clone(req.Events)
+	slices.Sort(events)
+	webhook := &models.Webhook{
+		ID:      uuid.New(),
+		OwnerID: ownerID,
+		URL:     req.URL,
+		Secret:  secret,
+		Events:  slices.Compact(events),
+	}
+	if err := u.webhookRepo.Create(webhook); err != nil {
+		logs.Error(err.Error())
+		return nil, ErrCreateWebhook
+	}
+
+	u.visitorSubscribers.Reload()
+	return &domain.CreateWebhookResponse{Webhook: webhook, Secret: secret}, nil
+}
+
+func (u *webhookUsecase) ListWebhooks(ownerID uuid.UUID) ([]*models.Webhook, error) {
+	webhooks, err := u.webhookRepo.FindByOwnerID(ownerID)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return webhooks, nil
+}
+
+func (u *webhookUsecase) DeleteWebhook(ownerID uuid.UUID, id uuid.UUID) error {
+	webhook, err := u.findWebhook(ownerID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.webhookRepo.Delete(webhook); err != nil {
+		logs.Error(err.Error())
+		return ErrDeleteWebhook
+	}
+	return nil
+}
+
+// ListDeadLetters returns the latest deliveries that failed for good.
+func (u *webhookUsecase) ListDeadLetters(ownerID uuid.UUID, id uuid.UUID) ([]*models.WebhookDeadLetter, error) {
+	webhook, err := u.findWebhook(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := u.webhookRepo.FindDeadLetters(webhook.ID, deadLetterLimit)
+	if err != nil {
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+	return deadLetters, nil
+}
+
+// Publish queues the events for the webhooks of each link's owner that
+// subscribe to them. Events without a key are queued together.
+func (u *webhookUsecase) Publish(events ...*domain.WebhookEvent) error {
+	subscriptions := make(map[uuid.UUID][]*models.Webhook)
+	var batch []*models.WebhookDelivery
+
+	for _, event := range events {
+		if event.Link == nil || event.Link.OwnerID == nil {
+			continue
+		}
+
+		ownerID := *event.Link.OwnerID
+		webhooks, ok := subscriptions[ownerID]
+		if !ok {
+			var err error
+			if webhooks, err = u.webhookRepo.FindByOwnerID(ownerID); err != nil {
+				logs.Error(err.Error())
+				return ErrUnexpected
+			}
+			subscriptions[ownerID] = webhooks
+		}
+
+		deliveries, err := newDeliveries(event, webhooks)
+		if err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+		if len(deliveries) == 0 {
+			continue
+		}
+
+		if event.Key == "" {
+			batch = append(batch, deliveries...)
+		} else if _, err := u.webhookRepo.Enqueue(event.Key, deliveries); err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+	}
+
+	if len(batch) > 0 {
+		if _, err := u.webhookRepo.Enqueue("", batch); err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+	}
+	return nil
+}
+
+// PublishVisitors publishes the milestones a link passed, and its expiry if
+// the visit budget ran out, when its visitors went from before to after.
+func (u *webhookUsecase) PublishVisitors(shortLink *models.ShortLink, before int, after int) error {
+	var events []*domain.WebhookEvent
+	for _, milestone := range u.milestones {
+		if before < milestone && milestone <= after {
+			events = append(events, &domain.WebhookEvent{
+				Type:      EventLinkMilestone,
+				Link:      shortLink,
+				Milestone: milestone,
+				Key:       fmt.Sprintf("%v:%v:%v", EventLinkMilestone, shortLink.ID, milestone),
+			})
+		}
+	}
+	if shortLink.MaxVisits != nil && before < *shortLink.MaxVisits && *shortLink.MaxVisits <= after {
+		events = append(events, expiredEvent(shortLink))
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+	return u.Publish(events...)
+}
+
+// SubscribesToVisitors reports whether any webhook listens to milestones or
+// expiries. Webhooks created on another replica are seen within 30 seconds.
+func (u *webhookUsecase) SubscribesToVisitors() bool {
+	return u.visitorSubscribers.Get()
+}
+
+// loadVisitorSubscribers counts a failed lookup as no subscribers, as the
+// links couldn't be read to publish anything either.
+func (u *webhookUsecase) loadVisitorSubscribers() (bool, error) {
+	found, err := u.webhookRepo.HasSubscribers([]string{EventLinkMilestone, EventLinkExpired})
+	if err != nil {
+		logs.Error(err.Error())
+	}
+	return found, err
+}
+
+// Deliver sends the deliveries that are due, batch after batch until none
+// are left. Failed ones are retried with exponential backoff and moved to
+// the dead letters after the last attempt. Calls made while it is sending
+// return at once.
+func (u *webhookUsecase) Deliver() error {
+	if !u.deliverMu.TryLock() {
+		return nil
+	}
+	defer u.deliverMu.Unlock()
+
+	for {
+		deliveries, err := u.webhookRepo.ClaimDeliveries(webhookBatchSize, webhookLease)
+		if err != nil {
+			logs.Error(err.Error())
+			return ErrUnexpected
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, webhookConcurrency)
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				u.attempt(delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+}
+
+func (u *webhookUsecase) attempt(delivery *models.WebhookDelivery) {
+	// The webhook was deleted after the delivery was claimed.
+	if delivery.Webhook == nil {
+		if err := u.webhookRepo.DeleteDelivery(delivery); err != nil {
+			logs.Error(err.Error())
+		}
+		return
+	}
+
+	err := u.send(delivery)
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+		if err := u.webhookRepo.DeleteDelivery(delivery); err != nil {
+			logs.Error(err.Error())
+		}
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = truncate(err.Error(), 512)
+	if delivery.Attempts >= webhookMaxAttempts {
+		metrics.WebhookDeliveries.WithLabelValues("dead").Inc()
+		logs.Error(fmt.Sprintf("webhook delivery %v to %v failed for good: %v", delivery.ID, delivery.Webhook.URL, delivery.LastError))
+		if err := u.webhookRepo.DeadLetter(delivery); err != nil {
+			logs.Error(err.Error())
+		}
+		return
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues("retried").Inc()
+	delivery.NextAttemptAt = time.Now().Add(retryDelay(delivery.Attempts))
+	if err := u.webhookRepo.RetryDelivery(delivery); err != nil {
+		logs.Error(err.Error())
+	}
+}
+
+func (u *webhookUsecase) send(delivery *models.WebhookDelivery) error {
+	req, err := http.NewRequest(http.MethodPost, delivery.Webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "url-shortener-webhooks")
+	req.Header.Set("X-Webhook-Id", delivery.ID.String())
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", res.StatusCode)
+	}
+	return nil
+}
+
+func (u *webhookUsecase) findWebhook(ownerID uuid.UUID, id uuid.UUID) (*models.Webhook, error) {
+	webhook, err := u.webhookRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		logs.Error(err.Error())
+		return nil, ErrUnexpected
+	}
+
+	if webhook.OwnerID != ownerID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func newDeliveries(event *domain.WebhookEvent, webhooks []*models.Webhook) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	var payload []byte
+
+	for _, webhook := range webhooks {
+		if !slices.Contains(webhook.Events, event.Type) {
+			continue
+		}
+
+		if payload == nil {
+			event.ID = uuid.New()
+			event.CreatedAt = time.Now()
+
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				return nil, err
+			}
+		}
+
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			ID:            uuid.New(),
+			WebhookID:     webhook.ID,
+			Event:         event.Type,
+			Payload:       string(payload),
+			NextAttemptAt: event.CreatedAt,
+		})
+	}
+
+	return deliveries, nil
+}
+
+// expiredEvent is keyed by link, so a link that runs out of visits and is
+// past its expiry as well is only reported once.
+func expiredEvent(shortLink *models.ShortLink) *domain.WebhookEvent {
+	return &domain.WebhookEvent{
+		Type: EventLinkExpired,
+		Link: shortLink,
+		Key:  fmt.Sprintf("%v:%v", EventLinkExpired, shortLink.ID),
+	}
+}
+
+// signWebhook signs the timestamp together with the payload, so a captured
+// delivery can't be replayed later with a new timestamp.
+func signWebhook(secret string, timestamp string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func retryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
@@ -0,0 +1,310 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/domain"
+	mockDomain "url-shortener/domain/mocks"
+	"url-shortener/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestNewWebhookUsecaseFromEnv(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Setenv("WEBHOOK_MILESTONES", "1000, 50,")
+	usecase := NewWebhookUsecaseFromEnv(mockDomain.NewMockWebhookRepository(ctrl), SetupDestinationPolicy(ctrl))
+	assert.Equal(t, []int{50, 1000}, usecase.milestones)
+
+	t.Setenv("WEBHOOK_MILESTONES", "100,lots")
+	assert.Panics(t, func() {
+		NewWebhookUsecaseFromEnv(mockDomain.NewMockWebhookRepository(ctrl), SetupDestinationPolicy(ctrl))
+	})
+}
+
+func TestWebhookSubscribesToVisitors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	mock := mockDomain.NewMockWebhookRepository(ctrl)
+	usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), nil)
+
+	// The answer is kept until the next refresh.
+	mock.EXPECT().HasSubscribers([]string{EventLinkMilestone, EventLinkExpired}).Return(true, nil)
+	assert.True(t, usecase.SubscribesToVisitors())
+	assert.True(t, usecase.SubscribesToVisitors())
+
+	mock.EXPECT().HasSubscribers(gomock.Any()).Return(false, errors.New("error"))
+	assert.True(t, usecase.visitorSubscribers.Reload())
+}
+
+func TestWebhookCreateWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+
+	tests := []struct {
+		name        string
+		request     *domain.CreateWebhookRequest
+		setup       func(mr *mockDomain.MockWebhookRepository)
+		expectedErr error
+	}{
+		{
+			name:    "success",
+			request: &domain.CreateWebhookRequest{URL: "https://crm.example.com/hooks", Events: []string{EventLinkDeleted, EventLinkCreated, EventLinkDeleted}},
+			setup: func(mr *mockDomain.MockWebhookRepository) {
+				mr.EXPECT().FindByOwnerID(ownerID).Return([]*models.Webhook{}, nil)
+				mr.EXPECT().Create(gomock.Any()).DoAndReturn(func(webhook *models.Webhook) error {
+					assert.Equal(t, ownerID, webhook.OwnerID)
+					assert.Equal(t, []string{EventLinkCreated, EventLinkDeleted}, webhook.Events)
+					assert.True(t, strings.HasPrefix(webhook.Secret, webhookSecretPrefix))
+					return nil
+				})
+				mr.EXPECT().HasSubscribers([]string{EventLinkMilestone, EventLinkExpired}).Return(false, nil)
+			},
+		}, {
+			name:        "error private url",
+			request:     &domain.CreateWebhookRequest{URL: "http://127.0.0.1/hooks", Events: []string{EventLinkCreated}},
+			setup:       func(mr *mockDomain.MockWebhookRepository) {},
+			expectedErr: ErrPrivateDestination,
+		}, {
+			name:    "error too many webhooks",
+			request: &domain.CreateWebhookRequest{URL: "https://crm.example.com/hooks", Events: []string{EventLinkCreated}},
+			setup: func(mr *mockDomain.MockWebhookRepository) {
+				mr.EXPECT().FindByOwnerID(ownerID).Return(make([]*models.Webhook, maxWebhooks), nil)
+			},
+			expectedErr: ErrTooManyWebhooks,
+		}, {
+			name:    "error Create()",
+			request: &domain.CreateWebhookRequest{URL: "https://crm.example.com/hooks", Events: []string{EventLinkCreated}},
+			setup: func(mr *mockDomain.MockWebhookRepository) {
+				mr.EXPECT().FindByOwnerID(ownerID).Return([]*models.Webhook{}, nil)
+				mr.EXPECT().Create(gomock.Any()).Return(errors.New("error"))
+			},
+			expectedErr: ErrCreateWebhook,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockWebhookRepository(ctrl)
+			usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), nil)
+			tt.setup(mock)
+
+			res, err := usecase.CreateWebhook(ownerID, tt.request)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, res)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, res.Webhook.Secret, res.Secret)
+			}
+		})
+	}
+}
+
+func TestWebhookDeleteWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	webhook := &models.Webhook{ID: uuid.New(), OwnerID: ownerID}
+
+	mock := mockDomain.NewMockWebhookRepository(ctrl)
+	usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), nil)
+
+	mock.EXPECT().FindByID(webhook.ID).Return(webhook, nil)
+	mock.EXPECT().Delete(webhook).Return(nil)
+	assert.NoError(t, usecase.DeleteWebhook(ownerID, webhook.ID))
+
+	mock.EXPECT().FindByID(webhook.ID).Return(webhook, nil)
+	assert.ErrorIs(t, usecase.DeleteWebhook(uuid.New(), webhook.ID), gorm.ErrRecordNotFound)
+
+	mock.EXPECT().FindByID(webhook.ID).Return(webhook, nil)
+	mock.EXPECT().Delete(webhook).Return(errors.New("error"))
+	assert.ErrorIs(t, usecase.DeleteWebhook(ownerID, webhook.ID), ErrDeleteWebhook)
+
+	mock.EXPECT().FindByID(webhook.ID).Return(webhook, nil)
+	mock.EXPECT().FindDeadLetters(webhook.ID, deadLetterLimit).Return([]*models.WebhookDeadLetter{{ID: uuid.New()}}, nil)
+	deadLetters, err := usecase.ListDeadLetters(ownerID, webhook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, deadLetters, 1)
+}
+
+func TestWebhookPublish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	ownerID := uuid.New()
+	shortLink := &models.ShortLink{ID: uuid.New(), OwnerID: &ownerID, SlashCode: "foo"}
+	webhooks := []*models.Webhook{
+		{ID: uuid.New(), Events: []string{EventLinkCreated, EventLinkMilestone}},
+		{ID: uuid.New(), Events: []string{EventLinkDeleted}},
+	}
+
+	mock := mockDomain.NewMockWebhookRepository(ctrl)
+	usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), []int{100, 1000})
+
+	mock.EXPECT().FindByOwnerID(ownerID).Return(webhooks, nil)
+	mock.EXPECT().Enqueue("", gomock.Any()).DoAndReturn(func(key string, deliveries []*models.WebhookDelivery) (bool, error) {
+		assert.Len(t, deliveries, 2)
+		assert.Equal(t, webhooks[0].ID, deliveries[0].WebhookID)
+		assert.Equal(t, EventLinkCreated, deliveries[0].Event)
+
+		event := &domain.WebhookEvent{}
+		assert.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), event))
+		assert.Equal(t, EventLinkCreated, event.Type)
+		assert.Equal(t, "foo", event.Link.SlashCode)
+		assert.NotEqual(t, uuid.Nil, event.ID)
+		return true, nil
+	})
+	err := usecase.Publish(
+		&domain.WebhookEvent{Type: EventLinkCreated, Link: shortLink},
+		&domain.WebhookEvent{Type: EventLinkUpdated, Link: shortLink},
+		&domain.WebhookEvent{Type: EventLinkCreated, Link: shortLink},
+		&domain.WebhookEvent{Type: EventLinkCreated, Link: &models.ShortLink{}},
+	)
+	assert.NoError(t, err)
+
+	// 1 to 1000 passes both milestones, each published once under its own key.
+	mock.EXPECT().FindByOwnerID(ownerID).Return(webhooks, nil)
+	mock.EXPECT().Enqueue("link.milestone:"+shortLink.ID.String()+":100", gomock.Len(1)).Return(true, nil)
+	mock.EXPECT().Enqueue("link.milestone:"+shortLink.ID.String()+":1000", gomock.Len(1)).Return(false, nil)
+	assert.NoError(t, usecase.PublishVisitors(shortLink, 1, 1000))
+
+	assert.NoError(t, usecase.PublishVisitors(shortLink, 100, 999))
+
+	mock.EXPECT().FindByOwnerID(ownerID).Return(nil, errors.New("error"))
+	assert.ErrorIs(t, usecase.Publish(&domain.WebhookEvent{Type: EventLinkCreated, Link: shortLink}), ErrUnexpected)
+}
+
+func TestWebhookPublishVisitorsExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ownerID := uuid.New()
+	maxVisits := 50
+	shortLink := &models.ShortLink{ID: uuid.New(), OwnerID: &ownerID, MaxVisits: &maxVisits}
+
+	mock := mockDomain.NewMockWebhookRepository(ctrl)
+	usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), nil)
+
+	mock.EXPECT().FindByOwnerID(ownerID).Return([]*models.Webhook{{ID: uuid.New(), Events: []string{EventLinkExpired}}}, nil)
+	mock.EXPECT().Enqueue("link.expired:"+shortLink.ID.String(), gomock.Len(1)).Return(true, nil)
+	assert.NoError(t, usecase.PublishVisitors(shortLink, 48, 52))
+
+	assert.NoError(t, usecase.PublishVisitors(shortLink, 50, 52))
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closeLog := SetupLogger(t)
+	defer closeLog()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Webhook-Timestamp")
+		if r.Header.Get("X-Webhook-Signature") != "sha256="+signWebhook("secret", timestamp, string(body)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Webhook-Event") != EventLinkCreated {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{ID: uuid.New(), URL: server.URL, Secret: "secret"}
+	tests := []struct {
+		name     string
+		delivery *models.WebhookDelivery
+		setup    func(mr *mockDomain.MockWebhookRepository, delivery *models.WebhookDelivery)
+	}{
+		{
+			name:     "delivered",
+			delivery: &models.WebhookDelivery{ID: uuid.New(), Webhook: webhook, Event: EventLinkCreated, Payload: `{"type":"link.created"}`},
+			setup: func(mr *mockDomain.MockWebhookRepository, delivery *models.WebhookDelivery) {
+				mr.EXPECT().DeleteDelivery(delivery).Return(nil)
+			},
+		}, {
+			name:     "retried",
+			delivery: &models.WebhookDelivery{ID: uuid.New(), Webhook: webhook, Event: EventLinkDeleted, Payload: "{}", Attempts: 2},
+			setup: func(mr *mockDomain.MockWebhookRepository, delivery *models.WebhookDelivery) {
+				mr.EXPECT().RetryDelivery(delivery).DoAndReturn(func(delivery *models.WebhookDelivery) error {
+					assert.Equal(t, 3, delivery.Attempts)
+					assert.Equal(t, "unexpected status 500", delivery.LastError)
+					assert.WithinDuration(t, time.Now().Add(2*time.Minute), delivery.NextAttemptAt, time.Second)
+					return nil
+				})
+			},
+		}, {
+			name:     "dead",
+			delivery: &models.WebhookDelivery{ID: uuid.New(), Webhook: webhook, Event: EventLinkDeleted, Payload: "{}", Attempts: webhookMaxAttempts - 1},
+			setup: func(mr *mockDomain.MockWebhookRepository, delivery *models.WebhookDelivery) {
+				mr.EXPECT().DeadLetter(delivery).Return(nil)
+			},
+		}, {
+			name:     "webhook deleted",
+			delivery: &models.WebhookDelivery{ID: uuid.New(), Event: EventLinkCreated, Payload: "{}"},
+			setup: func(mr *mockDomain.MockWebhookRepository, delivery *models.WebhookDelivery) {
+				mr.EXPECT().DeleteDelivery(delivery).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDomain.NewMockWebhookRepository(ctrl)
+			usecase := NewWebhookUsecase(mock, SetupDestinationPolicy(ctrl), nil)
+			usecase.client = server.Client()
+
+			mock.EXPECT().ClaimDeliveries(webhookBatchSize, webhookLease).Return([]*models.WebhookDelivery{tt.delivery}, nil)
+			tt.setup(mock, tt.delivery)
+
+			assert.NoError(t, usecase.Deliver())
+		})
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := newWebhookClient().Post(server.URL, "application/json", strings.NewReader("{}"))
+	assert.ErrorIs(t, err, ErrPrivateDestination)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(1))
+	assert.Equal(t, time.Minute, retryDelay(2))
+	assert.Equal(t, 128*time.Minute, retryDelay(9))
+	assert.Equal(t, webhookRetryMax, retryDelay(20))
+}
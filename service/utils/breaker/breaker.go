@@ -0,0 +1,118 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker opens after threshold consecutive failures and rejects calls for
+// cooldown. After that a single trial call is let through: it closes the
+// breaker on success and reopens it on failure.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	onChange  func(from State, to State)
+
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+	mu       sync.Mutex
+}
+
+func New(threshold int, cooldown time.Duration, onChange func(from State, to State)) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, onChange: onChange}
+}
+
+// Allow returns ErrOpen when the call shouldn't be attempted. Every allowed
+// call must be followed by Done.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+	case HalfOpen:
+		if b.trial {
+			return ErrOpen
+		}
+	default:
+		return nil
+	}
+
+	b.trial = true
+	return nil
+}
+
+func (b *Breaker) Done(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen && b.trial {
+		b.trial = false
+		if failed {
+			b.open()
+		} else {
+			b.failures = 0
+			b.setState(Closed)
+		}
+		return
+	}
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == Closed && b.failures >= b.threshold {
+		b.open()
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.failures = 0
+	b.openedAt = time.Now()
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	from := b.state
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(from, state)
+	}
+}
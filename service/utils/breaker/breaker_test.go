@@ -0,0 +1,45 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	var changes []State
+	b := New(3, 20*time.Millisecond, func(from State, to State) {
+		changes = append(changes, to)
+	})
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Allow())
+		b.Done(true)
+	}
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, Closed, b.State())
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Allow())
+		b.Done(true)
+	}
+	assert.Equal(t, Open, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, b.Allow())
+	assert.Equal(t, HalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), ErrOpen)
+	b.Done(true)
+	assert.Equal(t, Open, b.State())
+
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Done(false)
+	assert.Equal(t, Closed, b.State())
+	assert.NoError(t, b.Allow())
+
+	assert.Equal(t, []State{Open, HalfOpen, Open, HalfOpen, Closed}, changes)
+}
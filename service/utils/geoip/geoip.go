@@ -0,0 +1,54 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Reader looks up countries in a local MaxMind database, such as GeoLite2
+// Country. A nil Reader knows no countries.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+func Open(path string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{db}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country ip is in, or
+// an empty string if it isn't known.
+func (r *Reader) Country(ip string) string {
+	if r == nil {
+		return ""
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+
+	var rec record
+	if err := r.db.Lookup(addr, &rec); err != nil {
+		return ""
+	}
+	return strings.ToUpper(rec.Country.ISOCode)
+}
+
+func (r *Reader) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.db.Close()
+}
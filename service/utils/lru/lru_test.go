@@ -0,0 +1,57 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](2)
+	c.nowFunc = func() time.Time { return now }
+
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set("c", 3, time.Minute)
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	assert.Equal(t, 2, c.Len())
+
+	c.Set("a", 4, time.Minute)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 4, value)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+
+	c.Purge()
+	assert.Equal(t, 0, c.Len())
+	c.Set("c", 3, time.Minute)
+
+	c.Set("d", 5, 0)
+	_, ok = c.Get("d")
+	assert.False(t, ok, "entries without ttl aren't stored")
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("c")
+	assert.False(t, ok, "expired entry is dropped")
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache[string, int]
+
+	c.Set("a", 1, time.Minute)
+	c.Delete("a")
+	c.Purge()
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,85 @@
+package refresh
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Value holds data loaded from a slower source such as the database. Once
+// the data is older than interval it is reloaded in the background while
+// readers keep getting the previous data. A failed load is retried after
+// backoff, so an outage doesn't send every reader to the source.
+type Value[T any] struct {
+	load     func() (T, error)
+	interval time.Duration
+	backoff  time.Duration
+
+	data     atomic.Pointer[T]
+	nextLoad atomic.Int64
+	loading  atomic.Bool
+	mu       sync.Mutex
+	nowFunc  func() time.Time
+}
+
+func New[T any](load func() (T, error), interval time.Duration, backoff time.Duration) *Value[T] {
+	return &Value[T]{load: load, interval: interval, backoff: backoff, nowFunc: time.Now}
+}
+
+// Get only waits for the source on the first load. Until a load succeeds it
+// returns the zero value, trying again at most once per backoff.
+func (v *Value[T]) Get() T {
+	if data := v.data.Load(); data != nil {
+		if v.due() && v.loading.CompareAndSwap(false, true) {
+			go func() {
+				defer v.loading.Store(false)
+				v.Reload()
+			}()
+		}
+		return *data
+	}
+
+	if !v.due() {
+		var zero T
+		return zero
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if data := v.data.Load(); data != nil || !v.due() {
+		return v.current()
+	}
+	return v.reload()
+}
+
+// Reload loads the data at once, after a change made by this process. It
+// returns the previous data if the load fails.
+func (v *Value[T]) Reload() T {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.reload()
+}
+
+func (v *Value[T]) reload() T {
+	data, err := v.load()
+	if err != nil {
+		v.nextLoad.Store(v.nowFunc().Add(v.backoff).UnixNano())
+		return v.current()
+	}
+
+	v.data.Store(&data)
+	v.nextLoad.Store(v.nowFunc().Add(v.interval).UnixNano())
+	return data
+}
+
+func (v *Value[T]) current() T {
+	if data := v.data.Load(); data != nil {
+		return *data
+	}
+	var zero T
+	return zero
+}
+
+func (v *Value[T]) due() bool {
+	return v.nowFunc().UnixNano() >= v.nextLoad.Load()
+}
@@ -0,0 +1,64 @@
+package refresh
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue(t *testing.T) {
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	advance := func(d time.Duration) { now.Add(int64(d)) }
+
+	var calls atomic.Int32
+	var fail atomic.Bool
+	result := atomic.Int32{}
+	v := New(func() (int, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return 0, errors.New("error")
+		}
+		return int(result.Load()), nil
+	}, time.Minute, 10*time.Second)
+	v.nowFunc = func() time.Time { return time.Unix(0, now.Load()) }
+
+	// A failed first load is retried only after the backoff.
+	fail.Store(true)
+	assert.Equal(t, 0, v.Get())
+	assert.Equal(t, 0, v.Get())
+	assert.Equal(t, int32(1), calls.Load())
+
+	advance(10 * time.Second)
+	fail.Store(false)
+	result.Store(1)
+	assert.Equal(t, 1, v.Get())
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Fresh data doesn't reach the source.
+	result.Store(2)
+	assert.Equal(t, 1, v.Get())
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Stale data is served while it's reloaded in the background.
+	advance(time.Minute)
+	assert.Equal(t, 1, v.Get())
+	assert.Eventually(t, func() bool { return v.Get() == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// A failed reload keeps the data and backs off.
+	advance(time.Minute)
+	fail.Store(true)
+	assert.Equal(t, 2, v.Get())
+	assert.Eventually(t, func() bool { return !v.loading.Load() }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, v.Get())
+	assert.Equal(t, int32(4), calls.Load())
+
+	fail.Store(false)
+	result.Store(3)
+	assert.Equal(t, 3, v.Reload())
+	assert.Equal(t, 3, v.Get())
+}
@@ -0,0 +1,27 @@
+package slashcode
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+type randomGenerator struct {
+	alphabet string
+}
+
+func NewRandom(alphabet string) *randomGenerator {
+	return &randomGenerator{alphabet}
+}
+
+func (g *randomGenerator) Generate(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(g.alphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = g.alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,50 @@
+package slashcode
+
+import (
+	"context"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type sequenceGenerator struct {
+	rdb      *redis.Client
+	key      string
+	alphabet string
+}
+
+func NewSequence(rdb *redis.Client, key string, alphabet string) *sequenceGenerator {
+	return &sequenceGenerator{rdb, key, alphabet}
+}
+
+// Generate encodes the next value of a Redis counter, padded to length. The
+// code grows past length once the counter no longer fits.
+func (g *sequenceGenerator) Generate(length int) (string, error) {
+	n, err := g.rdb.Incr(context.Background(), g.key).Result()
+	if err != nil {
+		return "", err
+	}
+
+	code := encode(uint64(n), g.alphabet)
+	if len(code) < length {
+		code = strings.Repeat(g.alphabet[:1], length-len(code)) + code
+	}
+	return code, nil
+}
+
+func encode(n uint64, alphabet string) string {
+	base := uint64(len(alphabet))
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	var b []byte
+	for n > 0 {
+		b = append(b, alphabet[n%base])
+		n /= base
+	}
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,50 @@
+package slashcode
+
+import (
+	"fmt"
+	"strconv"
+	"url-shortener/domain"
+	"url-shortener/helpers"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	DefaultLength = 6
+	MaxLength     = 12
+
+	Base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// ReadableAlphabet leaves out characters that are easily mistaken for one
+	// another: 0/O/o, 1/l/I.
+	ReadableAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz"
+
+	sequenceKey = "slash_code_sequence"
+)
+
+func NewGenerator(strategy string, rdb *redis.Client) (domain.SlashCodeGenerator, error) {
+	switch strategy {
+	case "random":
+		return NewRandom(Base62Alphabet), nil
+	case "readable":
+		return NewRandom(ReadableAlphabet), nil
+	case "sequence":
+		return NewSequence(rdb, sequenceKey, Base62Alphabet), nil
+	}
+	return nil, fmt.Errorf("unknown slash code generator %q", strategy)
+}
+
+// NewFromEnv builds the generator and initial code length configured by
+// SLASH_CODE_GENERATOR and SLASH_CODE_LENGTH.
+func NewFromEnv(rdb *redis.Client) (domain.SlashCodeGenerator, int) {
+	generator, err := NewGenerator(helpers.Getenv("SLASH_CODE_GENERATOR", "random"), rdb)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	length, err := strconv.Atoi(helpers.Getenv("SLASH_CODE_LENGTH", strconv.Itoa(DefaultLength)))
+	if err != nil || length < 1 || length > MaxLength {
+		panic(fmt.Sprintf("SLASH_CODE_LENGTH must be between 1 and %v", MaxLength))
+	}
+
+	return generator, length
+}
@@ -0,0 +1,126 @@
+package slashcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupRedisMock(t *testing.T) (*miniredis.Miniredis, *redis.Client, func()) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub redis connection", err)
+	}
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr, rdb, func() {
+		rdb.Close()
+		mr.Close()
+	}
+}
+
+func TestNewGenerator(t *testing.T) {
+	_, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	tests := []struct {
+		strategy string
+		expected interface{}
+		isErr    bool
+	}{
+		{strategy: "random", expected: &randomGenerator{}},
+		{strategy: "readable", expected: &randomGenerator{}},
+		{strategy: "sequence", expected: &sequenceGenerator{}},
+		{strategy: "uuid", isErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			generator, err := NewGenerator(tt.strategy, rdb)
+			if tt.isErr {
+				assert.Error(t, err)
+				assert.Nil(t, generator)
+			} else {
+				assert.NoError(t, err)
+				assert.IsType(t, tt.expected, generator)
+			}
+		})
+	}
+}
+
+func TestRandomGenerate(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		length   int
+	}{
+		{name: "base62", alphabet: Base62Alphabet, length: 6},
+		{name: "readable", alphabet: ReadableAlphabet, length: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := NewRandom(tt.alphabet)
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				code, err := generator.Generate(tt.length)
+				assert.NoError(t, err)
+				assert.Len(t, code, tt.length)
+				for _, c := range code {
+					assert.True(t, strings.ContainsRune(tt.alphabet, c))
+				}
+				seen[code] = true
+			}
+			assert.Greater(t, len(seen), 90)
+		})
+	}
+}
+
+func TestReadableAlphabet(t *testing.T) {
+	for _, c := range "0Oo1lI" {
+		assert.False(t, strings.ContainsRune(ReadableAlphabet, c), string(c))
+	}
+}
+
+func TestSequenceGenerate(t *testing.T) {
+	mr, rdb, cleanup := SetupRedisMock(t)
+	defer cleanup()
+
+	generator := NewSequence(rdb, sequenceKey, Base62Alphabet)
+
+	code, err := generator.Generate(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "0001", code)
+
+	code, err = generator.Generate(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "0002", code)
+
+	mr.Set(sequenceKey, "14776335")
+	code, err = generator.Generate(4)
+	assert.NoError(t, err)
+	assert.Equal(t, "10000", code)
+
+	mr.SetError("error")
+	_, err = generator.Generate(4)
+	assert.Error(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		n        uint64
+		expected string
+	}{
+		{n: 0, expected: "0"},
+		{n: 61, expected: "z"},
+		{n: 62, expected: "10"},
+		{n: 3843, expected: "zz"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, encode(tt.n, Base62Alphabet))
+	}
+}
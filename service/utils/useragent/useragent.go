@@ -0,0 +1,66 @@
+package useragent
+
+import "strings"
+
+const (
+	OSIOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// crawlers are the user agents that fetch a page to unfurl a pasted link.
+var crawlers = []string{
+	"Slackbot",
+	"Twitterbot",
+	"facebookexternalhit",
+	"LinkedInBot",
+	"Discordbot",
+	"TelegramBot",
+	"WhatsApp",
+}
+
+// Parse tells the operating system and kind of device from a User-Agent
+// header. Both are empty when they can't be told. iPads asking for the
+// desktop site look like a Mac.
+func Parse(ua string) (os string, device string) {
+	switch {
+	case strings.Contains(ua, "iPad"):
+		return OSIOS, DeviceTablet
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		return OSIOS, DeviceMobile
+	case strings.Contains(ua, "Android"):
+		if strings.Contains(ua, "Mobile") {
+			return OSAndroid, DeviceMobile
+		}
+		return OSAndroid, DeviceTablet
+	case strings.Contains(ua, "Windows Phone"):
+		return OSWindows, DeviceMobile
+	case strings.Contains(ua, "Windows"):
+		return OSWindows, DeviceDesktop
+	case strings.Contains(ua, "CrOS"):
+		return OSChromeOS, DeviceDesktop
+	case strings.Contains(ua, "Macintosh"), strings.Contains(ua, "Mac OS X"):
+		return OSMacOS, DeviceDesktop
+	case strings.Contains(ua, "Linux"), strings.Contains(ua, "X11"):
+		return OSLinux, DeviceDesktop
+	}
+	return "", ""
+}
+
+// IsCrawler reports whether ua belongs to a chat app or social network
+// fetching a link preview.
+func IsCrawler(ua string) bool {
+	for _, crawler := range crawlers {
+		if strings.Contains(ua, crawler) {
+			return true
+		}
+	}
+	return false
+}
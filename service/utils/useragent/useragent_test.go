@@ -0,0 +1,90 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		ua             string
+		expectedOS     string
+		expectedDevice string
+	}{
+		{
+			name:           "iphone",
+			ua:             "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			expectedOS:     OSIOS,
+			expectedDevice: DeviceMobile,
+		}, {
+			name:           "ipad",
+			ua:             "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			expectedOS:     OSIOS,
+			expectedDevice: DeviceTablet,
+		}, {
+			name:           "android phone",
+			ua:             "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Mobile Safari/537.36",
+			expectedOS:     OSAndroid,
+			expectedDevice: DeviceMobile,
+		}, {
+			name:           "android tablet",
+			ua:             "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36",
+			expectedOS:     OSAndroid,
+			expectedDevice: DeviceTablet,
+		}, {
+			name:           "windows",
+			ua:             "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36",
+			expectedOS:     OSWindows,
+			expectedDevice: DeviceDesktop,
+		}, {
+			name:           "mac",
+			ua:             "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15",
+			expectedOS:     OSMacOS,
+			expectedDevice: DeviceDesktop,
+		}, {
+			name:           "chromebook",
+			ua:             "Mozilla/5.0 (X11; CrOS x86_64 15633.69.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36",
+			expectedOS:     OSChromeOS,
+			expectedDevice: DeviceDesktop,
+		}, {
+			name:           "linux",
+			ua:             "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/118.0",
+			expectedOS:     OSLinux,
+			expectedDevice: DeviceDesktop,
+		}, {
+			name: "unknown",
+			ua:   "curl/8.4.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os, device := Parse(tt.ua)
+			assert.Equal(t, tt.expectedOS, os)
+			assert.Equal(t, tt.expectedDevice, device)
+		})
+	}
+}
+
+func TestIsCrawler(t *testing.T) {
+	tests := []struct {
+		name     string
+		ua       string
+		expected bool
+	}{
+		{name: "slack", ua: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", expected: true},
+		{name: "twitter", ua: "Twitterbot/1.0", expected: true},
+		{name: "facebook", ua: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", expected: true},
+		{name: "discord", ua: "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", expected: true},
+		{name: "browser", ua: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15"},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsCrawler(tt.ua))
+		})
+	}
+}